@@ -12,13 +12,20 @@ import (
 
 	"github.com/bgaurav7/gin-microservice-boilerplate/config"
 	delivery "github.com/bgaurav7/gin-microservice-boilerplate/internal/delivery/http"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/certificate"
 	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/db"
 	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+	"github.com/bgaurav7/gin-microservice-boilerplate/migrations"
 )
 
 func main() {
 	// Check if -dsn flag is provided
 	printDSN := flag.Bool("dsn", false, "Print the database connection string and exit")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] [migrate|user|rbac <command>]\n\n", os.Args[0])
+		flag.PrintDefaults()
+		fmt.Fprintf(flag.CommandLine.Output(), "\n%s\n\n%s\n\n%s\n", migrateUsage, userUsage, rbacUsage)
+	}
 	flag.Parse()
 
 	// Load configuration
@@ -49,8 +56,40 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Run a migrate subcommand instead of the server if requested
+	if flag.Arg(0) == "migrate" {
+		os.Exit(runMigrate(database, flag.Args()[1:]))
+	}
+
+	// Run a user subcommand instead of the server if requested
+	if flag.Arg(0) == "user" {
+		os.Exit(runUser(cfg, log, database, flag.Args()[1:]))
+	}
+
+	// Run an rbac subcommand instead of the server if requested
+	if flag.Arg(0) == "rbac" {
+		os.Exit(runRBAC(cfg, log, database, flag.Args()[1:]))
+	}
+
+	// Apply pending migrations before serving traffic
+	if cfg.Database.MigrateOnStart {
+		migrator, err := db.NewMigrator(database, migrations.FS)
+		if err != nil {
+			log.Error("Failed to load migrations", map[string]interface{}{"error": err.Error()})
+			os.Exit(1)
+		}
+		if err := migrator.Up(context.Background()); err != nil {
+			log.Error("Failed to apply migrations", map[string]interface{}{"error": err.Error()})
+			os.Exit(1)
+		}
+	}
+
 	// Create router
-	router := delivery.NewRouter(log, database, cfg)
+	router, err := delivery.NewRouter(log, database, cfg)
+	if err != nil {
+		log.Error("Failed to create router", map[string]interface{}{"error": err.Error()})
+		os.Exit(1)
+	}
 
 	// Create HTTP server
 	server := &http.Server{
@@ -60,10 +99,31 @@ func main() {
 		WriteTimeout: time.Duration(cfg.Server.WriteTimeout) * time.Second,
 	}
 
+	// Serve HTTPS when configured, picking up renewed certificates without a restart
+	var certificates *certificate.Reloader
+	if cfg.Server.TLS.Enabled {
+		certificates, err = certificate.NewReloader(&cfg.Server.TLS, log)
+		if err != nil {
+			log.Error("Failed to load TLS certificate", map[string]interface{}{"error": err.Error()})
+			os.Exit(1)
+		}
+		if err := certificates.Watch(); err != nil {
+			log.Error("Failed to watch TLS certificate files", map[string]interface{}{"error": err.Error()})
+		}
+		server.TLSConfig = certificates.TLSConfig()
+	}
+
 	// Start server in a goroutine
 	go func() {
-		log.Info("Starting server", "addr", server.Addr)
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Info("Starting server", "addr", server.Addr, "tls", cfg.Server.TLS.Enabled)
+		var err error
+		if cfg.Server.TLS.Enabled {
+			// The certificate comes from the TLS config
+			err = server.ListenAndServeTLS("", "")
+		} else {
+			err = server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			log.Fatal("Failed to start server", "error", err)
 		}
 	}()
@@ -81,6 +141,14 @@ func main() {
 	if err := server.Shutdown(ctx); err != nil {
 		log.Fatal("Server forced to shutdown", "error", err)
 	}
+	if err := router.Close(); err != nil {
+		log.Error("Failed to stop router", map[string]interface{}{"error": err.Error()})
+	}
+	if certificates != nil {
+		if err := certificates.Close(); err != nil {
+			log.Error("Failed to stop watching TLS certificate files", map[string]interface{}{"error": err.Error()})
+		}
+	}
 
 	log.Info("Server exited")
 }
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/db"
+	"github.com/bgaurav7/gin-microservice-boilerplate/migrations"
+)
+
+// migrateUsage describes the migrate subcommand
+const migrateUsage = `usage: server migrate <command>
+
+commands:
+  up          apply all pending migrations
+  down N      revert the last N applied migrations
+  status      show the current version and the state of every migration
+  force V     record version V as applied and clear the dirty flag (0 for none)`
+
+// runMigrate executes a migrate subcommand and returns the process exit code
+func runMigrate(database *db.Database, args []string) int {
+	if len(args) == 0 {
+		fmt.Println(migrateUsage)
+		return 2
+	}
+
+	migrator, err := db.NewMigrator(database, migrations.FS)
+	if err != nil {
+		fmt.Printf("Failed to load migrations: %v\n", err)
+		return 1
+	}
+
+	ctx := context.Background()
+	switch {
+	case args[0] == "up" && len(args) == 1:
+		err = migrator.Up(ctx)
+
+	case args[0] == "down" && len(args) == 2:
+		n, convErr := strconv.Atoi(args[1])
+		if convErr != nil {
+			fmt.Printf("Invalid number of migrations %q\n", args[1])
+			return 2
+		}
+		err = migrator.Down(ctx, n)
+
+	case args[0] == "status" && len(args) == 1:
+		current, dirty, statuses, statusErr := migrator.Status(ctx)
+		if statusErr != nil {
+			err = statusErr
+			break
+		}
+		fmt.Printf("version: %d (dirty: %t)\n", current, dirty)
+		for _, status := range statuses {
+			state := "pending"
+			if status.Applied {
+				state = "applied"
+			}
+			fmt.Printf("%06d  %-8s %s\n", status.Version, state, status.Name)
+		}
+
+	case args[0] == "force" && len(args) == 2:
+		version, convErr := strconv.ParseUint(args[1], 10, 0)
+		if convErr != nil {
+			fmt.Printf("Invalid version %q\n", args[1])
+			return 2
+		}
+		err = migrator.Force(ctx, uint(version))
+
+	default:
+		fmt.Println(migrateUsage)
+		return 2
+	}
+
+	if err != nil {
+		fmt.Printf("Migration failed: %v\n", err)
+		return 1
+	}
+	return 0
+}
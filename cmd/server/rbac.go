@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/config"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/db"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/rbac"
+)
+
+// rbacUsage describes the rbac subcommand
+const rbacUsage = `usage: server rbac <command>
+
+commands:
+  explain SUBJECT TENANT OBJECT ACTION
+                 explain the decision of the active policy for a request, naming
+                 the matching rule and the role assignments leading to it
+  dry-run POLICY CASES
+                 evaluate the cases in the file CASES, one
+                 "subject, tenant, object, action, allow|deny" per line, with the
+                 proposed policy file POLICY. Exits with 1 if a case fails.`
+
+// runRBAC executes an rbac subcommand and returns the process exit code
+func runRBAC(cfg *config.Config, log *logger.Logger, database *db.Database, args []string) int {
+	switch {
+	case len(args) == 5 && args[0] == "explain":
+	case len(args) == 3 && args[0] == "dry-run":
+	default:
+		fmt.Println(rbacUsage)
+		return 2
+	}
+
+	enforcer, err := rbac.NewEnforcer(&cfg.RBAC, database, log)
+	if err != nil {
+		fmt.Printf("Failed to load RBAC model and policy: %v\n", err)
+		return 1
+	}
+	explainer := rbac.NewPolicyExplainer(enforcer, cfg.RBAC.Resources)
+	ctx := context.Background()
+
+	if args[0] == "explain" {
+		decision, err := explainer.Explain(ctx, model.PolicyQuery{
+			Subject: args[1],
+			Tenant:  args[2],
+			Object:  args[3],
+			Action:  strings.ToUpper(args[4]),
+		})
+		if err != nil {
+			fmt.Printf("Failed to explain decision: %v\n", err)
+			return 1
+		}
+		printDecision(decision)
+		return 0
+	}
+
+	policy, err := os.ReadFile(args[1])
+	if err != nil {
+		fmt.Printf("Failed to read policy: %v\n", err)
+		return 1
+	}
+	casesFile, err := os.Open(args[2])
+	if err != nil {
+		fmt.Printf("Failed to read cases: %v\n", err)
+		return 1
+	}
+	defer casesFile.Close()
+	cases, err := rbac.ParsePolicyCases(casesFile)
+	if err != nil {
+		fmt.Printf("Failed to read cases: %v\n", err)
+		return 1
+	}
+
+	run, err := explainer.DryRun(ctx, string(policy), cases)
+	if err != nil {
+		fmt.Printf("Failed to dry-run policy: %v\n", err)
+		return 1
+	}
+	for _, result := range run.Results {
+		if !result.Passed {
+			fmt.Printf("FAIL expected %s, got ", decisionWord(result.Expected))
+			printDecision(&result.PolicyDecision)
+		}
+	}
+	fmt.Printf("%d of %d cases passed\n", len(run.Results)-run.Failures, len(run.Results))
+	if !run.Passed {
+		return 1
+	}
+	return 0
+}
+
+// printDecision prints a policy decision and the rule and roles explaining it
+func printDecision(decision *model.PolicyDecision) {
+	fmt.Printf("%s: %s %s %s in %s\n", decisionWord(decision.Allowed), decision.Subject, decision.Action, decision.Object, decision.Tenant)
+	if decision.Rule != nil {
+		fmt.Printf("  rule:  p, %s, %s, %s, %s\n", decision.Rule.Subject, decision.Rule.Tenant, decision.Rule.Object, decision.Rule.Action)
+		fmt.Printf("  via:   %s\n", strings.Join(decision.RoleChain, " -> "))
+		return
+	}
+	if len(decision.Roles) == 0 {
+		fmt.Printf("  no rule matched; the subject holds no roles in %s\n", decision.Tenant)
+		return
+	}
+	fmt.Printf("  no rule matched; roles held in %s: %s\n", decision.Tenant, strings.Join(decision.Roles, ", "))
+}
+
+// decisionWord names a decision
+func decisionWord(allowed bool) string {
+	if allowed {
+		return "allow"
+	}
+	return "deny"
+}
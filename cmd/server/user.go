@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/config"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/db"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/jwt"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/password"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/repository"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/revocation"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/usecase"
+)
+
+// userUsage describes the user subcommand
+const userUsage = `usage: server user <command>
+
+commands:
+  create EMAIL   create an account, reading the password from the first line of stdin.
+                 Unlike /auth/register this may create the superadmin account.`
+
+// runUser executes a user subcommand and returns the process exit code
+func runUser(cfg *config.Config, log *logger.Logger, database *db.Database, args []string) int {
+	if len(args) != 2 || args[0] != "create" {
+		fmt.Println(userUsage)
+		return 2
+	}
+
+	secret, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && secret == "" {
+		fmt.Printf("Failed to read password from stdin: %v\n", err)
+		return 1
+	}
+
+	userRepo := repository.NewUserRepository(database, log)
+	refreshTokenRepo := repository.NewRefreshTokenRepository(database, log)
+	revocations := revocation.NewPostgresStore(database, log)
+	hasher := password.NewBcryptHasher(cfg.Auth.PasswordHashCost)
+	tokenService, err := jwt.NewTokenService(&cfg.Auth)
+	if err != nil {
+		fmt.Printf("Failed to create token service: %v\n", err)
+		return 1
+	}
+	authUsecase := usecase.NewAuthUsecase(userRepo, refreshTokenRepo, revocations, nil, nil, nil, nil, hasher, tokenService, &cfg.Auth, log)
+
+	user, err := authUsecase.Provision(context.Background(), args[1], strings.TrimRight(secret, "\r\n"))
+	if err != nil {
+		fmt.Printf("Failed to create user: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("Created user %d (%s)\n", user.ID, user.Email)
+	return 0
+}
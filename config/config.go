@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
 	"github.com/spf13/viper"
@@ -17,6 +18,7 @@ type Config struct {
 	Database DatabaseConfig `mapstructure:"database"`
 	Auth     AuthConfig     `mapstructure:"auth"`
 	RBAC     RBACConfig     `mapstructure:"rbac"`
+	Mailer   MailerConfig   `mapstructure:"mailer"`
 }
 
 // AppConfig represents the application configuration
@@ -31,8 +33,35 @@ type ServerConfig struct {
 	Port         int    `mapstructure:"port"`
 	ReadTimeout  int    `mapstructure:"read_timeout"`
 	WriteTimeout int    `mapstructure:"write_timeout"`
+	// TrustedProxies lists the addresses or CIDRs of reverse proxies whose
+	// X-Forwarded-For headers name the client IP. With none, the client IP
+	// is the peer address of the connection.
+	TrustedProxies []string  `mapstructure:"trusted_proxies"`
+	TLS            TLSConfig `mapstructure:"tls"`
 }
 
+// TLSConfig configures HTTPS. The certificate, key and client CA files are
+// reloaded when they change. When client certificates are verified, callers
+// presenting one and no token or API key are authenticated as the service
+// named by the certificate's first DNS SAN, or its CN without one.
+type TLSConfig struct {
+	Enabled  bool   `mapstructure:"enabled"`
+	CertFile string `mapstructure:"cert_file"`
+	KeyFile  string `mapstructure:"key_file"`
+	// ClientAuth is none, optional or require. Optional verifies client
+	// certificates that are presented; require refuses connections without one.
+	ClientAuth string `mapstructure:"client_auth"`
+	// ClientCAFile holds the PEM CA certificates client certificates must chain to
+	ClientCAFile string `mapstructure:"client_ca_file"`
+}
+
+// Supported client certificate modes
+const (
+	TLSClientAuthNone     = "none"
+	TLSClientAuthOptional = "optional"
+	TLSClientAuthRequire  = "require"
+)
+
 // DatabaseConfig represents the database configuration
 type DatabaseConfig struct {
 	Driver          string `mapstructure:"driver"`
@@ -45,21 +74,255 @@ type DatabaseConfig struct {
 	MaxIdleConns    int    `mapstructure:"max_idle_conns"`
 	MaxOpenConns    int    `mapstructure:"max_open_conns"`
 	ConnMaxLifetime int    `mapstructure:"conn_max_lifetime"`
+	MigrateOnStart  bool   `mapstructure:"migrate_on_start"`
 }
 
 // AuthConfig represents the authentication configuration
 type AuthConfig struct {
-	JWTSecret      string `mapstructure:"jwt_secret"`
-	JWTExpiryHours int    `mapstructure:"jwt_expiry_hours"`
-	SuperAdminEmail string `mapstructure:"superadmin_email"`
+	JWTSecret             string              `mapstructure:"jwt_secret"`
+	JWTAlgorithm          string              `mapstructure:"jwt_algorithm"`
+	JWTSigningKey         JWTKeyConfig        `mapstructure:"jwt_signing_key"`
+	JWTVerificationKeys   []JWTKeyConfig      `mapstructure:"jwt_verification_keys"`
+	AccessTokenTTLMinutes int                 `mapstructure:"access_token_ttl_minutes"`
+	RefreshTokenTTLHours  int                 `mapstructure:"refresh_token_ttl_hours"`
+	SuperAdminEmail       string              `mapstructure:"superadmin_email"`
+	PasswordHashCost      int                 `mapstructure:"password_hash_cost"`
+	RevocationStore       string              `mapstructure:"revocation_store"`
+	DefaultTenant         string              `mapstructure:"default_tenant"`
+	OIDC                  OIDCConfig          `mapstructure:"oidc"`
+	MagicLink             MagicLinkConfig     `mapstructure:"magic_link"`
+	MFA                   MFAConfig           `mapstructure:"mfa"`
+	Lockout               LockoutConfig       `mapstructure:"lockout"`
+	Impersonation         ImpersonationConfig `mapstructure:"impersonation"`
+
+	// PublicRoutes lists the paths served without authentication or
+	// authorization. A pattern ending in /** matches a route group, and
+	// * matches within a single path segment.
+	PublicRoutes []string `mapstructure:"public_routes"`
+}
+
+// OIDCConfig configures an external OpenID Connect provider whose tokens are
+// accepted alongside locally issued ones. Claim names may be dotted paths into
+// nested claims, such as "realm_access.roles".
+type OIDCConfig struct {
+	Enabled            bool   `mapstructure:"enabled"`
+	IssuerURL          string `mapstructure:"issuer_url"`
+	Audience           string `mapstructure:"audience"`
+	EmailClaim         string `mapstructure:"email_claim"`
+	SubjectClaim       string `mapstructure:"subject_claim"`
+	RolesClaim         string `mapstructure:"roles_claim"`
+	TenantClaim        string `mapstructure:"tenant_claim"`
+	JWKSRefreshMinutes int    `mapstructure:"jwks_refresh_minutes"`
+	ClockSkewSeconds   int    `mapstructure:"clock_skew_seconds"`
+}
+
+// MagicLinkConfig configures passwordless login. A login email carries both
+// a single-use link and a 6-digit code; either is exchanged for tokens at
+// POST /auth/verify.
+type MagicLinkConfig struct {
+	Enabled    bool `mapstructure:"enabled"`
+	TTLMinutes int  `mapstructure:"ttl_minutes"`
+	// URL is the page the link opens; the login token is appended as the
+	// token query parameter
+	URL             string `mapstructure:"url"`
+	MaxCodeAttempts int    `mapstructure:"max_code_attempts"`
+}
+
+// TTL returns how long login links and codes are valid
+func (c *MagicLinkConfig) TTL() time.Duration {
+	return time.Duration(c.TTLMinutes) * time.Minute
+}
+
+// MFAConfig configures multi-factor authentication with TOTP authenticator
+// apps. Any user may enroll; users it makes MFA mandatory for are asked to
+// enroll at their next login.
+type MFAConfig struct {
+	// Issuer names the service in authenticator apps
+	Issuer                string `mapstructure:"issuer"`
+	RequiredForSuperAdmin bool   `mapstructure:"required_for_superadmin"`
+	// RequiredRoles makes MFA mandatory for users holding any of the Casbin
+	// roles, directly or through other roles
+	RequiredRoles []string `mapstructure:"required_roles"`
+	// ChallengeTTLMinutes is how long a login may take between the password
+	// or login link and the second factor
+	ChallengeTTLMinutes int `mapstructure:"challenge_ttl_minutes"`
+}
+
+// ChallengeTTL returns how long the second factor of a login may be entered
+func (c *MFAConfig) ChallengeTTL() time.Duration {
+	return time.Duration(c.ChallengeTTLMinutes) * time.Minute
+}
+
+// LockoutConfig configures brute-force protection of the password, login
+// code and MFA code checks. Failures are counted per account and per client
+// IP. Past FreeAttempts, each attempt must wait BaseDelaySeconds, doubling
+// with every further failure; at MaxAttempts the key is locked out for
+// LockoutMinutes. Failures are forgotten LockoutMinutes after the last one,
+// and a successful login resets the account's count.
+type LockoutConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Store is where failures are counted: postgres or memory
+	Store            string        `mapstructure:"store"`
+	Account          LockoutLimits `mapstructure:"account"`
+	IP               LockoutLimits `mapstructure:"ip"`
+	BaseDelaySeconds int           `mapstructure:"base_delay_seconds"`
+	LockoutMinutes   int           `mapstructure:"lockout_minutes"`
+}
+
+// LockoutLimits are the failure thresholds of one kind of key
+type LockoutLimits struct {
+	// FreeAttempts is how many failures are allowed before backoff starts
+	FreeAttempts int `mapstructure:"free_attempts"`
+	// MaxAttempts is how many failures lock the key out; zero disables lockout
+	MaxAttempts int `mapstructure:"max_attempts"`
+}
+
+// BaseDelay returns the backoff after the first failure past the free attempts
+func (c *LockoutConfig) BaseDelay() time.Duration {
+	return time.Duration(c.BaseDelaySeconds) * time.Second
+}
+
+// LockoutDuration returns how long a key is locked out
+func (c *LockoutConfig) LockoutDuration() time.Duration {
+	return time.Duration(c.LockoutMinutes) * time.Minute
+}
+
+// Supported login attempt stores
+const (
+	LockoutStorePostgres = "postgres"
+	LockoutStoreMemory   = "memory"
+)
+
+// ImpersonationConfig configures superadmins acting as other users. The
+// tokens issued for it carry the superadmin in their act claim, cannot be
+// refreshed and expire after TTLMinutes.
+type ImpersonationConfig struct {
+	Enabled    bool `mapstructure:"enabled"`
+	TTLMinutes int  `mapstructure:"ttl_minutes"`
+}
+
+// TTL returns how long impersonation tokens are valid
+func (c *ImpersonationConfig) TTL() time.Duration {
+	return time.Duration(c.TTLMinutes) * time.Minute
+}
+
+// MailerConfig selects how emails are delivered
+type MailerConfig struct {
+	Driver string `mapstructure:"driver"`
+	From   string `mapstructure:"from"`
+	// Dir is where the file driver writes messages
+	Dir  string     `mapstructure:"dir"`
+	SMTP SMTPConfig `mapstructure:"smtp"`
+}
+
+// SMTPConfig configures the SMTP server the smtp mailer driver sends through
+type SMTPConfig struct {
+	Host     string `mapstructure:"host"`
+	Port     int    `mapstructure:"port"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+}
+
+// Supported mailer drivers
+const (
+	MailerDriverSMTP   = "smtp"
+	MailerDriverFile   = "file"
+	MailerDriverMemory = "memory"
+)
+
+// JWTKeyConfig identifies a PEM-encoded key used to sign or verify JWTs.
+// The signing key needs PrivateKeyPath; verification keys need PublicKeyPath.
+// ID is published as the kid header; when empty the RFC 7638 thumbprint of
+// the public key is used.
+type JWTKeyConfig struct {
+	ID             string `mapstructure:"id"`
+	PrivateKeyPath string `mapstructure:"private_key_path"`
+	PublicKeyPath  string `mapstructure:"public_key_path"`
+}
+
+// Supported token revocation stores
+const (
+	RevocationStorePostgres = "postgres"
+	RevocationStoreMemory   = "memory"
+)
+
+// DefaultTenant is the tenant of users and tokens that name none when
+// auth.default_tenant is not set
+const DefaultTenant = "default"
+
+// DefaultPublicRoutes are the public routes when auth.public_routes is not set
+var DefaultPublicRoutes = []string{
+	"/healthz",
+	"/readyz",
+	"/.well-known/**",
+	"/auth",
+	"/auth/login",
+	"/auth/register",
+	"/auth/refresh",
+	"/auth/magic-link",
+	"/auth/verify",
+	"/auth/login/mfa",
+	"/auth/login/mfa/enroll",
+	"/public/**",
+}
+
+// TenantOrDefault returns the tenant, or the default tenant when it is empty
+func (c *AuthConfig) TenantOrDefault(tenant string) string {
+	if tenant != "" {
+		return tenant
+	}
+	if c.DefaultTenant != "" {
+		return c.DefaultTenant
+	}
+	return DefaultTenant
+}
+
+// PublicRoutesOrDefault returns the public route patterns, or the default
+// ones when none are configured
+func (c *AuthConfig) PublicRoutesOrDefault() []string {
+	if len(c.PublicRoutes) > 0 {
+		return c.PublicRoutes
+	}
+	return DefaultPublicRoutes
+}
+
+// AccessTokenTTL returns how long issued access tokens are valid
+func (c *AuthConfig) AccessTokenTTL() time.Duration {
+	return time.Duration(c.AccessTokenTTLMinutes) * time.Minute
+}
+
+// RefreshTokenTTL returns how long issued refresh tokens are valid
+func (c *AuthConfig) RefreshTokenTTL() time.Duration {
+	return time.Duration(c.RefreshTokenTTLHours) * time.Hour
 }
 
 // RBACConfig represents the RBAC configuration
 type RBACConfig struct {
-	ModelPath  string `mapstructure:"model_path"`
-	PolicyPath string `mapstructure:"policy_path"`
+	ModelPath   string `mapstructure:"model_path"`
+	PolicyPath  string `mapstructure:"policy_path"`
+	PolicyStore string `mapstructure:"policy_store"`
+	Watch       bool   `mapstructure:"watch"`
+
+	// ReloadIntervalSeconds reloads the policy from the postgres store at this
+	// interval, so rules changed through other replicas are enforced. 0 disables.
+	ReloadIntervalSeconds int `mapstructure:"reload_interval_seconds"`
+
+	// Resources names route groups by path prefix, e.g. "/api/v1/todos": "todos".
+	// Routes in a named group are authorized as "<resource>:<route template>".
+	Resources map[string]string `mapstructure:"resources"`
+
+	// InsecureSkipAuthorization serves /api/v1 without authorization when the
+	// model or policy fails to load, instead of refusing to start. For local
+	// development only; it is rejected in the prod environment.
+	InsecureSkipAuthorization bool `mapstructure:"insecure_skip_authorization"`
 }
 
+// Supported RBAC policy stores
+const (
+	PolicyStorePostgres = "postgres"
+	PolicyStoreFile     = "file"
+)
+
 // Load loads the configuration from the config file and environment variables
 func Load() (*Config, error) {
 	// Determine which config file to load based on environment
@@ -107,6 +370,12 @@ func Load() (*Config, error) {
 	baseConfig.BindEnv("server.port", "SERVER_PORT")
 	baseConfig.BindEnv("server.read_timeout", "SERVER_READ_TIMEOUT")
 	baseConfig.BindEnv("server.write_timeout", "SERVER_WRITE_TIMEOUT")
+	baseConfig.BindEnv("server.trusted_proxies", "SERVER_TRUSTED_PROXIES")
+	baseConfig.BindEnv("server.tls.enabled", "SERVER_TLS_ENABLED")
+	baseConfig.BindEnv("server.tls.cert_file", "SERVER_TLS_CERT_FILE")
+	baseConfig.BindEnv("server.tls.key_file", "SERVER_TLS_KEY_FILE")
+	baseConfig.BindEnv("server.tls.client_auth", "SERVER_TLS_CLIENT_AUTH")
+	baseConfig.BindEnv("server.tls.client_ca_file", "SERVER_TLS_CLIENT_CA_FILE")
 	baseConfig.BindEnv("logger.level", "LOGGER_LEVEL")
 	baseConfig.BindEnv("database.driver", "DB_DRIVER")
 	baseConfig.BindEnv("database.host", "DB_HOST")
@@ -118,9 +387,55 @@ func Load() (*Config, error) {
 	baseConfig.BindEnv("database.max_idle_conns", "DB_MAX_IDLE_CONNS")
 	baseConfig.BindEnv("database.max_open_conns", "DB_MAX_OPEN_CONNS")
 	baseConfig.BindEnv("database.conn_max_lifetime", "DB_CONN_MAX_LIFETIME")
+	baseConfig.BindEnv("database.migrate_on_start", "DB_MIGRATE_ON_START")
 	baseConfig.BindEnv("auth.jwt_secret", "JWT_SECRET")
-	baseConfig.BindEnv("auth.jwt_expiry_hours", "JWT_EXPIRY_HOURS")
+	baseConfig.BindEnv("auth.jwt_algorithm", "JWT_ALGORITHM")
+	baseConfig.BindEnv("auth.jwt_signing_key.id", "JWT_SIGNING_KEY_ID")
+	baseConfig.BindEnv("auth.jwt_signing_key.private_key_path", "JWT_SIGNING_KEY_PATH")
+	baseConfig.BindEnv("auth.access_token_ttl_minutes", "ACCESS_TOKEN_TTL_MINUTES")
+	baseConfig.BindEnv("auth.refresh_token_ttl_hours", "REFRESH_TOKEN_TTL_HOURS")
 	baseConfig.BindEnv("auth.superadmin_email", "SUPERADMIN_EMAIL")
+	baseConfig.BindEnv("auth.password_hash_cost", "PASSWORD_HASH_COST")
+	baseConfig.BindEnv("auth.revocation_store", "TOKEN_REVOCATION_STORE")
+	baseConfig.BindEnv("auth.default_tenant", "DEFAULT_TENANT")
+	baseConfig.BindEnv("auth.oidc.enabled", "OIDC_ENABLED")
+	baseConfig.BindEnv("auth.oidc.issuer_url", "OIDC_ISSUER_URL")
+	baseConfig.BindEnv("auth.oidc.audience", "OIDC_AUDIENCE")
+	baseConfig.BindEnv("auth.oidc.email_claim", "OIDC_EMAIL_CLAIM")
+	baseConfig.BindEnv("auth.oidc.subject_claim", "OIDC_SUBJECT_CLAIM")
+	baseConfig.BindEnv("auth.oidc.roles_claim", "OIDC_ROLES_CLAIM")
+	baseConfig.BindEnv("auth.oidc.tenant_claim", "OIDC_TENANT_CLAIM")
+	baseConfig.BindEnv("auth.magic_link.enabled", "MAGIC_LINK_ENABLED")
+	baseConfig.BindEnv("auth.magic_link.ttl_minutes", "MAGIC_LINK_TTL_MINUTES")
+	baseConfig.BindEnv("auth.magic_link.url", "MAGIC_LINK_URL")
+	baseConfig.BindEnv("auth.magic_link.max_code_attempts", "MAGIC_LINK_MAX_CODE_ATTEMPTS")
+	baseConfig.BindEnv("auth.mfa.issuer", "MFA_ISSUER")
+	baseConfig.BindEnv("auth.mfa.required_for_superadmin", "MFA_REQUIRED_FOR_SUPERADMIN")
+	baseConfig.BindEnv("auth.mfa.required_roles", "MFA_REQUIRED_ROLES")
+	baseConfig.BindEnv("auth.mfa.challenge_ttl_minutes", "MFA_CHALLENGE_TTL_MINUTES")
+	baseConfig.BindEnv("auth.lockout.enabled", "LOCKOUT_ENABLED")
+	baseConfig.BindEnv("auth.lockout.store", "LOCKOUT_STORE")
+	baseConfig.BindEnv("auth.lockout.account.free_attempts", "LOCKOUT_ACCOUNT_FREE_ATTEMPTS")
+	baseConfig.BindEnv("auth.lockout.account.max_attempts", "LOCKOUT_ACCOUNT_MAX_ATTEMPTS")
+	baseConfig.BindEnv("auth.lockout.ip.free_attempts", "LOCKOUT_IP_FREE_ATTEMPTS")
+	baseConfig.BindEnv("auth.lockout.ip.max_attempts", "LOCKOUT_IP_MAX_ATTEMPTS")
+	baseConfig.BindEnv("auth.lockout.base_delay_seconds", "LOCKOUT_BASE_DELAY_SECONDS")
+	baseConfig.BindEnv("auth.lockout.lockout_minutes", "LOCKOUT_MINUTES")
+	baseConfig.BindEnv("auth.impersonation.enabled", "IMPERSONATION_ENABLED")
+	baseConfig.BindEnv("auth.impersonation.ttl_minutes", "IMPERSONATION_TTL_MINUTES")
+	baseConfig.BindEnv("rbac.model_path", "RBAC_MODEL_PATH")
+	baseConfig.BindEnv("rbac.policy_path", "RBAC_POLICY_PATH")
+	baseConfig.BindEnv("rbac.policy_store", "RBAC_POLICY_STORE")
+	baseConfig.BindEnv("rbac.watch", "RBAC_WATCH")
+	baseConfig.BindEnv("rbac.reload_interval_seconds", "RBAC_RELOAD_INTERVAL_SECONDS")
+	baseConfig.BindEnv("rbac.insecure_skip_authorization", "RBAC_INSECURE_SKIP_AUTHORIZATION")
+	baseConfig.BindEnv("mailer.driver", "MAILER_DRIVER")
+	baseConfig.BindEnv("mailer.from", "MAILER_FROM")
+	baseConfig.BindEnv("mailer.dir", "MAILER_DIR")
+	baseConfig.BindEnv("mailer.smtp.host", "SMTP_HOST")
+	baseConfig.BindEnv("mailer.smtp.port", "SMTP_PORT")
+	baseConfig.BindEnv("mailer.smtp.username", "SMTP_USERNAME")
+	baseConfig.BindEnv("mailer.smtp.password", "SMTP_PASSWORD")
 
 	// Unmarshal configuration
 	var config Config
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+// authErrorMessages names what usecase errors mean to an endpoint, replacing
+// the generic message of their response
+type authErrorMessages map[error]string
+
+// get returns the message for err, or fallback if none is named
+func (m authErrorMessages) get(err error, fallback string) string {
+	for target, message := range m {
+		if errors.Is(err, target) {
+			return message
+		}
+	}
+	return fallback
+}
+
+// handleAuthError maps the errors of the authentication usecases, including
+// throttling and MFA challenges, to HTTP responses with the messages the
+// endpoint names for them. Other errors are logged and answered with message.
+func handleAuthError(c *gin.Context, logger *logger.Logger, err error, message string, messages authErrorMessages) {
+	var validationErr *model.ValidationError
+	var mfaErr *usecase.MFARequiredError
+	var throttledErr *usecase.ThrottledError
+	switch {
+	case errors.As(err, &throttledErr):
+		retryAfter := int64(math.Ceil(throttledErr.RetryAfter.Seconds()))
+		c.Header("Retry-After", strconv.FormatInt(retryAfter, 10))
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error":       "Too many failed attempts, try again later",
+			"retry_after": retryAfter,
+		})
+	case errors.As(err, &mfaErr):
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":                   "Multi-factor authentication required",
+			"mfa_token":               mfaErr.Token,
+			"mfa_enrollment_required": mfaErr.EnrollmentRequired,
+		})
+	case errors.Is(err, model.ErrInvalidCredentials):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": messages.get(err, "Invalid email or password")})
+	case errors.Is(err, model.ErrInvalidToken):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": messages.get(err, "Invalid or expired token")})
+	case errors.Is(err, model.ErrUnauthenticated):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": messages.get(err, "Authentication required")})
+	case errors.Is(err, model.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": messages.get(err, "Forbidden")})
+	case errors.Is(err, model.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": messages.get(err, "Not found")})
+	case errors.Is(err, model.ErrConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": messages.get(err, "Already exists")})
+	case errors.As(err, &validationErr):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":  "Validation failed",
+			"field":  validationErr.Field,
+			"reason": validationErr.Message,
+		})
+	default:
+		logger.Error(message, map[string]interface{}{
+			"error": err.Error(),
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
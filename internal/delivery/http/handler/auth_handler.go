@@ -1,102 +1,453 @@
 package handler
 
 import (
+	"errors"
+	"io"
 	"net/http"
-	"regexp"
+	"strings"
 
 	"github.com/bgaurav7/gin-microservice-boilerplate/config"
-	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/jwt"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
 	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/usecase"
 	"github.com/gin-gonic/gin"
 )
 
 // AuthHandler handles authentication-related requests
 type AuthHandler struct {
-	tokenService *jwt.TokenService
-	logger       *logger.Logger
-	config       *config.AuthConfig
+	authUsecase usecase.AuthUsecase
+	logger      *logger.Logger
+	config      *config.AuthConfig
+}
+
+// refreshTokenErrors are the messages of endpoints that take a refresh token
+var refreshTokenErrors = authErrorMessages{
+	model.ErrInvalidToken: "Invalid or expired refresh token",
 }
 
 // AuthRequest represents the authentication request
 type AuthRequest struct {
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// RefreshRequest represents the token refresh request
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// LogoutRequest represents the logout request
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// MagicLinkRequest represents the request for a login email
+type MagicLinkRequest struct {
 	Email string `json:"email" binding:"required"`
 }
 
+// VerifyRequest represents the request exchanging a login link or code for
+// tokens. Either token, from the link, or email and code are required.
+type VerifyRequest struct {
+	Token string `json:"token"`
+	Email string `json:"email"`
+	Code  string `json:"code"`
+}
+
+// MFATokenRequest represents a request carrying the MFA token of a login
+type MFATokenRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+}
+
+// MFAVerifyRequest represents the request completing a login with a TOTP
+// code or recovery code
+type MFAVerifyRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// ScopedTokenRequest represents the request for tokens restricted to some
+// roles. Scope is a space-delimited list of role names.
+type ScopedTokenRequest struct {
+	Scope string `json:"scope" binding:"required"`
+}
+
 // AuthResponse represents the authentication response
 type AuthResponse struct {
-	Token string `json:"token"`
+	Token        string `json:"token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+	// RecoveryCodes is only set by the login that activates MFA; they are
+	// not shown again
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
+	// Scope lists the roles the tokens are restricted to, space-delimited;
+	// absent for unrestricted tokens
+	Scope string `json:"scope,omitempty"`
+}
+
+// TOTPEnrollmentResponse represents a pending TOTP factor. URI is the
+// otpauth:// URI to render as a QR code.
+type TOTPEnrollmentResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// RegisterResponse represents the registration response
+type RegisterResponse struct {
+	ID    uint   `json:"id"`
+	Email string `json:"email"`
 }
 
 // NewAuthHandler creates a new authentication handler
-func NewAuthHandler(tokenService *jwt.TokenService, logger *logger.Logger, config *config.AuthConfig) *AuthHandler {
+func NewAuthHandler(authUsecase usecase.AuthUsecase, logger *logger.Logger, config *config.AuthConfig) *AuthHandler {
 	return &AuthHandler{
-		tokenService: tokenService,
-		logger:       logger,
-		config:       config,
+		authUsecase: authUsecase,
+		logger:      logger,
+		config:      config,
 	}
 }
 
+// Register handles the registration request
+// @Summary Register a user
+// @Description Create a new account with an email and password
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body AuthRequest true "Registration request"
+// @Success 201 {object} RegisterResponse
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /auth/register [post]
+func (h *AuthHandler) Register(c *gin.Context) {
+	req, ok := h.bindCredentials(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.authUsecase.Register(c.Request.Context(), req.Email, req.Password)
+	if err != nil {
+		handleAuthError(c, h.logger, err, "Failed to register user", authErrorMessages{
+			model.ErrConflict:  "Email is already registered",
+			model.ErrForbidden: "Email cannot be registered",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, RegisterResponse{
+		ID:    user.ID,
+		Email: user.Email,
+	})
+}
+
 // Authenticate handles the authentication request
 // @Summary Authenticate a user
-// @Description Authenticate a user and return a JWT token
+// @Description Verify an email and password and return a short-lived JWT access token and a refresh token.
+// @Description Also served at /auth/login.
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param request body AuthRequest true "Authentication request"
+// @Description Accounts with MFA get a 401 carrying an mfa_token to complete the login at /auth/login/mfa.
+// @Description Repeated failures for an account or from an IP are answered with 429 and a Retry-After header.
 // @Success 200 {object} AuthResponse
 // @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 429 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /auth [post]
 func (h *AuthHandler) Authenticate(c *gin.Context) {
-	var req AuthRequest
+	req, ok := h.bindCredentials(c)
+	if !ok {
+		return
+	}
+
+	tokens, err := h.authUsecase.Login(c.Request.Context(), req.Email, req.Password)
+	if err != nil {
+		handleAuthError(c, h.logger, err, "Failed to authenticate user", nil)
+		return
+	}
+
+	c.JSON(http.StatusOK, newAuthResponse(tokens))
+}
+
+// Refresh handles the token refresh request
+// @Summary Refresh tokens
+// @Description Exchange a refresh token for a new access token and refresh token.
+// @Description Each refresh token can be used once; reusing one revokes every token from the same login.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body RefreshRequest true "Refresh request"
+// @Success 200 {object} AuthResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/refresh [post]
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req RefreshRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.Error("Invalid request", map[string]interface{}{
-			"error": err.Error(),
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Refresh token is required",
 		})
+		return
+	}
+
+	tokens, err := h.authUsecase.Refresh(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		handleAuthError(c, h.logger, err, "Failed to refresh token", refreshTokenErrors)
+		return
+	}
+
+	c.JSON(http.StatusOK, newAuthResponse(tokens))
+}
+
+// Logout handles the logout request
+// @Summary Log out
+// @Description Revoke the access token used for the request. If a refresh token is given,
+// @Description every token issued from the same login is revoked too.
+// @Tags auth
+// @Accept json
+// @Param request body LogoutRequest false "Logout request"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/logout [post]
+func (h *AuthHandler) Logout(c *gin.Context) {
+	// The body is optional
+	var req LogoutRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid request body",
+			})
+			return
+		}
+	}
+
+	if err := h.authUsecase.Logout(c.Request.Context(), req.RefreshToken); err != nil {
+		handleAuthError(c, h.logger, err, "Failed to log out", refreshTokenErrors)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ScopedToken handles the request for tokens restricted to some roles
+// @Summary Issue scoped tokens
+// @Description Issue the caller an access token and refresh token restricted to the roles named in scope,
+// @Description such as a read-only token for a dashboard. Refreshed tokens keep the restriction, and
+// @Description callers with scoped tokens may only narrow their scopes. Scoped tokens never carry superadmin privileges.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body ScopedTokenRequest true "Scoped token request"
+// @Success 200 {object} AuthResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/scoped-token [post]
+func (h *AuthHandler) ScopedToken(c *gin.Context) {
+	var req ScopedTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request",
+			"error": "Scope is required",
 		})
 		return
 	}
 
-	// Validate email
-	if req.Email == "" {
-		h.logger.Error("Email is required", nil)
+	tokens, err := h.authUsecase.IssueScopedTokens(c.Request.Context(), strings.Fields(req.Scope))
+	if err != nil {
+		handleAuthError(c, h.logger, err, "Failed to issue scoped tokens", authErrorMessages{
+			model.ErrForbidden: "Scoped tokens are only issued to users with an account",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, newAuthResponse(tokens))
+}
+
+// MagicLink handles the request for a login email
+// @Summary Request a login link
+// @Description Email a single-use sign-in link and 6-digit code to the account with the given email.
+// @Description The response is the same whether or not the account exists.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body MagicLinkRequest true "Login link request"
+// @Success 202 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/magic-link [post]
+func (h *AuthHandler) MagicLink(c *gin.Context) {
+	var req MagicLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "Email is required",
 		})
 		return
 	}
-	
-	// Validate email format
-	emailRegex := regexp.MustCompile(`^[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}$`)
-	if !emailRegex.MatchString(req.Email) {
-		h.logger.Error("Invalid email format", nil)
+
+	if err := h.authUsecase.RequestLoginLink(c.Request.Context(), req.Email); err != nil {
+		handleAuthError(c, h.logger, err, "Failed to send login link", nil)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "If the email belongs to an account, a sign-in link has been sent to it",
+	})
+}
+
+// Verify handles the exchange of a login link or code for tokens
+// @Summary Verify a login link or code
+// @Description Exchange the token from a login link, or an email and the 6-digit code sent to it,
+// @Description for a JWT access token and a refresh token. Links and codes can be used once.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body VerifyRequest true "Verification request"
+// @Success 200 {object} AuthResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/verify [post]
+func (h *AuthHandler) Verify(c *gin.Context) {
+	var req VerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil || (req.Token == "" && (req.Email == "" || req.Code == "")) {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid email format",
+			"error": "Token, or email and code, are required",
 		})
 		return
 	}
 
-	// Generate token
-	token, err := h.tokenService.GenerateToken(req.Email)
+	var tokens *usecase.TokenPair
+	var err error
+	if req.Token != "" {
+		tokens, err = h.authUsecase.VerifyLoginLink(c.Request.Context(), req.Token)
+	} else {
+		tokens, err = h.authUsecase.VerifyLoginCode(c.Request.Context(), req.Email, req.Code)
+	}
 	if err != nil {
-		h.logger.Error("Failed to generate token", map[string]interface{}{
-			"error": err.Error(),
+		handleAuthError(c, h.logger, err, "Failed to verify login", authErrorMessages{
+			model.ErrInvalidToken: "Invalid or expired login link or code",
 		})
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to generate token",
+		return
+	}
+
+	c.JSON(http.StatusOK, newAuthResponse(tokens))
+}
+
+// MFAEnroll handles TOTP enrollment during a login for which MFA is mandatory
+// @Summary Enroll an authenticator app during login
+// @Description Start TOTP enrollment with the mfa_token of a login that requires MFA but has none enabled.
+// @Description The login is completed at /auth/login/mfa with the first code from the app.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body MFATokenRequest true "MFA token"
+// @Success 200 {object} TOTPEnrollmentResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/login/mfa/enroll [post]
+func (h *AuthHandler) MFAEnroll(c *gin.Context) {
+	var req MFATokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "MFA token is required",
 		})
 		return
 	}
 
-	// Log successful authentication
-	h.logger.Info("User authenticated", map[string]interface{}{
-		"email": req.Email,
-	})
+	enrollment, err := h.authUsecase.EnrollMFA(c.Request.Context(), req.MFAToken)
+	if err != nil {
+		handleAuthError(c, h.logger, err, "Failed to enroll MFA", authErrorMessages{
+			model.ErrInvalidToken: "Invalid or expired MFA token",
+			model.ErrConflict:     "MFA is already enabled",
+		})
+		return
+	}
 
-	// Return token
-	c.JSON(http.StatusOK, AuthResponse{
-		Token: token,
+	c.JSON(http.StatusOK, TOTPEnrollmentResponse{
+		Secret: enrollment.Secret,
+		URI:    enrollment.URI,
 	})
 }
+
+// MFAVerify handles the second factor of a login
+// @Summary Complete a login with MFA
+// @Description Exchange the mfa_token of a login and a code from the authenticator app, or a recovery code,
+// @Description for a JWT access token and a refresh token. The login that activates MFA also returns recovery codes.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body MFAVerifyRequest true "MFA verification request"
+// @Success 200 {object} AuthResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/login/mfa [post]
+func (h *AuthHandler) MFAVerify(c *gin.Context) {
+	var req MFAVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "MFA token and code are required",
+		})
+		return
+	}
+
+	tokens, err := h.authUsecase.VerifyMFA(c.Request.Context(), req.MFAToken, req.Code)
+	if err != nil {
+		handleAuthError(c, h.logger, err, "Failed to verify MFA", authErrorMessages{
+			model.ErrInvalidToken: "Invalid or expired MFA token or code",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, newAuthResponse(tokens))
+}
+
+// newAuthResponse converts a token pair into its response body
+func newAuthResponse(tokens *usecase.TokenPair) AuthResponse {
+	return AuthResponse{
+		Token:         tokens.AccessToken,
+		TokenType:     "Bearer",
+		ExpiresIn:     int64(tokens.ExpiresIn.Seconds()),
+		RefreshToken:  tokens.RefreshToken,
+		RecoveryCodes: tokens.RecoveryCodes,
+		Scope:         strings.Join(tokens.Scopes, " "),
+	}
+}
+
+// bindCredentials parses and validates the email and password, writing a 400 response on failure
+func (h *AuthHandler) bindCredentials(c *gin.Context) (*AuthRequest, bool) {
+	var req AuthRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Invalid request", map[string]interface{}{
+			"error": err.Error(),
+		})
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Email and password are required",
+		})
+		return nil, false
+	}
+
+	// Validate email format
+	if err := usecase.ValidateEmail(usecase.NormalizeEmail(req.Email)); err != nil {
+		h.logger.Error("Invalid email format", nil)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid email format",
+		})
+		return nil, false
+	}
+
+	return &req, true
+}
@@ -2,32 +2,130 @@ package handler
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/bgaurav7/gin-microservice-boilerplate/config"
-	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/jwt"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
 	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/usecase"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
+// MockAuthUsecase is a mock implementation of the AuthUsecase interface
+type MockAuthUsecase struct {
+	mock.Mock
+}
+
+func (m *MockAuthUsecase) Register(ctx context.Context, email, password string) (*model.User, error) {
+	args := m.Called(ctx, email, password)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+func (m *MockAuthUsecase) Provision(ctx context.Context, email, password string) (*model.User, error) {
+	args := m.Called(ctx, email, password)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+func (m *MockAuthUsecase) Login(ctx context.Context, email, password string) (*usecase.TokenPair, error) {
+	args := m.Called(ctx, email, password)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*usecase.TokenPair), args.Error(1)
+}
+
+func (m *MockAuthUsecase) Refresh(ctx context.Context, refreshToken string) (*usecase.TokenPair, error) {
+	args := m.Called(ctx, refreshToken)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*usecase.TokenPair), args.Error(1)
+}
+
+func (m *MockAuthUsecase) Logout(ctx context.Context, refreshToken string) error {
+	args := m.Called(ctx, refreshToken)
+	return args.Error(0)
+}
+
+func (m *MockAuthUsecase) RevokeUserTokens(ctx context.Context, email string) error {
+	args := m.Called(ctx, email)
+	return args.Error(0)
+}
+
+func (m *MockAuthUsecase) RequestLoginLink(ctx context.Context, email string) error {
+	args := m.Called(ctx, email)
+	return args.Error(0)
+}
+
+func (m *MockAuthUsecase) VerifyLoginLink(ctx context.Context, token string) (*usecase.TokenPair, error) {
+	args := m.Called(ctx, token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*usecase.TokenPair), args.Error(1)
+}
+
+func (m *MockAuthUsecase) VerifyLoginCode(ctx context.Context, email, code string) (*usecase.TokenPair, error) {
+	args := m.Called(ctx, email, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*usecase.TokenPair), args.Error(1)
+}
+
+func (m *MockAuthUsecase) EnrollMFA(ctx context.Context, mfaToken string) (*usecase.TOTPEnrollment, error) {
+	args := m.Called(ctx, mfaToken)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*usecase.TOTPEnrollment), args.Error(1)
+}
+
+func (m *MockAuthUsecase) VerifyMFA(ctx context.Context, mfaToken, code string) (*usecase.TokenPair, error) {
+	args := m.Called(ctx, mfaToken, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*usecase.TokenPair), args.Error(1)
+}
+
+func (m *MockAuthUsecase) IssueScopedTokens(ctx context.Context, scopes []string) (*usecase.TokenPair, error) {
+	args := m.Called(ctx, scopes)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*usecase.TokenPair), args.Error(1)
+}
+
 func TestAuthHandler_Authenticate(t *testing.T) {
 	// Setup test config
 	authConfig := &config.AuthConfig{
-		JWTSecret:       "test-secret",
-		JWTExpiryHours:  1,
 		SuperAdminEmail: "admin@example.com",
 	}
 
-	// Setup logger and token service
+	// Setup logger
 	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
-	tokenService := jwt.NewTokenService(authConfig)
 
 	// Setup auth handler
-	authHandler := NewAuthHandler(tokenService, log, authConfig)
+	authUsecase := new(MockAuthUsecase)
+	authUsecase.On("Login", mock.Anything, "user@example.com", "correct-password").
+		Return(&usecase.TokenPair{AccessToken: "access-token", ExpiresIn: 15 * time.Minute, RefreshToken: "refresh-token"}, nil)
+	authUsecase.On("Login", mock.Anything, "user@example.com", "wrong-password").
+		Return(nil, model.ErrInvalidCredentials)
+	authHandler := NewAuthHandler(authUsecase, log, authConfig)
 
 	// Setup gin router
 	gin.SetMode(gin.TestMode)
@@ -42,26 +140,38 @@ func TestAuthHandler_Authenticate(t *testing.T) {
 		checkToken     bool
 	}{
 		{
-			name:           "Valid email",
-			requestBody:    map[string]interface{}{"email": "user@example.com"},
+			name:           "Valid credentials",
+			requestBody:    map[string]interface{}{"email": "user@example.com", "password": "correct-password"},
 			expectedStatus: http.StatusOK,
 			checkToken:     true,
 		},
+		{
+			name:           "Wrong password",
+			requestBody:    map[string]interface{}{"email": "user@example.com", "password": "wrong-password"},
+			expectedStatus: http.StatusUnauthorized,
+			checkToken:     false,
+		},
+		{
+			name:           "Missing password",
+			requestBody:    map[string]interface{}{"email": "user@example.com"},
+			expectedStatus: http.StatusBadRequest,
+			checkToken:     false,
+		},
 		{
 			name:           "Empty email",
-			requestBody:    map[string]interface{}{"email": ""},
+			requestBody:    map[string]interface{}{"email": "", "password": "correct-password"},
 			expectedStatus: http.StatusBadRequest,
 			checkToken:     false,
 		},
 		{
 			name:           "Missing email field",
-			requestBody:    map[string]interface{}{},
+			requestBody:    map[string]interface{}{"password": "correct-password"},
 			expectedStatus: http.StatusBadRequest,
 			checkToken:     false,
 		},
 		{
 			name:           "Invalid email format",
-			requestBody:    map[string]interface{}{"email": "not-an-email"},
+			requestBody:    map[string]interface{}{"email": "not-an-email", "password": "correct-password"},
 			expectedStatus: http.StatusBadRequest,
 			checkToken:     false,
 		},
@@ -83,22 +193,402 @@ func TestAuthHandler_Authenticate(t *testing.T) {
 			// Check status code
 			assert.Equal(t, tt.expectedStatus, w.Code)
 
-			// Check token if expected
+			// Check tokens if expected
 			if tt.checkToken {
-				var response map[string]string
-				err := json.Unmarshal(w.Body.Bytes(), &response)
-				assert.NoError(t, err)
-
-				// Check if token exists and is not empty
-				token, exists := response["token"]
-				assert.True(t, exists)
-				assert.NotEmpty(t, token)
-
-				// Verify token is valid
-				claims, err := tokenService.ValidateToken(token)
-				assert.NoError(t, err)
-				assert.Equal(t, tt.requestBody["email"], claims.Email)
+				assert.JSONEq(t, `{"token":"access-token","token_type":"Bearer","expires_in":900,"refresh_token":"refresh-token"}`, w.Body.String())
 			}
 		})
 	}
 }
+
+func TestAuthHandler_Register(t *testing.T) {
+	authConfig := &config.AuthConfig{
+		SuperAdminEmail: "admin@example.com",
+	}
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+
+	authUsecase := new(MockAuthUsecase)
+	authHandler := NewAuthHandler(authUsecase, log, authConfig)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/auth/register", authHandler.Register)
+
+	register := func(body map[string]interface{}) *httptest.ResponseRecorder {
+		jsonBody, _ := json.Marshal(body)
+		req, _ := http.NewRequest("POST", "/auth/register", bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		authUsecase.On("Register", mock.Anything, "new@example.com", "long-enough").
+			Return(&model.User{ID: 7, Email: "new@example.com", PasswordHash: "secret-hash"}, nil).Once()
+
+		w := register(map[string]interface{}{"email": "new@example.com", "password": "long-enough"})
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.JSONEq(t, `{"id":7,"email":"new@example.com"}`, w.Body.String())
+	})
+
+	t.Run("Duplicate email", func(t *testing.T) {
+		authUsecase.On("Register", mock.Anything, "taken@example.com", "long-enough").
+			Return(nil, model.ErrConflict).Once()
+
+		w := register(map[string]interface{}{"email": "taken@example.com", "password": "long-enough"})
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Weak password", func(t *testing.T) {
+		authUsecase.On("Register", mock.Anything, "new@example.com", "short").
+			Return(nil, model.NewValidationError("password", "must be at least 8 characters")).Once()
+
+		w := register(map[string]interface{}{"email": "new@example.com", "password": "short"})
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	})
+
+	t.Run("Superadmin email", func(t *testing.T) {
+		authUsecase.On("Register", mock.Anything, "admin@example.com", "long-enough").
+			Return(nil, model.ErrForbidden).Once()
+
+		w := register(map[string]interface{}{"email": "admin@example.com", "password": "long-enough"})
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.JSONEq(t, `{"error":"Email cannot be registered"}`, w.Body.String())
+	})
+
+	authUsecase.AssertExpectations(t)
+}
+
+func TestAuthHandler_Refresh(t *testing.T) {
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+
+	authUsecase := new(MockAuthUsecase)
+	authHandler := NewAuthHandler(authUsecase, log, &config.AuthConfig{})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/auth/refresh", authHandler.Refresh)
+
+	refresh := func(body map[string]interface{}) *httptest.ResponseRecorder {
+		jsonBody, _ := json.Marshal(body)
+		req, _ := http.NewRequest("POST", "/auth/refresh", bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		authUsecase.On("Refresh", mock.Anything, "old-refresh-token").
+			Return(&usecase.TokenPair{AccessToken: "new-access-token", ExpiresIn: time.Minute, RefreshToken: "new-refresh-token"}, nil).Once()
+
+		w := refresh(map[string]interface{}{"refresh_token": "old-refresh-token"})
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"token":"new-access-token","token_type":"Bearer","expires_in":60,"refresh_token":"new-refresh-token"}`, w.Body.String())
+	})
+
+	t.Run("Invalid token", func(t *testing.T) {
+		authUsecase.On("Refresh", mock.Anything, "reused-refresh-token").
+			Return(nil, model.ErrInvalidToken).Once()
+
+		w := refresh(map[string]interface{}{"refresh_token": "reused-refresh-token"})
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.JSONEq(t, `{"error":"Invalid or expired refresh token"}`, w.Body.String())
+	})
+
+	t.Run("Missing token", func(t *testing.T) {
+		w := refresh(map[string]interface{}{})
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	authUsecase.AssertExpectations(t)
+}
+
+func TestAuthHandler_Logout(t *testing.T) {
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+
+	authUsecase := new(MockAuthUsecase)
+	authHandler := NewAuthHandler(authUsecase, log, &config.AuthConfig{})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/auth/logout", authHandler.Logout)
+
+	t.Run("Without body", func(t *testing.T) {
+		authUsecase.On("Logout", mock.Anything, "").Return(nil).Once()
+
+		req, _ := http.NewRequest("POST", "/auth/logout", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("With refresh token", func(t *testing.T) {
+		authUsecase.On("Logout", mock.Anything, "refresh-token").Return(nil).Once()
+
+		req, _ := http.NewRequest("POST", "/auth/logout", bytes.NewBufferString(`{"refresh_token":"refresh-token"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		authUsecase.On("Logout", mock.Anything, "").Return(model.ErrUnauthenticated).Once()
+
+		req, _ := http.NewRequest("POST", "/auth/logout", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	authUsecase.AssertExpectations(t)
+}
+
+func TestAuthHandler_MagicLink(t *testing.T) {
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+
+	authUsecase := new(MockAuthUsecase)
+	authHandler := NewAuthHandler(authUsecase, log, &config.AuthConfig{})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/auth/magic-link", authHandler.MagicLink)
+
+	request := func(body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/auth/magic-link", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Accepted", func(t *testing.T) {
+		authUsecase.On("RequestLoginLink", mock.Anything, "user@example.com").Return(nil).Once()
+
+		w := request(`{"email":"user@example.com"}`)
+
+		assert.Equal(t, http.StatusAccepted, w.Code)
+	})
+
+	t.Run("Invalid email", func(t *testing.T) {
+		authUsecase.On("RequestLoginLink", mock.Anything, "user").
+			Return(model.NewValidationError("email", "must be a valid email address")).Once()
+
+		w := request(`{"email":"user"}`)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	})
+
+	t.Run("Missing email", func(t *testing.T) {
+		w := request(`{}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	authUsecase.AssertExpectations(t)
+}
+
+func TestAuthHandler_Verify(t *testing.T) {
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+
+	authUsecase := new(MockAuthUsecase)
+	authHandler := NewAuthHandler(authUsecase, log, &config.AuthConfig{})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/auth/verify", authHandler.Verify)
+
+	verify := func(body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/auth/verify", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	tokens := &usecase.TokenPair{AccessToken: "access-token", ExpiresIn: time.Minute, RefreshToken: "refresh-token"}
+
+	t.Run("Link token", func(t *testing.T) {
+		authUsecase.On("VerifyLoginLink", mock.Anything, "login-token").Return(tokens, nil).Once()
+
+		w := verify(`{"token":"login-token"}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"token":"access-token","token_type":"Bearer","expires_in":60,"refresh_token":"refresh-token"}`, w.Body.String())
+	})
+
+	t.Run("Email and code", func(t *testing.T) {
+		authUsecase.On("VerifyLoginCode", mock.Anything, "user@example.com", "123456").Return(tokens, nil).Once()
+
+		w := verify(`{"email":"user@example.com","code":"123456"}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Wrong code", func(t *testing.T) {
+		authUsecase.On("VerifyLoginCode", mock.Anything, "user@example.com", "000000").Return(nil, model.ErrInvalidToken).Once()
+
+		w := verify(`{"email":"user@example.com","code":"000000"}`)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("Code without email", func(t *testing.T) {
+		w := verify(`{"code":"123456"}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	authUsecase.AssertExpectations(t)
+}
+
+func TestAuthHandler_MFA(t *testing.T) {
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+
+	authUsecase := new(MockAuthUsecase)
+	authHandler := NewAuthHandler(authUsecase, log, &config.AuthConfig{})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/auth/login", authHandler.Authenticate)
+	router.POST("/auth/login/mfa", authHandler.MFAVerify)
+	router.POST("/auth/login/mfa/enroll", authHandler.MFAEnroll)
+
+	post := func(path, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Login requires MFA", func(t *testing.T) {
+		authUsecase.On("Login", mock.Anything, "user@example.com", "password").
+			Return(nil, &usecase.MFARequiredError{Token: "mfa-token", EnrollmentRequired: true}).Once()
+
+		w := post("/auth/login", `{"email":"user@example.com","password":"password"}`)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.JSONEq(t, `{"error":"Multi-factor authentication required","mfa_token":"mfa-token","mfa_enrollment_required":true}`, w.Body.String())
+	})
+
+	t.Run("Enroll", func(t *testing.T) {
+		authUsecase.On("EnrollMFA", mock.Anything, "mfa-token").
+			Return(&usecase.TOTPEnrollment{Secret: "SECRET", URI: "otpauth://totp/App:user@example.com?secret=SECRET"}, nil).Once()
+
+		w := post("/auth/login/mfa/enroll", `{"mfa_token":"mfa-token"}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"secret":"SECRET","uri":"otpauth://totp/App:user@example.com?secret=SECRET"}`, w.Body.String())
+	})
+
+	t.Run("Enroll when already enabled", func(t *testing.T) {
+		authUsecase.On("EnrollMFA", mock.Anything, "mfa-token").Return(nil, model.ErrConflict).Once()
+
+		w := post("/auth/login/mfa/enroll", `{"mfa_token":"mfa-token"}`)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Verify returns recovery codes on activation", func(t *testing.T) {
+		tokens := &usecase.TokenPair{AccessToken: "access-token", ExpiresIn: time.Minute, RefreshToken: "refresh-token", RecoveryCodes: []string{"aaaaaaaa-bbbbbbbb"}}
+		authUsecase.On("VerifyMFA", mock.Anything, "mfa-token", "123456").Return(tokens, nil).Once()
+
+		w := post("/auth/login/mfa", `{"mfa_token":"mfa-token","code":"123456"}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"token":"access-token","token_type":"Bearer","expires_in":60,"refresh_token":"refresh-token","recovery_codes":["aaaaaaaa-bbbbbbbb"]}`, w.Body.String())
+	})
+
+	t.Run("Wrong code", func(t *testing.T) {
+		authUsecase.On("VerifyMFA", mock.Anything, "mfa-token", "000000").Return(nil, model.ErrInvalidToken).Once()
+
+		w := post("/auth/login/mfa", `{"mfa_token":"mfa-token","code":"000000"}`)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.NotContains(t, w.Body.String(), "mfa_token")
+	})
+
+	t.Run("Throttled", func(t *testing.T) {
+		authUsecase.On("VerifyMFA", mock.Anything, "mfa-token", "111111").
+			Return(nil, &usecase.ThrottledError{RetryAfter: 1500 * time.Millisecond}).Once()
+
+		w := post("/auth/login/mfa", `{"mfa_token":"mfa-token","code":"111111"}`)
+
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "2", w.Header().Get("Retry-After"))
+		assert.JSONEq(t, `{"error":"Too many failed attempts, try again later","retry_after":2}`, w.Body.String())
+	})
+
+	t.Run("Missing code", func(t *testing.T) {
+		w := post("/auth/login/mfa", `{"mfa_token":"mfa-token"}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	authUsecase.AssertExpectations(t)
+}
+
+func TestAuthHandler_ScopedToken(t *testing.T) {
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+
+	authUsecase := new(MockAuthUsecase)
+	authHandler := NewAuthHandler(authUsecase, log, &config.AuthConfig{})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/auth/scoped-token", authHandler.ScopedToken)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/auth/scoped-token", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		authUsecase.On("IssueScopedTokens", mock.Anything, []string{"viewer", "auditor"}).Return(&usecase.TokenPair{
+			AccessToken:  "access-token",
+			ExpiresIn:    15 * time.Minute,
+			RefreshToken: "refresh-token",
+			Scopes:       []string{"auditor", "viewer"},
+		}, nil).Once()
+
+		w := post(`{"scope":" viewer  auditor "}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"token":"access-token","token_type":"Bearer","expires_in":900,"refresh_token":"refresh-token","scope":"auditor viewer"}`, w.Body.String())
+	})
+
+	t.Run("Missing scope", func(t *testing.T) {
+		w := post(`{}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Widened scopes", func(t *testing.T) {
+		authUsecase.On("IssueScopedTokens", mock.Anything, []string{"admin"}).
+			Return(nil, model.NewValidationError("scopes", "cannot widen the scopes of the current token")).Once()
+
+		w := post(`{"scope":"admin"}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	})
+
+	t.Run("Caller without an account", func(t *testing.T) {
+		authUsecase.On("IssueScopedTokens", mock.Anything, []string{"viewer"}).Return(nil, model.ErrForbidden).Once()
+
+		w := post(`{"scope":"viewer"}`)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/jwt"
+	"github.com/gin-gonic/gin"
+)
+
+// JWKSHandler publishes the public keys access tokens are signed with
+type JWKSHandler struct {
+	tokenService *jwt.TokenService
+}
+
+// NewJWKSHandler creates a new JWKS handler
+func NewJWKSHandler(tokenService *jwt.TokenService) *JWKSHandler {
+	return &JWKSHandler{
+		tokenService: tokenService,
+	}
+}
+
+// GetJWKS handles the JWKS request
+// @Summary Get the JSON Web Key Set
+// @Description Return the public keys access tokens can be verified with.
+// @Description The set is empty when tokens are signed with HS256.
+// @Tags auth
+// @Produce json
+// @Success 200 {object} jwt.JWKS
+// @Router /.well-known/jwks.json [get]
+func (h *JWKSHandler) GetJWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.tokenService.JWKS())
+}
@@ -0,0 +1,186 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+// MFAHandler handles the authenticated user's multi-factor authentication settings
+type MFAHandler struct {
+	mfaUsecase usecase.MFAUsecase
+	logger     *logger.Logger
+}
+
+// mfaErrors are the messages of MFA usecase errors
+var mfaErrors = authErrorMessages{
+	model.ErrInvalidToken: "Invalid code",
+	model.ErrNotFound:     "MFA is not enrolled",
+	model.ErrConflict:     "MFA is already enabled",
+}
+
+// MFACodeRequest represents a request confirmed with a TOTP code, or for
+// some operations a recovery code
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// RecoveryCodesResponse represents newly issued recovery codes; they are not
+// shown again
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// NewMFAHandler creates a new MFA handler
+func NewMFAHandler(mfaUsecase usecase.MFAUsecase, logger *logger.Logger) *MFAHandler {
+	return &MFAHandler{
+		mfaUsecase: mfaUsecase,
+		logger:     logger,
+	}
+}
+
+// Status handles the request for the caller's MFA status
+// @Summary Get MFA status
+// @Description Report whether the caller has MFA enabled or pending, whether it is mandatory for them,
+// @Description and how many recovery codes remain.
+// @Tags mfa
+// @Produce json
+// @Success 200 {object} usecase.MFAStatus
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/mfa [get]
+func (h *MFAHandler) Status(c *gin.Context) {
+	status, err := h.mfaUsecase.Status(c.Request.Context())
+	if err != nil {
+		handleAuthError(c, h.logger, err, "Failed to get MFA status", mfaErrors)
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// Enroll handles the start of TOTP enrollment
+// @Summary Enroll an authenticator app
+// @Description Generate a TOTP secret and its otpauth:// provisioning URI, to render as a QR code.
+// @Description The factor stays pending until activated with a code; enrolling again replaces a pending factor.
+// @Tags mfa
+// @Produce json
+// @Success 200 {object} TOTPEnrollmentResponse
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/mfa/totp [post]
+func (h *MFAHandler) Enroll(c *gin.Context) {
+	enrollment, err := h.mfaUsecase.Enroll(c.Request.Context())
+	if err != nil {
+		handleAuthError(c, h.logger, err, "Failed to enroll MFA", mfaErrors)
+		return
+	}
+
+	c.JSON(http.StatusOK, TOTPEnrollmentResponse{
+		Secret: enrollment.Secret,
+		URI:    enrollment.URI,
+	})
+}
+
+// Activate handles the confirmation of a pending TOTP factor
+// @Summary Activate an authenticator app
+// @Description Confirm the pending TOTP factor with a code from the app and return recovery codes.
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Param request body MFACodeRequest true "TOTP code"
+// @Success 200 {object} RecoveryCodesResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/mfa/totp/activate [post]
+func (h *MFAHandler) Activate(c *gin.Context) {
+	req, ok := h.bindCode(c)
+	if !ok {
+		return
+	}
+
+	codes, err := h.mfaUsecase.Activate(c.Request.Context(), req.Code)
+	if err != nil {
+		handleAuthError(c, h.logger, err, "Failed to activate MFA", mfaErrors)
+		return
+	}
+
+	c.JSON(http.StatusOK, RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// Disable handles the removal of the TOTP factor
+// @Summary Disable MFA
+// @Description Remove the caller's TOTP factor and recovery codes after checking a code or recovery code.
+// @Description Not allowed when MFA is mandatory for the caller.
+// @Tags mfa
+// @Accept json
+// @Param request body MFACodeRequest true "TOTP code or recovery code"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/mfa/totp [delete]
+func (h *MFAHandler) Disable(c *gin.Context) {
+	req, ok := h.bindCode(c)
+	if !ok {
+		return
+	}
+
+	if err := h.mfaUsecase.Disable(c.Request.Context(), req.Code); err != nil {
+		handleAuthError(c, h.logger, err, "Failed to disable MFA", mfaErrors)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RegenerateRecoveryCodes handles the replacement of the recovery codes
+// @Summary Regenerate recovery codes
+// @Description Replace the caller's recovery codes after checking a code or recovery code.
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Param request body MFACodeRequest true "TOTP code or recovery code"
+// @Success 200 {object} RecoveryCodesResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/mfa/recovery-codes [post]
+func (h *MFAHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	req, ok := h.bindCode(c)
+	if !ok {
+		return
+	}
+
+	codes, err := h.mfaUsecase.RegenerateRecoveryCodes(c.Request.Context(), req.Code)
+	if err != nil {
+		handleAuthError(c, h.logger, err, "Failed to regenerate recovery codes", mfaErrors)
+		return
+	}
+
+	c.JSON(http.StatusOK, RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// bindCode parses the code of the request, writing a 400 response on failure
+func (h *MFAHandler) bindCode(c *gin.Context) (*MFACodeRequest, bool) {
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Code is required",
+		})
+		return nil, false
+	}
+	return &req, true
+}
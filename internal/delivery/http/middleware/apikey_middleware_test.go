@@ -0,0 +1,124 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/config"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/auth"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/jwt"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/revocation"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubAPIKeys authenticates a fixed set of API keys
+type stubAPIKeys map[string]*model.APIKey
+
+func (s stubAPIKeys) Authenticate(ctx context.Context, key string) (*model.APIKey, error) {
+	if key == "unavailable" {
+		return nil, errors.New("database unavailable")
+	}
+	apiKey, ok := s[key]
+	if !ok {
+		return nil, model.ErrInvalidToken
+	}
+	return apiKey, nil
+}
+
+func TestAuthMiddleware_APIKey(t *testing.T) {
+	authConfig := &config.AuthConfig{
+		JWTSecret:             "test-secret",
+		AccessTokenTTLMinutes: 60,
+		SuperAdminEmail:       "admin@example.com",
+	}
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+	tokenService, err := jwt.NewTokenService(authConfig)
+	require.NoError(t, err)
+
+	apiKeys := stubAPIKeys{
+		"gmk_0123456789ab_secret": {Service: "batch", Tenant: "acme", Prefix: "gmk_0123456789ab", Scopes: []string{"user"}},
+	}
+	authMiddleware := NewAuthMiddleware(tokenService, nil, revocation.NewMemoryStore(), apiKeys, defaultPublicRoutes(t), log, authConfig)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(authMiddleware.Authenticate())
+	router.GET("/protected", authMiddleware.RequireAuthentication(), func(c *gin.Context) {
+		principal, _ := auth.PrincipalFromContext(c.Request.Context())
+		c.JSON(http.StatusOK, gin.H{
+			"userEmail":    c.GetString("userEmail"),
+			"userTenant":   c.GetString("userTenant"),
+			"userScopes":   c.GetStringSlice("userScopes"),
+			"isSuperAdmin": c.GetBool("isSuperAdmin"),
+			"service":      principal.Service,
+		})
+	})
+
+	get := func(header, value string) (int, map[string]interface{}) {
+		req, _ := http.NewRequest("GET", "/protected", nil)
+		req.Header.Set(header, value)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var response map[string]interface{}
+		_ = json.Unmarshal(w.Body.Bytes(), &response)
+		return w.Code, response
+	}
+
+	t.Run("X-API-Key header should set a service principal", func(t *testing.T) {
+		code, response := get("X-API-Key", "gmk_0123456789ab_secret")
+
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "service:batch", response["userEmail"])
+		assert.Equal(t, "acme", response["userTenant"])
+		assert.Equal(t, []interface{}{"user"}, response["userScopes"])
+		assert.Equal(t, false, response["isSuperAdmin"])
+		assert.Equal(t, "batch", response["service"])
+	})
+
+	t.Run("ApiKey authorization scheme should be accepted", func(t *testing.T) {
+		code, response := get("Authorization", "ApiKey gmk_0123456789ab_secret")
+
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "service:batch", response["userEmail"])
+	})
+
+	t.Run("Unknown API keys should be rejected", func(t *testing.T) {
+		code, _ := get("X-API-Key", "gmk_0123456789ab_guessed")
+		assert.Equal(t, http.StatusUnauthorized, code)
+	})
+
+	t.Run("Failures to verify API keys should be reported as unavailable", func(t *testing.T) {
+		code, _ := get("X-API-Key", "unavailable")
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+	})
+
+	t.Run("Bearer tokens should still be accepted", func(t *testing.T) {
+		token, _ := tokenService.GenerateToken("user@example.com", "")
+
+		code, response := get("Authorization", "Bearer "+token)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "user@example.com", response["userEmail"])
+	})
+
+	t.Run("API keys should be rejected when not accepted", func(t *testing.T) {
+		withoutKeys := NewAuthMiddleware(tokenService, nil, revocation.NewMemoryStore(), nil, defaultPublicRoutes(t), log, authConfig)
+		r := gin.New()
+		r.Use(withoutKeys.Authenticate())
+		r.GET("/protected", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+		req, _ := http.NewRequest("GET", "/protected", nil)
+		req.Header.Set("X-API-Key", "gmk_0123456789ab_secret")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
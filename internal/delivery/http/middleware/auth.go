@@ -1,43 +1,83 @@
 package middleware
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/bgaurav7/gin-microservice-boilerplate/config"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/auth"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/repository"
 	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/jwt"
 	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
 	"github.com/gin-gonic/gin"
 )
 
+// APIKeyAuthenticator authenticates services by the API keys they present
+type APIKeyAuthenticator interface {
+	// Authenticate returns the unexpired API key matching key.
+	// It returns model.ErrInvalidToken for unknown and expired keys.
+	Authenticate(ctx context.Context, key string) (*model.APIKey, error)
+}
+
 // AuthMiddleware represents the authentication middleware
 type AuthMiddleware struct {
 	tokenService *jwt.TokenService
+	oidc         *jwt.OIDCVerifier
+	revocations  repository.RevocationStore
+	apiKeys      APIKeyAuthenticator
+	public       *PublicRoutes
 	logger       *logger.Logger
 	config       *config.AuthConfig
 }
 
-// NewAuthMiddleware creates a new authentication middleware
-func NewAuthMiddleware(tokenService *jwt.TokenService, logger *logger.Logger, config *config.AuthConfig) *AuthMiddleware {
+// NewAuthMiddleware creates a new authentication middleware.
+// oidc may be nil when no external OIDC provider is configured, and apiKeys
+// when API keys are not accepted. Requests to public routes pass through
+// unauthenticated.
+func NewAuthMiddleware(tokenService *jwt.TokenService, oidc *jwt.OIDCVerifier, revocations repository.RevocationStore, apiKeys APIKeyAuthenticator, public *PublicRoutes, logger *logger.Logger, config *config.AuthConfig) *AuthMiddleware {
 	return &AuthMiddleware{
 		tokenService: tokenService,
+		oidc:         oidc,
+		revocations:  revocations,
+		apiKeys:      apiKeys,
+		public:       public,
 		logger:       logger,
 		config:       config,
 	}
 }
 
-// Authenticate is a middleware that authenticates requests using JWT tokens
+// Authenticate is a middleware that authenticates requests using JWT tokens,
+// API keys or, without either, verified client certificates
 func (m *AuthMiddleware) Authenticate() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Skip authentication for certain paths
-		if c.Request.URL.Path == "/healthz" || c.Request.URL.Path == "/readyz" ||
-			c.Request.URL.Path == "/auth" || c.Request.URL.Path == "/public" {
+		// Skip authentication for public routes
+		if m.public.Matches(c.Request.URL.Path) {
 			c.Next()
 			return
 		}
 
-		// Get the Authorization header
+		// Services authenticate with an API key instead of a token
+		if key, ok := apiKeyFromRequest(c); ok {
+			m.authenticateAPIKey(c, key)
+			return
+		}
+
+		// Services on mutual TLS connections may authenticate with their
+		// verified client certificate instead
 		authHeader := c.GetHeader("Authorization")
+		if authHeader == "" {
+			if cert, ok := verifiedClientCertificate(c.Request); ok {
+				m.authenticateClientCertificate(c, cert)
+				return
+			}
+		}
+
+		// Require the Authorization header
 		if authHeader == "" {
 			m.logger.Error("Authorization header is missing", map[string]interface{}{
 				"path": c.Request.URL.Path,
@@ -66,7 +106,7 @@ func (m *AuthMiddleware) Authenticate() gin.HandlerFunc {
 		tokenString := authHeader[len(prefix):]
 
 		// Validate the token
-		claims, err := m.tokenService.ValidateToken(tokenString)
+		identity, err := m.verifyToken(c.Request.Context(), tokenString)
 		if err != nil {
 			m.logger.Error("Authentication failed", map[string]interface{}{
 				"error": err.Error(),
@@ -79,24 +119,176 @@ func (m *AuthMiddleware) Authenticate() gin.HandlerFunc {
 			return
 		}
 
+		// Reject tokens revoked before they expired
+		revoked, err := m.revocations.IsRevoked(c.Request.Context(), identity.TokenID, identity.Subject, identity.IssuedAt)
+		if err != nil {
+			m.logger.Error("Failed to check token revocation", map[string]interface{}{
+				"error": err.Error(),
+				"path":  c.Request.URL.Path,
+			})
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error": "Unable to verify token",
+			})
+			c.Abort()
+			return
+		}
+		if revoked {
+			m.logger.Warn("Revoked token presented", map[string]interface{}{
+				"email": identity.Email,
+				"path":  c.Request.URL.Path,
+			})
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Token has been revoked",
+			})
+			c.Abort()
+			return
+		}
+
 		// Set user information in context
-		c.Set("userEmail", claims.Email)
-		c.Set("userID", claims.Subject)
+		tenant := m.config.TenantOrDefault(identity.Tenant)
+		c.Set("userEmail", identity.Email)
+		c.Set("userID", identity.Subject)
+		c.Set("userRoles", identity.Roles)
+		c.Set("userTenant", tenant)
+		c.Set("userAMR", identity.AMR)
+		c.Set("userScopes", identity.Scopes)
+		if identity.Actor != "" {
+			c.Set("actorEmail", identity.Actor)
+		}
 
-		// Check if user is a super admin
-		isSuperAdmin := m.tokenService.IsSuperAdmin(claims.Email)
+		// Check if user is a super admin. When MFA is mandatory for the
+		// superadmin, tokens from logins without it carry no privileges
+		// beyond the Casbin rules of the email. Impersonation and scoped
+		// tokens never carry superadmin privileges, whoever the subject is,
+		// nor do identities asserted by an external provider: the superadmin
+		// is an account of this service, not an email any provider may claim.
+		isSuperAdmin := !identity.External && m.tokenService.IsSuperAdmin(identity.Email) && identity.Actor == "" && len(identity.Scopes) == 0
+		if isSuperAdmin && m.config.MFA.RequiredForSuperAdmin && !slices.Contains(identity.AMR, auth.AMRMFA) {
+			m.logger.Warn("Superadmin token without MFA, superadmin privileges withheld", map[string]interface{}{
+				"email": identity.Email,
+				"path":  c.Request.URL.Path,
+			})
+			isSuperAdmin = false
+		}
 		c.Set("isSuperAdmin", isSuperAdmin)
 
+		// Expose the caller to the usecase layer through the request context
+		c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), &auth.Principal{
+			ID:             identity.Subject,
+			Email:          identity.Email,
+			Roles:          identity.Roles,
+			Tenant:         tenant,
+			IsSuperAdmin:   isSuperAdmin,
+			AMR:            identity.AMR,
+			Scopes:         identity.Scopes,
+			Actor:          identity.Actor,
+			TokenID:        identity.TokenID,
+			TokenExpiresAt: identity.ExpiresAt,
+		}))
+
 		m.logger.Info("User authenticated", map[string]interface{}{
-			"email":        claims.Email,
+			"email":        identity.Email,
+			"tenant":       tenant,
 			"path":         c.Request.URL.Path,
 			"isSuperAdmin": isSuperAdmin,
+			"actor":        identity.Actor,
 		})
 
 		c.Next()
 	}
 }
 
+// authenticateAPIKey authenticates the service presenting the API key and
+// sets its principal, the subject service:<name>, in the context
+func (m *AuthMiddleware) authenticateAPIKey(c *gin.Context, key string) {
+	if m.apiKeys == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "API keys are not accepted",
+		})
+		c.Abort()
+		return
+	}
+
+	apiKey, err := m.apiKeys.Authenticate(c.Request.Context(), key)
+	if err != nil {
+		if !errors.Is(err, model.ErrInvalidToken) {
+			m.logger.Error("Failed to verify API key", map[string]interface{}{
+				"error": err.Error(),
+				"path":  c.Request.URL.Path,
+			})
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error": "Unable to verify API key",
+			})
+			c.Abort()
+			return
+		}
+		m.logger.Error("API key authentication failed", map[string]interface{}{
+			"path": c.Request.URL.Path,
+		})
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication failed",
+		})
+		c.Abort()
+		return
+	}
+
+	// Services are never superadmins; their access comes from Casbin rules
+	// naming their subject or the roles assigned to it
+	subject := auth.ServiceSubject(apiKey.Service)
+	tenant := m.config.TenantOrDefault(apiKey.Tenant)
+	c.Set("userEmail", subject)
+	c.Set("userID", subject)
+	c.Set("userRoles", []string(nil))
+	c.Set("userScopes", apiKey.Scopes)
+	c.Set("userTenant", tenant)
+	c.Set("isSuperAdmin", false)
+
+	c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), &auth.Principal{
+		ID:      subject,
+		Email:   subject,
+		Tenant:  tenant,
+		Scopes:  apiKey.Scopes,
+		Service: apiKey.Service,
+	}))
+
+	m.logger.Info("Service authenticated", map[string]interface{}{
+		"service": apiKey.Service,
+		"key":     apiKey.Prefix,
+		"tenant":  tenant,
+		"path":    c.Request.URL.Path,
+	})
+
+	c.Next()
+}
+
+// apiKeyFromRequest returns the API key sent in the X-API-Key header or as
+// "Authorization: ApiKey {key}"
+func apiKeyFromRequest(c *gin.Context) (string, bool) {
+	if key := c.GetHeader("X-API-Key"); key != "" {
+		return key, true
+	}
+	const prefix = "ApiKey "
+	if authHeader := c.GetHeader("Authorization"); strings.HasPrefix(authHeader, prefix) {
+		return strings.TrimSpace(authHeader[len(prefix):]), true
+	}
+	return "", false
+}
+
+// verifyToken validates a bearer token with the verifier for its issuer.
+// Tokens from the configured OIDC provider are verified against its keys;
+// everything else must be a token issued by the token service.
+func (m *AuthMiddleware) verifyToken(ctx context.Context, tokenString string) (*jwt.Identity, error) {
+	if m.oidc != nil && jwt.UnverifiedIssuer(tokenString) == m.oidc.Issuer() {
+		return m.oidc.Verify(ctx, tokenString)
+	}
+
+	claims, err := m.tokenService.ValidateToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	return claims.Identity(), nil
+}
+
 // RequireAuthentication is a middleware that requires authentication
 func (m *AuthMiddleware) RequireAuthentication() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -127,3 +319,42 @@ func (m *AuthMiddleware) RequireSuperAdmin() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// RequireScopes is a middleware that requires a caller whose scopes include
+// every named role. Callers without scopes carry every role they hold and
+// pass; Casbin still decides what those roles allow.
+func (m *AuthMiddleware) RequireScopes(scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		held := c.GetStringSlice("userScopes")
+		if len(held) == 0 {
+			c.Next()
+			return
+		}
+		for _, scope := range scopes {
+			if !slices.Contains(held, scope) {
+				c.Header("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_scope", scope="%s"`, strings.Join(scopes, " ")))
+				c.JSON(http.StatusForbidden, gin.H{
+					"error": "Insufficient scope",
+				})
+				c.Abort()
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// RequireMFA is a middleware that requires a token from a login that passed
+// multi-factor authentication, as recorded in its amr claim
+func (m *AuthMiddleware) RequireMFA() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !slices.Contains(c.GetStringSlice("userAMR"), auth.AMRMFA) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Multi-factor authentication required",
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
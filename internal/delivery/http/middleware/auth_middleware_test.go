@@ -1,14 +1,21 @@
 package middleware
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/bgaurav7/gin-microservice-boilerplate/config"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/auth"
 	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/jwt"
 	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/rbac"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/revocation"
+	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/model"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 )
@@ -16,17 +23,19 @@ import (
 func TestAuthMiddleware(t *testing.T) {
 	// Setup test config
 	authConfig := &config.AuthConfig{
-		JWTSecret:      "test-secret",
-		JWTExpiryHours: 1,
-		SuperAdminEmail: "admin@example.com",
+		JWTSecret:             "test-secret",
+		AccessTokenTTLMinutes: 60,
+		SuperAdminEmail:       "admin@example.com",
 	}
 
 	// Setup logger and token service
 	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
-	tokenService := jwt.NewTokenService(authConfig)
+	tokenService, err := jwt.NewTokenService(authConfig)
+	assert.NoError(t, err)
 
 	// Setup auth middleware
-	authMiddleware := NewAuthMiddleware(tokenService, log, authConfig)
+	revocations := revocation.NewMemoryStore()
+	authMiddleware := NewAuthMiddleware(tokenService, nil, revocations, nil, defaultPublicRoutes(t), log, authConfig)
 
 	// Setup gin router
 	gin.SetMode(gin.TestMode)
@@ -45,6 +54,20 @@ func TestAuthMiddleware(t *testing.T) {
 		})
 	})
 	
+	// Add route that reads the principal from the request context
+	router.GET("/principal", authMiddleware.RequireAuthentication(), func(c *gin.Context) {
+		principal, ok := auth.PrincipalFromContext(c.Request.Context())
+		if !ok {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"id":           principal.ID,
+			"isSuperAdmin": principal.IsSuperAdmin,
+			"tokenID":      principal.TokenID,
+		})
+	})
+
 	// Add public route
 	router.GET("/public", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"status": "public"})
@@ -79,7 +102,7 @@ func TestAuthMiddleware(t *testing.T) {
 
 	t.Run("Protected endpoint should be accessible with valid token", func(t *testing.T) {
 		// Generate token for test user
-		token, _ := tokenService.GenerateToken("user@example.com")
+		token, _ := tokenService.GenerateToken("user@example.com", "")
 		
 		req, _ := http.NewRequest("GET", "/protected", nil)
 		req.Header.Set("Authorization", "Bearer "+token)
@@ -91,7 +114,7 @@ func TestAuthMiddleware(t *testing.T) {
 
 	t.Run("Superadmin should have isSuperAdmin flag set to true", func(t *testing.T) {
 		// Generate token for superadmin user
-		token, _ := tokenService.GenerateToken("admin@example.com")
+		token, _ := tokenService.GenerateToken("admin@example.com", "")
 		
 		req, _ := http.NewRequest("GET", "/protected", nil)
 		req.Header.Set("Authorization", "Bearer "+token)
@@ -109,7 +132,7 @@ func TestAuthMiddleware(t *testing.T) {
 
 	t.Run("Regular user should have isSuperAdmin flag set to false", func(t *testing.T) {
 		// Generate token for regular user
-		token, _ := tokenService.GenerateToken("user@example.com")
+		token, _ := tokenService.GenerateToken("user@example.com", "")
 		
 		req, _ := http.NewRequest("GET", "/protected", nil)
 		req.Header.Set("Authorization", "Bearer "+token)
@@ -125,6 +148,58 @@ func TestAuthMiddleware(t *testing.T) {
 		assert.Equal(t, false, response["isSuperAdmin"])
 	})
 
+	t.Run("Principal should be available on the request context", func(t *testing.T) {
+		token, _ := tokenService.GenerateToken("user@example.com", "")
+
+		req, _ := http.NewRequest("GET", "/principal", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err)
+		assert.Equal(t, "user@example.com", response["id"])
+		assert.Equal(t, false, response["isSuperAdmin"])
+		assert.NotEmpty(t, response["tokenID"])
+	})
+
+	t.Run("Revoked token should be rejected", func(t *testing.T) {
+		token, _ := tokenService.GenerateToken("user@example.com", "")
+		claims, _ := tokenService.ValidateToken(token)
+		_ = revocations.RevokeToken(context.Background(), claims.ID, claims.ExpiresAt.Time)
+
+		req, _ := http.NewRequest("GET", "/protected", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+		// Other tokens of the same user are unaffected
+		other, _ := tokenService.GenerateToken("user@example.com", "")
+		req, _ = http.NewRequest("GET", "/protected", nil)
+		req.Header.Set("Authorization", "Bearer "+other)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Tokens issued before a subject revocation should be rejected", func(t *testing.T) {
+		token, _ := tokenService.GenerateToken("revoked@example.com", "")
+		_ = revocations.RevokeSubject(context.Background(), "revoked@example.com", time.Now())
+
+		req, _ := http.NewRequest("GET", "/protected", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
 	t.Run("Invalid token should be rejected", func(t *testing.T) {
 		req, _ := http.NewRequest("GET", "/protected", nil)
 		req.Header.Set("Authorization", "Bearer invalid-token")
@@ -134,3 +209,130 @@ func TestAuthMiddleware(t *testing.T) {
 		assert.Equal(t, http.StatusUnauthorized, w.Code)
 	})
 }
+
+func TestAuthMiddleware_MFA(t *testing.T) {
+	authConfig := &config.AuthConfig{
+		JWTSecret:             "test-secret",
+		AccessTokenTTLMinutes: 60,
+		SuperAdminEmail:       "admin@example.com",
+		MFA:                   config.MFAConfig{RequiredForSuperAdmin: true},
+	}
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+	tokenService, err := jwt.NewTokenService(authConfig)
+	assert.NoError(t, err)
+	authMiddleware := NewAuthMiddleware(tokenService, nil, revocation.NewMemoryStore(), nil, defaultPublicRoutes(t), log, authConfig)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(authMiddleware.Authenticate())
+	router.GET("/admin", authMiddleware.RequireMFA(), authMiddleware.RequireSuperAdmin(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	router.GET("/principal", authMiddleware.RequireAuthentication(), func(c *gin.Context) {
+		principal, _ := auth.PrincipalFromContext(c.Request.Context())
+		c.JSON(http.StatusOK, gin.H{
+			"isSuperAdmin": principal.IsSuperAdmin,
+			"mfa":          principal.HasAMR(auth.AMRMFA),
+		})
+	})
+
+	// Casbin allows the superadmin's email nothing
+	m, _ := model.NewModelFromFile("../../../infrastructure/rbac/model.conf")
+	e, _ := casbin.NewEnforcer(m)
+	rbacMiddleware := NewRBACMiddleware(rbac.NewStaticEnforcer(e), defaultPublicRoutes(t), log, authConfig, nil)
+	router.GET("/api/v1/todos", rbacMiddleware.Authorize(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	get := func(path string, amr ...string) *httptest.ResponseRecorder {
+		token, err := tokenService.IssueToken(auth.TokenGrant{Email: "admin@example.com", AMR: amr})
+		assert.NoError(t, err)
+		req, _ := http.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Superadmin with MFA", func(t *testing.T) {
+		w := get("/admin", auth.AMRPassword, auth.AMROTP, auth.AMRMFA)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = get("/principal", auth.AMRPassword, auth.AMROTP, auth.AMRMFA)
+		assert.JSONEq(t, `{"isSuperAdmin":true,"mfa":true}`, w.Body.String())
+
+		w = get("/api/v1/todos", auth.AMRPassword, auth.AMROTP, auth.AMRMFA)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Superadmin without MFA loses superadmin privileges", func(t *testing.T) {
+		w := get("/admin", auth.AMRPassword)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "Multi-factor authentication required")
+
+		w = get("/principal", auth.AMRPassword)
+		assert.JSONEq(t, `{"isSuperAdmin":false,"mfa":false}`, w.Body.String())
+
+		// Casbin is not bypassed, and grants the email nothing
+		w = get("/api/v1/todos", auth.AMRPassword)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
+
+func TestAuthMiddleware_Scopes(t *testing.T) {
+	authConfig := &config.AuthConfig{
+		JWTSecret:             "test-secret",
+		AccessTokenTTLMinutes: 60,
+		SuperAdminEmail:       "admin@example.com",
+	}
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+	tokenService, err := jwt.NewTokenService(authConfig)
+	assert.NoError(t, err)
+	authMiddleware := NewAuthMiddleware(tokenService, nil, revocation.NewMemoryStore(), nil, defaultPublicRoutes(t), log, authConfig)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(authMiddleware.Authenticate())
+	router.POST("/todos", authMiddleware.RequireScopes("editor"), func(c *gin.Context) {
+		principal, _ := auth.PrincipalFromContext(c.Request.Context())
+		c.JSON(http.StatusOK, gin.H{
+			"scopes":       principal.Scopes,
+			"userScopes":   c.GetStringSlice("userScopes"),
+			"isSuperAdmin": c.GetBool("isSuperAdmin"),
+		})
+	})
+
+	post := func(email string, scopes ...string) *httptest.ResponseRecorder {
+		token, err := tokenService.IssueToken(auth.TokenGrant{Email: email, Scopes: scopes})
+		assert.NoError(t, err)
+		req, _ := http.NewRequest("POST", "/todos", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Unscoped tokens pass", func(t *testing.T) {
+		w := post("user@example.com")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"scopes":null,"userScopes":null,"isSuperAdmin":false}`, w.Body.String())
+	})
+
+	t.Run("Tokens with the scope pass", func(t *testing.T) {
+		w := post("user@example.com", "viewer", "editor")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"scopes":["viewer","editor"],"userScopes":["viewer","editor"],"isSuperAdmin":false}`, w.Body.String())
+	})
+
+	t.Run("Tokens without the scope are refused", func(t *testing.T) {
+		w := post("user@example.com", "viewer")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Equal(t, `Bearer error="insufficient_scope", scope="editor"`, w.Header().Get("WWW-Authenticate"))
+	})
+
+	t.Run("Scoped superadmin tokens carry no superadmin privileges", func(t *testing.T) {
+		w := post("admin@example.com", "editor")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"isSuperAdmin":false`)
+	})
+}
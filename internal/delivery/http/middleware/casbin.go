@@ -4,39 +4,39 @@ import (
 	"net/http"
 
 	"github.com/bgaurav7/gin-microservice-boilerplate/config"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/auth"
 	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
 	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/rbac"
-	"github.com/casbin/casbin/v2"
 	"github.com/gin-gonic/gin"
 )
 
 // RBACMiddleware represents the RBAC middleware
 type RBACMiddleware struct {
-	enforcer *casbin.Enforcer
-	logger   *logger.Logger
-	config   *config.AuthConfig
+	routes *rbac.RouteAuthorizer
+	public *PublicRoutes
+	logger *logger.Logger
+	config *config.AuthConfig
 }
 
-// NewRBACMiddleware creates a new RBAC middleware
-func NewRBACMiddleware(logger *logger.Logger, config *config.AuthConfig) (*RBACMiddleware, error) {
-	enforcer, err := rbac.NewEnforcer()
-	if err != nil {
-		return nil, err
-	}
-
+// NewRBACMiddleware creates a new RBAC middleware.
+// Requests are authorized with the enforcer active when they arrive, so
+// reloaded models and policies apply from the next request on. resources
+// maps route group prefixes to the resource names used in Casbin objects.
+// Requests to public routes pass through without authorization.
+func NewRBACMiddleware(enforcer *rbac.Enforcer, public *PublicRoutes, logger *logger.Logger, config *config.AuthConfig, resources map[string]string) *RBACMiddleware {
 	return &RBACMiddleware{
-		enforcer: enforcer,
-		logger:   logger,
-		config:   config,
-	}, nil
+		routes: rbac.NewRouteAuthorizer(enforcer, resources, logger),
+		public: public,
+		logger: logger,
+		config: config,
+	}
 }
 
 // Authorize is a middleware that authorizes requests using Casbin
 func (m *RBACMiddleware) Authorize() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Skip authorization for certain paths
-		if c.Request.URL.Path == "/healthz" || c.Request.URL.Path == "/readyz" ||
-			c.Request.URL.Path == "/auth" || c.Request.URL.Path == "/public" {
+		// Skip authorization for public routes
+		if m.public.Matches(c.Request.URL.Path) {
 			c.Next()
 			return
 		}
@@ -63,28 +63,39 @@ func (m *RBACMiddleware) Authorize() gin.HandlerFunc {
 			return
 		}
 
-		// Superadmin override - always allow access
-		if email == m.config.SuperAdminEmail {
-			m.logger.Info("Superadmin access granted", map[string]interface{}{
-				"email": email,
-				"path":  c.Request.URL.Path,
+		// Requests that matched no route have no template to authorize against
+		route := c.FullPath()
+		if route == "" {
+			m.logger.Warn("Access denied to unmatched route", map[string]interface{}{
+				"email":  email,
+				"path":   c.Request.URL.Path,
 				"method": c.Request.Method,
 			})
-			c.Next()
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Forbidden",
+			})
+			c.Abort()
 			return
 		}
 
-		// Check if user has permission
-		obj := c.Request.URL.Path
+		// Check if user, or a role asserted by the token issuer such as an OIDC
+		// group, has permission in their tenant. Scoped callers, such as API
+		// keys and tokens with scopes, only act with the roles their scopes name.
+		// Superadmins are always allowed; Authenticate withholds superadmin
+		// privileges from scoped and impersonation tokens, and from logins
+		// without MFA when MFA is required.
+		principal := &auth.Principal{
+			Email:        email,
+			IsSuperAdmin: c.GetBool("isSuperAdmin"),
+			Roles:        c.GetStringSlice("userRoles"),
+			Scopes:       c.GetStringSlice("userScopes"),
+			Tenant:       m.config.TenantOrDefault(c.GetString("userTenant")),
+		}
+		tenant := principal.Tenant
+		obj := m.routes.Object(route)
 		act := c.Request.Method
-		allowed, err := m.enforcer.Enforce(email, obj, act)
+		allowed, err := m.routes.AuthorizeRoute(c.Request.Context(), principal, act, route)
 		if err != nil {
-			m.logger.Error("Casbin enforcement error", map[string]interface{}{
-				"error": err.Error(),
-				"email": email,
-				"path":  obj,
-				"method": act,
-			})
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"error": "Internal server error",
 			})
@@ -94,8 +105,9 @@ func (m *RBACMiddleware) Authorize() gin.HandlerFunc {
 
 		if !allowed {
 			m.logger.Warn("Access denied", map[string]interface{}{
-				"email": email,
-				"path":  obj,
+				"email":  email,
+				"tenant": tenant,
+				"object": obj,
 				"method": act,
 			})
 			c.JSON(http.StatusForbidden, gin.H{
@@ -106,9 +118,11 @@ func (m *RBACMiddleware) Authorize() gin.HandlerFunc {
 		}
 
 		m.logger.Info("Access granted", map[string]interface{}{
-			"email": email,
-			"path":  obj,
-			"method": act,
+			"email":        email,
+			"tenant":       tenant,
+			"object":       obj,
+			"method":       act,
+			"isSuperAdmin": principal.IsSuperAdmin,
 		})
 		c.Next()
 	}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/bgaurav7/gin-microservice-boilerplate/config"
 	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/rbac"
 	"github.com/casbin/casbin/v2"
 	"github.com/casbin/casbin/v2/model"
 	"github.com/gin-gonic/gin"
@@ -22,30 +23,35 @@ func TestRBACMiddleware(t *testing.T) {
 	// Create a simple model for testing
 	m, _ := model.NewModelFromString(`
 [request_definition]
-r = sub, obj, act
+r = sub, dom, obj, act
 
 [policy_definition]
-p = sub, obj, act
+p = sub, dom, obj, act
 
 [role_definition]
-g = _, _
+g = _, _, _
 
 [policy_effect]
 e = some(where (p.eft == allow))
 
 [matchers]
-m = g(r.sub, p.sub) && keyMatch(r.obj, p.obj) && r.act == p.act
+m = g(r.sub, p.sub, r.dom) && keyMatch(r.dom, p.dom) && keyMatch(r.obj, p.obj) && r.act == p.act
 `)
 
 	// Create a test enforcer
 	e, _ := casbin.NewEnforcer(m)
 	
 	// Add test policies
-	e.AddPolicy("admin", "/api/v1/todos", "GET")
-	e.AddPolicy("admin", "/api/v1/todos", "POST")
-	e.AddPolicy("user", "/api/v1/todos", "GET")
-	e.AddGroupingPolicy("alice@example.com", "admin")
-	e.AddGroupingPolicy("bob@example.com", "user")
+	e.AddPolicy("admin", "*", "/api/v1/todos", "GET")
+	e.AddPolicy("admin", "*", "/api/v1/todos", "POST")
+	e.AddPolicy("user", "*", "/api/v1/todos", "GET")
+	e.AddPolicy("user", "*", "/api/v1/todos/:id", "GET")
+	e.AddPolicy("user", "*", "/api/v1/todos/42", "DELETE")
+	e.AddPolicy("auditor", "acme", "/api/v1/todos", "GET")
+	e.AddGroupingPolicy("alice@example.com", "admin", "default")
+	e.AddGroupingPolicy("bob@example.com", "user", "default")
+	e.AddGroupingPolicy("dave@example.com", "auditor", "acme")
+	e.AddGroupingPolicy("dave@example.com", "auditor", "default")
 
 	// Create config with superadmin
 	authConfig := &config.AuthConfig{
@@ -53,19 +59,20 @@ m = g(r.sub, p.sub) && keyMatch(r.obj, p.obj) && r.act == p.act
 	}
 
 	// Create middleware with mocked enforcer
-	middleware := &RBACMiddleware{
-		enforcer: e,
-		logger:   log,
-		config:   authConfig,
-	}
+	middleware := NewRBACMiddleware(rbac.NewStaticEnforcer(e), defaultPublicRoutes(t), log, authConfig, nil)
 
 	// Test cases
 	tests := []struct {
-		name       string
-		path       string
-		method     string
-		userEmail  string
-		statusCode int
+		name         string
+		route        string
+		path         string
+		method       string
+		userEmail    string
+		userRoles    []string
+		userScopes   []string
+		tenant       string
+		isSuperAdmin bool
+		statusCode   int
 	}{
 		{
 			name:       "Public endpoint should be accessible",
@@ -95,6 +102,22 @@ m = g(r.sub, p.sub) && keyMatch(r.obj, p.obj) && r.act == p.act
 			userEmail:  "bob@example.com",
 			statusCode: http.StatusOK,
 		},
+		{
+			name:       "Route template is authorized instead of the path",
+			route:      "/api/v1/todos/:id",
+			path:       "/api/v1/todos/42",
+			method:     "GET",
+			userEmail:  "bob@example.com",
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Policy for a concrete path does not match the route",
+			route:      "/api/v1/todos/:id",
+			path:       "/api/v1/todos/42",
+			method:     "DELETE",
+			userEmail:  "bob@example.com",
+			statusCode: http.StatusForbidden,
+		},
 		{
 			name:       "User cannot access POST endpoint",
 			path:       "/api/v1/todos",
@@ -110,12 +133,83 @@ m = g(r.sub, p.sub) && keyMatch(r.obj, p.obj) && r.act == p.act
 			statusCode: http.StatusForbidden,
 		},
 		{
-			name:       "Superadmin can access any endpoint",
+			name:       "Role from the identity provider grants access",
+			path:       "/api/v1/todos",
+			method:     "POST",
+			userEmail:  "carol@example.com",
+			userRoles:  []string{"engineering", "admin"},
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Unknown provider roles grant nothing",
+			path:       "/api/v1/todos",
+			method:     "GET",
+			userEmail:  "carol@example.com",
+			userRoles:  []string{"engineering"},
+			statusCode: http.StatusForbidden,
+		},
+		{
+			name:       "Roles only apply in the tenant they are assigned in",
+			path:       "/api/v1/todos",
+			method:     "GET",
+			userEmail:  "bob@example.com",
+			tenant:     "acme",
+			statusCode: http.StatusForbidden,
+		},
+		{
+			name:       "Tenant-specific rule grants access in its tenant",
+			path:       "/api/v1/todos",
+			method:     "GET",
+			userEmail:  "dave@example.com",
+			tenant:     "acme",
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Tenant-specific rule grants nothing in other tenants",
+			path:       "/api/v1/todos",
+			method:     "GET",
+			userEmail:  "dave@example.com",
+			statusCode: http.StatusForbidden,
+		},
+		{
+			name:         "Superadmin can access any endpoint",
+			path:         "/api/v1/todos",
+			method:       "POST",
+			userEmail:    "admin@example.com",
+			isSuperAdmin: true,
+			statusCode:   http.StatusOK,
+		},
+		{
+			name:       "Superadmin email without superadmin privileges is not overridden",
 			path:       "/api/v1/todos",
 			method:     "POST",
 			userEmail:  "admin@example.com",
+			statusCode: http.StatusForbidden,
+		},
+		{
+			name:       "Scoped token acts with the roles its scopes name",
+			path:       "/api/v1/todos",
+			method:     "POST",
+			userEmail:  "alice@example.com",
+			userScopes: []string{"admin"},
 			statusCode: http.StatusOK,
 		},
+		{
+			name:       "Scopes naming roles the user does not hold grant nothing",
+			path:       "/api/v1/todos",
+			method:     "GET",
+			userEmail:  "alice@example.com",
+			userScopes: []string{"user"},
+			statusCode: http.StatusForbidden,
+		},
+		{
+			name:       "Scoped superadmin token is not overridden",
+			path:       "/api/v1/todos",
+			method:     "POST",
+			userEmail:  "admin@example.com",
+			userScopes: []string{"admin"},
+			statusCode: http.StatusForbidden,
+		},
 	}
 
 	for _, tt := range tests {
@@ -130,12 +224,26 @@ m = g(r.sub, p.sub) && keyMatch(r.obj, p.obj) && r.act == p.act
 				if tt.userEmail != "" {
 					c.Set("userEmail", tt.userEmail)
 				}
+				if tt.userRoles != nil {
+					c.Set("userRoles", tt.userRoles)
+				}
+				if tt.userScopes != nil {
+					c.Set("userScopes", tt.userScopes)
+				}
+				if tt.tenant != "" {
+					c.Set("userTenant", tt.tenant)
+				}
+				c.Set("isSuperAdmin", tt.isSuperAdmin)
 				c.Next()
 			})
 			r.Use(middleware.Authorize())
 			
 			// Add test route
-			r.Any(tt.path, func(c *gin.Context) {
+			route := tt.route
+			if route == "" {
+				route = tt.path
+			}
+			r.Any(route, func(c *gin.Context) {
 				c.Status(http.StatusOK)
 			})
 
@@ -150,3 +258,53 @@ m = g(r.sub, p.sub) && keyMatch(r.obj, p.obj) && r.act == p.act
 		})
 	}
 }
+
+func TestRBACMiddleware_Routes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+
+	m, _ := model.NewModelFromFile("../../../infrastructure/rbac/model.conf")
+	e, _ := casbin.NewEnforcer(m)
+	e.AddPolicy("user", "*", "todos:/api/v1/todos/:id", "GET")
+	e.AddPolicy("user", "*", "/api/v1/todos", "GET")
+	e.AddGroupingPolicy("bob@example.com", "user", "default")
+
+	rbacMiddleware := NewRBACMiddleware(rbac.NewStaticEnforcer(e), defaultPublicRoutes(t), log, &config.AuthConfig{SuperAdminEmail: "admin@example.com"}, map[string]string{
+		"/api/v1/todos/": "todos",
+		"/api/v1":        "api",
+	})
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("userEmail", c.GetHeader("X-Test-Email"))
+		c.Set("isSuperAdmin", c.GetHeader("X-Test-Email") == "admin@example.com")
+		c.Next()
+	})
+	r.Use(rbacMiddleware.Authorize())
+	r.GET("/api/v1/todos", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/api/v1/todos/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		name       string
+		path       string
+		email      string
+		statusCode int
+	}{
+		{name: "Object is prefixed with the longest matching resource", path: "/api/v1/todos/42", email: "bob@example.com", statusCode: http.StatusOK},
+		{name: "Routes outside a group use the enclosing group's resource", path: "/api/v1/todos", email: "bob@example.com", statusCode: http.StatusForbidden},
+		{name: "Unmatched route is denied", path: "/api/v1/unknown", email: "bob@example.com", statusCode: http.StatusForbidden},
+		{name: "Unmatched route is denied to superadmins", path: "/api/v1/unknown", email: "admin@example.com", statusCode: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("X-Test-Email", tt.email)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.statusCode, w.Code)
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package middleware
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/config"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/auth"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/jwt"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/rbac"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/revocation"
+	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/model"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newClientCertificate returns a self-signed client certificate with the
+// given common name and DNS SANs
+func newClientCertificate(t *testing.T, commonName string, dnsNames ...string) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(42),
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     dnsNames,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert
+}
+
+func TestAuthMiddleware_ClientCertificate(t *testing.T) {
+	authConfig := &config.AuthConfig{
+		JWTSecret:             "test-secret",
+		AccessTokenTTLMinutes: 60,
+		SuperAdminEmail:       "admin@example.com",
+	}
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+	tokenService, err := jwt.NewTokenService(authConfig)
+	require.NoError(t, err)
+
+	m, _ := model.NewModelFromString(`
+[request_definition]
+r = sub, dom, obj, act
+
+[policy_definition]
+p = sub, dom, obj, act
+
+[role_definition]
+g = _, _, _
+
+[policy_effect]
+e = some(where (p.eft == allow))
+
+[matchers]
+m = g(r.sub, p.sub, r.dom) && keyMatch(r.dom, p.dom) && keyMatch(r.obj, p.obj) && r.act == p.act
+`)
+	e, _ := casbin.NewEnforcer(m)
+	e.AddPolicy("service:billing.internal", "*", "/protected", "GET")
+
+	authMiddleware := NewAuthMiddleware(tokenService, nil, revocation.NewMemoryStore(), nil, defaultPublicRoutes(t), log, authConfig)
+	rbacMiddleware := NewRBACMiddleware(rbac.NewStaticEnforcer(e), defaultPublicRoutes(t), log, authConfig, nil)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(authMiddleware.Authenticate())
+	router.GET("/protected", rbacMiddleware.Authorize(), func(c *gin.Context) {
+		principal, _ := auth.PrincipalFromContext(c.Request.Context())
+		c.JSON(http.StatusOK, gin.H{
+			"userEmail":    c.GetString("userEmail"),
+			"userTenant":   c.GetString("userTenant"),
+			"isSuperAdmin": c.GetBool("isSuperAdmin"),
+			"service":      principal.Service,
+		})
+	})
+
+	get := func(state *tls.ConnectionState, authorization string) (int, map[string]interface{}) {
+		req, _ := http.NewRequest("GET", "/protected", nil)
+		req.TLS = state
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var response map[string]interface{}
+		_ = json.Unmarshal(w.Body.Bytes(), &response)
+		return w.Code, response
+	}
+	verified := func(cert *x509.Certificate) *tls.ConnectionState {
+		return &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+	}
+
+	t.Run("Verified client certificate should set a service principal named by its SAN", func(t *testing.T) {
+		code, response := get(verified(newClientCertificate(t, "billing", "billing.internal")), "")
+
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "service:billing.internal", response["userEmail"])
+		assert.Equal(t, "default", response["userTenant"])
+		assert.Equal(t, false, response["isSuperAdmin"])
+		assert.Equal(t, "billing.internal", response["service"])
+	})
+
+	t.Run("Common name should name the service without a SAN", func(t *testing.T) {
+		code, _ := get(verified(newClientCertificate(t, "billing")), "")
+
+		// Authenticated as service:billing, which the policy does not allow
+		assert.Equal(t, http.StatusForbidden, code)
+	})
+
+	t.Run("Unverified client certificates should be ignored", func(t *testing.T) {
+		cert := newClientCertificate(t, "billing", "billing.internal")
+
+		code, _ := get(&tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}, "")
+		assert.Equal(t, http.StatusUnauthorized, code)
+	})
+
+	t.Run("Certificates naming no service should be rejected", func(t *testing.T) {
+		code, _ := get(verified(newClientCertificate(t, "")), "")
+		assert.Equal(t, http.StatusUnauthorized, code)
+	})
+
+	t.Run("Bearer tokens should take precedence over the certificate", func(t *testing.T) {
+		token, _ := tokenService.GenerateToken("user@example.com", "")
+
+		code, _ := get(verified(newClientCertificate(t, "billing", "billing.internal")), "Bearer "+token)
+
+		// Authenticated as the user, whom the policy does not allow
+		assert.Equal(t, http.StatusForbidden, code)
+	})
+}
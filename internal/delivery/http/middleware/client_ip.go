@@ -0,0 +1,16 @@
+package middleware
+
+import (
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/auth"
+	"github.com/gin-gonic/gin"
+)
+
+// ClientIP returns a gin middleware that stores the client IP in the request
+// context, for usecases that track callers by address. Forwarding headers
+// are only honored from the engine's trusted proxies.
+func ClientIP() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(auth.WithClientIP(c.Request.Context(), c.ClientIP()))
+		c.Next()
+	}
+}
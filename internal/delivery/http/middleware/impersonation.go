@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+	"github.com/gin-gonic/gin"
+)
+
+// AuditImpersonatedRequest is the audit action of requests made while a
+// superadmin impersonates a user
+const AuditImpersonatedRequest = "impersonation.request"
+
+// AuditRecorder records security-relevant events
+type AuditRecorder interface {
+	Record(ctx context.Context, event model.AuditEvent)
+}
+
+// AuditImpersonation returns a gin middleware that writes every request made
+// with an impersonation token to the audit log, once it has been handled.
+// It must run after AuthMiddleware.Authenticate has set the caller.
+func AuditImpersonation(audit AuditRecorder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		actor := c.GetString("actorEmail")
+		if actor == "" {
+			return
+		}
+		audit.Record(c.Request.Context(), model.AuditEvent{
+			Action: AuditImpersonatedRequest,
+			Actor:  actor,
+			IP:     c.ClientIP(),
+			Details: map[string]interface{}{
+				"subject": c.GetString("userEmail"),
+				"tenant":  c.GetString("userTenant"),
+				"method":  c.Request.Method,
+				"path":    c.Request.URL.Path,
+				"status":  c.Writer.Status(),
+			},
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/config"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/auth"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/jwt"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/revocation"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordedAudit keeps the audit events recorded during a test
+type recordedAudit struct {
+	events []model.AuditEvent
+}
+
+func (r *recordedAudit) Record(ctx context.Context, event model.AuditEvent) {
+	r.events = append(r.events, event)
+}
+
+func TestAuthMiddleware_Impersonation(t *testing.T) {
+	authConfig := &config.AuthConfig{
+		JWTSecret:             "test-secret",
+		AccessTokenTTLMinutes: 60,
+		SuperAdminEmail:       "admin@example.com",
+	}
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+	tokenService, err := jwt.NewTokenService(authConfig)
+	require.NoError(t, err)
+	authMiddleware := NewAuthMiddleware(tokenService, nil, revocation.NewMemoryStore(), nil, defaultPublicRoutes(t), log, authConfig)
+
+	audit := &recordedAudit{}
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(authMiddleware.Authenticate())
+	router.Use(AuditImpersonation(audit))
+	router.GET("/principal", authMiddleware.RequireAuthentication(), func(c *gin.Context) {
+		principal, _ := auth.PrincipalFromContext(c.Request.Context())
+		c.JSON(http.StatusOK, gin.H{
+			"email":        principal.Email,
+			"actor":        principal.Actor,
+			"actorEmail":   c.GetString("actorEmail"),
+			"isSuperAdmin": principal.IsSuperAdmin,
+		})
+	})
+	router.GET("/admin", authMiddleware.RequireSuperAdmin(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	get := func(path string, grant auth.TokenGrant) *httptest.ResponseRecorder {
+		token, err := tokenService.IssueToken(grant)
+		require.NoError(t, err)
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Both identities are exposed and the request is audited", func(t *testing.T) {
+		audit.events = nil
+		w := get("/principal", auth.TokenGrant{Email: "user@example.com", Actor: "admin@example.com", TTL: time.Minute})
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "user@example.com", response["email"])
+		assert.Equal(t, "admin@example.com", response["actor"])
+		assert.Equal(t, "admin@example.com", response["actorEmail"])
+
+		require.Len(t, audit.events, 1)
+		event := audit.events[0]
+		assert.Equal(t, AuditImpersonatedRequest, event.Action)
+		assert.Equal(t, "admin@example.com", event.Actor)
+		assert.Equal(t, "user@example.com", event.Details["subject"])
+		assert.Equal(t, "/principal", event.Details["path"])
+		assert.Equal(t, http.StatusOK, event.Details["status"])
+	})
+
+	t.Run("Impersonation never carries superadmin privileges", func(t *testing.T) {
+		audit.events = nil
+		w := get("/admin", auth.TokenGrant{Email: "admin@example.com", Actor: "admin@example.com"})
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		require.Len(t, audit.events, 1)
+		assert.Equal(t, http.StatusForbidden, audit.events[0].Details["status"])
+	})
+
+	t.Run("Ordinary requests are not audited", func(t *testing.T) {
+		audit.events = nil
+		w := get("/principal", auth.TokenGrant{Email: "admin@example.com"})
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"isSuperAdmin":true`)
+		assert.Contains(t, w.Body.String(), `"actor":""`)
+		assert.Empty(t, audit.events)
+	})
+}
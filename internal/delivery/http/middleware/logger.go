@@ -22,7 +22,7 @@ func Logger(log *logger.Logger) gin.HandlerFunc {
 		latency := time.Since(start)
 
 		// Log request
-		log.Info("HTTP request",
+		fields := []interface{}{
 			"status", c.Writer.Status(),
 			"method", c.Request.Method,
 			"path", path,
@@ -30,6 +30,13 @@ func Logger(log *logger.Logger) gin.HandlerFunc {
 			"ip", c.ClientIP(),
 			"user-agent", c.Request.UserAgent(),
 			"latency", latency.String(),
-		)
+		}
+
+		// Tag requests made while a superadmin impersonates the user
+		if actor := c.GetString("actorEmail"); actor != "" {
+			fields = append(fields, "user", c.GetString("userEmail"), "impersonated_by", actor)
+		}
+
+		log.Info("HTTP request", fields...)
 	}
 }
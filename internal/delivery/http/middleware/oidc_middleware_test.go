@@ -0,0 +1,191 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/config"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/jwt"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/revocation"
+	"github.com/gin-gonic/gin"
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthMiddleware_OIDC(t *testing.T) {
+	// Setup a stub OpenID provider publishing a single RSA key
+	providerKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	var provider *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":   provider.URL,
+			"jwks_uri": provider.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(jwt.JWKS{Keys: []jwt.JWK{{
+			Kty: "RSA",
+			Use: "sig",
+			Alg: "RS256",
+			Kid: "provider-key",
+			N:   base64.RawURLEncoding.EncodeToString(providerKey.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(providerKey.E)).Bytes()),
+		}}})
+	})
+	provider = httptest.NewServer(mux)
+	defer provider.Close()
+
+	authConfig := &config.AuthConfig{
+		JWTSecret:             "test-secret",
+		AccessTokenTTLMinutes: 60,
+		SuperAdminEmail:       "admin@example.com",
+		OIDC: config.OIDCConfig{
+			Enabled:   true,
+			IssuerURL: provider.URL,
+			Audience:  "gin-api",
+		},
+	}
+
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+	tokenService, err := jwt.NewTokenService(authConfig)
+	require.NoError(t, err)
+	verifier, err := jwt.NewOIDCVerifier(&authConfig.OIDC, provider.Client())
+	require.NoError(t, err)
+
+	authMiddleware := NewAuthMiddleware(tokenService, verifier, revocation.NewMemoryStore(), nil, defaultPublicRoutes(t), log, authConfig)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(authMiddleware.Authenticate())
+	router.GET("/protected", authMiddleware.RequireAuthentication(), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"userEmail":    c.GetString("userEmail"),
+			"userID":       c.GetString("userID"),
+			"userRoles":    c.GetStringSlice("userRoles"),
+			"isSuperAdmin": c.GetBool("isSuperAdmin"),
+		})
+	})
+	router.GET("/api/v1/admin/api-keys", authMiddleware.RequireSuperAdmin(), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{})
+	})
+
+	providerToken := func(claims gojwt.MapClaims) string {
+		token := gojwt.NewWithClaims(gojwt.SigningMethodRS256, claims)
+		token.Header["kid"] = "provider-key"
+		signed, err := token.SignedString(providerKey)
+		require.NoError(t, err)
+		return signed
+	}
+
+	request := func(path, token string) (int, map[string]interface{}) {
+		req, _ := http.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var response map[string]interface{}
+		_ = json.Unmarshal(w.Body.Bytes(), &response)
+		return w.Code, response
+	}
+	get := func(token string) (int, map[string]interface{}) {
+		return request("/protected", token)
+	}
+
+	t.Run("Provider tokens should be accepted with mapped claims", func(t *testing.T) {
+		code, response := get(providerToken(gojwt.MapClaims{
+			"iss":            provider.URL,
+			"aud":            "gin-api",
+			"sub":            "00u1abcd",
+			"email":          "bob@example.com",
+			"email_verified": true,
+			"groups":         []string{"admin"},
+			"exp":            time.Now().Add(5 * time.Minute).Unix(),
+		}))
+
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "bob@example.com", response["userEmail"])
+		assert.Equal(t, "00u1abcd", response["userID"])
+		assert.Equal(t, []interface{}{"admin"}, response["userRoles"])
+		assert.Equal(t, false, response["isSuperAdmin"])
+	})
+
+	t.Run("Provider tokens should never carry superadmin privileges", func(t *testing.T) {
+		token := providerToken(gojwt.MapClaims{
+			"iss":            provider.URL,
+			"aud":            "gin-api",
+			"sub":            "00u1abcd",
+			"email":          "Admin@Example.com",
+			"email_verified": true,
+			"amr":            []string{"pwd", "mfa"},
+			"exp":            time.Now().Add(5 * time.Minute).Unix(),
+		})
+
+		code, response := get(token)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "admin@example.com", response["userEmail"])
+		assert.Equal(t, false, response["isSuperAdmin"])
+
+		code, _ = request("/api/v1/admin/api-keys", token)
+		assert.Equal(t, http.StatusForbidden, code)
+	})
+
+	t.Run("Provider tokens without a verified email should be rejected", func(t *testing.T) {
+		code, _ := get(providerToken(gojwt.MapClaims{
+			"iss":   provider.URL,
+			"aud":   "gin-api",
+			"sub":   "00u1abcd",
+			"email": "user@example.com",
+			"exp":   time.Now().Add(5 * time.Minute).Unix(),
+		}))
+		assert.Equal(t, http.StatusUnauthorized, code)
+	})
+
+	t.Run("Local superadmin tokens should carry superadmin privileges", func(t *testing.T) {
+		token, _ := tokenService.GenerateToken("admin@example.com", "")
+
+		code, _ := request("/api/v1/admin/api-keys", token)
+		assert.Equal(t, http.StatusOK, code)
+	})
+
+	t.Run("Local tokens should still be accepted", func(t *testing.T) {
+		token, _ := tokenService.GenerateToken("user@example.com", "")
+
+		code, response := get(token)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "user@example.com", response["userEmail"])
+	})
+
+	t.Run("Provider tokens for another audience should be rejected", func(t *testing.T) {
+		code, _ := get(providerToken(gojwt.MapClaims{
+			"iss":   provider.URL,
+			"aud":   "another-api",
+			"sub":   "00u1abcd",
+			"email": "user@example.com",
+			"exp":   time.Now().Add(5 * time.Minute).Unix(),
+		}))
+		assert.Equal(t, http.StatusUnauthorized, code)
+	})
+
+	t.Run("Tokens claiming an unknown issuer should be rejected", func(t *testing.T) {
+		code, _ := get(providerToken(gojwt.MapClaims{
+			"iss":   "https://evil.example.com",
+			"aud":   "gin-api",
+			"sub":   "00u1abcd",
+			"email": "user@example.com",
+			"exp":   time.Now().Add(5 * time.Minute).Unix(),
+		}))
+		assert.Equal(t, http.StatusUnauthorized, code)
+	})
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// groupSuffix marks a pattern matching a route group: the prefix itself and
+// every path beneath it
+const groupSuffix = "/**"
+
+// PublicRoutes is the registry of paths served without authentication or
+// authorization. It is shared by the auth and RBAC middlewares, so a path is
+// either public for both or for neither.
+//
+// A pattern is either
+//   - a path, such as /healthz, matching that path only
+//   - a route group, such as /public/**, matching /public and every path beneath it
+//   - a glob, such as /docs/*.json, where * matches within a single path segment
+//     as in path.Match
+type PublicRoutes struct {
+	exact  map[string]bool
+	groups []string
+	globs  []string
+}
+
+// NewPublicRoutes creates the registry of public routes from patterns
+func NewPublicRoutes(patterns []string) (*PublicRoutes, error) {
+	routes := &PublicRoutes{exact: make(map[string]bool)}
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if !strings.HasPrefix(pattern, "/") {
+			return nil, fmt.Errorf("public route %q must start with /", pattern)
+		}
+
+		switch {
+		case strings.HasSuffix(pattern, groupSuffix):
+			prefix := strings.TrimSuffix(pattern, groupSuffix)
+			if strings.ContainsAny(prefix, "*?[") {
+				return nil, fmt.Errorf("public route group %q must not contain wildcards before %s", pattern, groupSuffix)
+			}
+			routes.groups = append(routes.groups, prefix)
+		case strings.ContainsAny(pattern, "*?["):
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("public route %q: %w", pattern, err)
+			}
+			routes.globs = append(routes.globs, pattern)
+		default:
+			routes.exact[pattern] = true
+		}
+	}
+	return routes, nil
+}
+
+// Matches reports whether a request path is public. The path is cleaned
+// first, so dot segments cannot lead out of a public group.
+func (r *PublicRoutes) Matches(requestPath string) bool {
+	if r == nil {
+		return false
+	}
+	if requestPath == "" {
+		requestPath = "/"
+	}
+	requestPath = path.Clean(requestPath)
+
+	if r.exact[requestPath] {
+		return true
+	}
+	for _, prefix := range r.groups {
+		if requestPath == prefix || prefix == "" || strings.HasPrefix(requestPath, prefix+"/") {
+			return true
+		}
+	}
+	for _, glob := range r.globs {
+		if ok, _ := path.Match(glob, requestPath); ok {
+			return true
+		}
+	}
+	return false
+}
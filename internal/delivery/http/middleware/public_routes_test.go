@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"testing"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// defaultPublicRoutes returns the registry of the default public routes
+func defaultPublicRoutes(t *testing.T) *PublicRoutes {
+	t.Helper()
+	routes, err := NewPublicRoutes(config.DefaultPublicRoutes)
+	require.NoError(t, err)
+	return routes
+}
+
+func TestPublicRoutes_Matches(t *testing.T) {
+	routes, err := NewPublicRoutes([]string{"/healthz", "/public/**", "/docs/*.json", "/auth/login"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		path   string
+		public bool
+	}{
+		{name: "Exact path", path: "/healthz", public: true},
+		{name: "Exact path does not match below it", path: "/healthz/details", public: false},
+		{name: "Exact path with trailing slash", path: "/healthz/", public: true},
+		{name: "Route group root", path: "/public", public: true},
+		{name: "Route group member", path: "/public/assets/app.js", public: true},
+		{name: "Route group requires a path separator", path: "/publicity", public: false},
+		{name: "Dot segments cannot leave a route group", path: "/public/../api/v1/todos", public: false},
+		{name: "Glob match", path: "/docs/openapi.json", public: true},
+		{name: "Glob does not cross segments", path: "/docs/v1/openapi.json", public: false},
+		{name: "Sibling of a public path", path: "/auth/logout", public: false},
+		{name: "Protected path", path: "/api/v1/todos", public: false},
+		{name: "Empty path", path: "", public: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.public, routes.Matches(tt.path))
+		})
+	}
+}
+
+func TestPublicRoutes_Defaults(t *testing.T) {
+	routes := defaultPublicRoutes(t)
+
+	for _, path := range []string{"/healthz", "/readyz", "/.well-known/jwks.json", "/auth", "/auth/login", "/auth/register", "/auth/refresh", "/public"} {
+		assert.True(t, routes.Matches(path), path)
+	}
+	for _, path := range []string{"/", "/auth/logout", "/api/v1/todos", "/api/v1/me"} {
+		assert.False(t, routes.Matches(path), path)
+	}
+}
+
+func TestNewPublicRoutes_InvalidPatterns(t *testing.T) {
+	for _, pattern := range []string{"healthz", "", "/docs/[a-", "/api/*/**"} {
+		_, err := NewPublicRoutes([]string{pattern})
+		assert.Error(t, err, pattern)
+	}
+}
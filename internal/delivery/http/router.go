@@ -1,15 +1,30 @@
 package http
 
 import (
+	"fmt"
 	"net/http"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/bgaurav7/gin-microservice-boilerplate/config"
 	"github.com/bgaurav7/gin-microservice-boilerplate/internal/delivery/http/handler"
 	"github.com/bgaurav7/gin-microservice-boilerplate/internal/delivery/http/middleware"
 	v1 "github.com/bgaurav7/gin-microservice-boilerplate/internal/delivery/http/v1"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+	domainrepo "github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/repository"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/audit"
 	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/db"
 	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/jwt"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/lockout"
 	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/mailer"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/password"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/rbac"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/repository"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/revocation"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/totp"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/usecase"
 	"github.com/gin-gonic/gin"
 )
 
@@ -19,34 +34,104 @@ type Router struct {
 	logger         *logger.Logger
 	db             *db.Database
 	tokenService   *jwt.TokenService
+	revocations    domainrepo.RevocationStore
+	apiKeys        usecase.APIKeyUsecase
+	auditLog       *audit.Log
+	enforcer       *rbac.Enforcer
 	config         *config.Config
 	authMiddleware *middleware.AuthMiddleware
 	rbacMiddleware *middleware.RBACMiddleware
 }
 
 // NewRouter creates a new HTTP router
-func NewRouter(logger *logger.Logger, database *db.Database, cfg *config.Config) *Router {
+func NewRouter(logger *logger.Logger, database *db.Database, cfg *config.Config) (*Router, error) {
 	// Set Gin mode
 	gin.SetMode(gin.ReleaseMode)
 
 	// Create router
 	engine := gin.New()
 
+	// Only reverse proxies in server.trusted_proxies may name the client IP
+	// with X-Forwarded-For; otherwise any caller could pick its own address
+	if err := engine.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("invalid trusted proxies: %w", err)
+	}
+
 	// Add middleware
 	engine.Use(gin.Recovery())
 	engine.Use(middleware.Logger(logger))
+	engine.Use(middleware.ClientIP())
 
 	// Create JWT token service
-	tokenService := jwt.NewTokenService(&cfg.Auth)
+	tokenService, err := jwt.NewTokenService(&cfg.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token service: %w", err)
+	}
+
+	// Create the verifier for tokens from an external OIDC provider, if configured
+	var oidcVerifier *jwt.OIDCVerifier
+	if cfg.Auth.OIDC.Enabled {
+		oidcVerifier, err = jwt.NewOIDCVerifier(&cfg.Auth.OIDC, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OIDC verifier: %w", err)
+		}
+		logger.Info("Accepting tokens from external OIDC provider", map[string]interface{}{
+			"issuer": cfg.Auth.OIDC.IssuerURL,
+		})
+	}
+
+	// Create token revocation store
+	revocations := newRevocationStore(database, logger, &cfg.Auth)
+
+	// Create the registry of routes served without authentication, shared by
+	// the auth and RBAC middlewares
+	publicRoutes, err := middleware.NewPublicRoutes(cfg.Auth.PublicRoutesOrDefault())
+	if err != nil {
+		return nil, fmt.Errorf("invalid public routes: %w", err)
+	}
+
+	// Create the API key usecase, which authenticates services and manages their keys
+	apiKeys := usecase.NewAPIKeyUsecase(repository.NewAPIKeyRepository(database, logger), &cfg.Auth, logger)
+
+	// Create the audit log of security-relevant events
+	auditLog := audit.NewLog(logger)
 
 	// Create auth middleware
-	authMiddleware := middleware.NewAuthMiddleware(tokenService, logger, &cfg.Auth)
+	authMiddleware := middleware.NewAuthMiddleware(tokenService, oidcVerifier, revocations, apiKeys, publicRoutes, logger, &cfg.Auth)
 
-	// Create RBAC middleware
-	rbacMiddleware, err := middleware.NewRBACMiddleware(logger, &cfg.Auth)
+	// Create RBAC middleware. Without it protected routes would be served to
+	// any authenticated caller, so startup fails unless explicitly allowed.
+	var rbacMiddleware *middleware.RBACMiddleware
+	enforcer, err := rbac.NewEnforcer(&cfg.RBAC, database, logger)
 	if err != nil {
-		logger.Error("Failed to create RBAC middleware", map[string]interface{}{"error": err.Error()})
-		// Continue without RBAC if it fails to initialize
+		if !cfg.RBAC.InsecureSkipAuthorization {
+			return nil, fmt.Errorf("failed to create RBAC enforcer: %w", err)
+		}
+		if cfg.App.Environment == "prod" {
+			return nil, fmt.Errorf("failed to create RBAC enforcer: %w; rbac.insecure_skip_authorization is not allowed in prod", err)
+		}
+		logger.Error("Failed to create RBAC enforcer, serving /api/v1 WITHOUT AUTHORIZATION because rbac.insecure_skip_authorization is set", map[string]interface{}{"error": err.Error()})
+	} else {
+		rbacMiddleware = middleware.NewRBACMiddleware(enforcer, publicRoutes, logger, &cfg.Auth, cfg.RBAC.Resources)
+
+		// Pick up model and policy file changes without a restart
+		if cfg.RBAC.Watch {
+			if err := enforcer.Watch(); err != nil {
+				logger.Error("Failed to watch RBAC model and policy files", map[string]interface{}{"error": err.Error()})
+			} else {
+				logger.Info("Watching RBAC model and policy files for changes", nil)
+			}
+		}
+
+		// Pick up rules changed through other replicas sharing the policy store
+		if cfg.RBAC.PolicyStore == config.PolicyStorePostgres && cfg.RBAC.ReloadIntervalSeconds > 0 {
+			interval := time.Duration(cfg.RBAC.ReloadIntervalSeconds) * time.Second
+			if err := enforcer.Poll(interval); err != nil {
+				logger.Error("Failed to poll the RBAC policy store", map[string]interface{}{"error": err.Error()})
+			} else {
+				logger.Info("Polling the RBAC policy store for changes", map[string]interface{}{"interval": interval.String()})
+			}
+		}
 	}
 
 	// Create router
@@ -55,6 +140,10 @@ func NewRouter(logger *logger.Logger, database *db.Database, cfg *config.Config)
 		logger:         logger,
 		db:             database,
 		tokenService:   tokenService,
+		revocations:    revocations,
+		apiKeys:        apiKeys,
+		auditLog:       auditLog,
+		enforcer:       enforcer,
 		config:         cfg,
 		authMiddleware: authMiddleware,
 		rbacMiddleware: rbacMiddleware,
@@ -63,10 +152,13 @@ func NewRouter(logger *logger.Logger, database *db.Database, cfg *config.Config)
 	// Apply auth middleware globally for JWT parsing
 	engine.Use(authMiddleware.Authenticate())
 
+	// Audit every request made while a superadmin impersonates a user
+	engine.Use(middleware.AuditImpersonation(auditLog))
+
 	// Register routes
 	router.registerRoutes()
 
-	return router
+	return router, nil
 }
 
 // Handler returns the HTTP handler
@@ -74,6 +166,14 @@ func (r *Router) Handler() http.Handler {
 	return r.engine
 }
 
+// Close stops background work started by the router
+func (r *Router) Close() error {
+	if r.enforcer != nil {
+		return r.enforcer.Close()
+	}
+	return nil
+}
+
 // registerRoutes registers all routes
 func (r *Router) registerRoutes() {
 	// Root path
@@ -98,9 +198,63 @@ func (r *Router) registerRoutes() {
 		c.JSON(http.StatusOK, gin.H{"status": "ready"})
 	})
 
+	// Public keys for verifying access tokens
+	jwksHandler := handler.NewJWKSHandler(r.tokenService)
+	r.engine.GET("/.well-known/jwks.json", jwksHandler.GetJWKS)
+
 	// Auth routes
-	authHandler := handler.NewAuthHandler(r.tokenService, r.logger, &r.config.Auth)
+	userRepo := repository.NewUserRepository(r.db, r.logger)
+	refreshTokenRepo := repository.NewRefreshTokenRepository(r.db, r.logger)
+	hasher := password.NewBcryptHasher(r.config.Auth.PasswordHashCost)
+	var challengeRepo domainrepo.LoginChallengeRepository
+	var loginMailer usecase.Mailer
+	if r.config.Auth.MagicLink.Enabled {
+		challengeRepo = repository.NewLoginChallengeRepository(r.db, r.logger)
+		loginMailer = newMailer(&r.config.Mailer, r.logger)
+	}
+
+	// MFA requirements for Casbin roles are resolved through the enforcer,
+	// which may have failed to initialize
+	var routeAuthorizer *rbac.RouteAuthorizer
+	var roles usecase.RoleResolver
+	if r.enforcer != nil {
+		routeAuthorizer = rbac.NewRouteAuthorizer(r.enforcer, r.config.RBAC.Resources, r.logger)
+		roles = routeAuthorizer
+	}
+	mfaUsecase := usecase.NewMFAUsecase(userRepo, repository.NewMFARepository(r.db, r.logger), totp.NewAuthenticator(r.config.Auth.MFA.Issuer), roles, &r.config.Auth, r.logger)
+
+	var throttle usecase.LoginThrottle
+	if r.config.Auth.Lockout.Enabled {
+		throttle = usecase.NewLoginThrottle(newLoginAttemptStore(r.db, r.logger, &r.config.Auth.Lockout), r.auditLog, &r.config.Auth.Lockout, r.logger)
+	} else {
+		r.logger.Warn("Brute-force protection of logins is disabled", nil)
+	}
+
+	authUsecase := usecase.NewAuthUsecase(userRepo, refreshTokenRepo, r.revocations, challengeRepo, loginMailer, mfaUsecase, throttle, hasher, r.tokenService, &r.config.Auth, r.logger)
+	authHandler := handler.NewAuthHandler(authUsecase, r.logger, &r.config.Auth)
 	r.engine.POST("/auth", authHandler.Authenticate)
+	r.engine.POST("/auth/login", authHandler.Authenticate)
+	r.engine.POST("/auth/register", authHandler.Register)
+	r.engine.POST("/auth/refresh", authHandler.Refresh)
+	r.engine.POST("/auth/logout", r.authMiddleware.RequireAuthentication(), authHandler.Logout)
+	r.engine.POST("/auth/scoped-token", r.authMiddleware.RequireAuthentication(), authHandler.ScopedToken)
+	if r.config.Auth.MagicLink.Enabled {
+		r.engine.POST("/auth/magic-link", authHandler.MagicLink)
+		r.engine.POST("/auth/verify", authHandler.Verify)
+	}
+	r.engine.POST("/auth/login/mfa", authHandler.MFAVerify)
+	r.engine.POST("/auth/login/mfa/enroll", authHandler.MFAEnroll)
+
+	// MFA self-service for the authenticated user
+	mfaHandler := handler.NewMFAHandler(mfaUsecase, r.logger)
+	mfaRoutes := r.engine.Group("/auth/mfa", r.authMiddleware.RequireAuthentication())
+	{
+		mfaRoutes.GET("", mfaHandler.Status)
+		mfaRoutes.POST("/totp", mfaHandler.Enroll)
+		mfaRoutes.POST("/totp/activate", mfaHandler.Activate)
+		mfaRoutes.DELETE("/totp", mfaHandler.Disable)
+		mfaRoutes.POST("/recovery-codes", mfaHandler.RegenerateRecoveryCodes)
+	}
 
 	// API v1 routes - protected by auth middleware and RBAC
 	apiV1 := r.engine.Group("/api/v1")
@@ -111,8 +265,114 @@ func (r *Router) registerRoutes() {
 		apiV1.Use(r.rbacMiddleware.Authorize())
 		r.logger.Info("RBAC middleware applied to /api/v1 routes", nil)
 	} else {
-		r.logger.Warn("RBAC middleware not available, skipping RBAC enforcement", nil)
+		r.logger.Warn("RBAC middleware not available, skipping RBAC enforcement (insecure)", nil)
+	}
+
+	// RBAC administration and resource checks need the enforcer, which may have failed to initialize
+	var rbacUsecase usecase.RBACUsecase
+	var authorizer usecase.ResourceAuthorizer
+	if r.enforcer != nil {
+		rbacUsecase = usecase.NewRBACUsecase(rbac.NewPolicyRepository(r.enforcer, r.logger), r.enforcer, rbac.NewPolicyExplainer(r.enforcer, r.config.RBAC.Resources), r.logger)
+		authorizer = rbac.NewResourceAuthorizer(r.enforcer, r.logger)
 	}
 
-	v1.RegisterRoutes(apiV1, r.db, r.logger)
+	// Superadmin routes require MFA when it is mandatory for the superadmin
+	requireSuperAdmin := []gin.HandlerFunc{r.authMiddleware.RequireSuperAdmin()}
+	if r.config.Auth.MFA.RequiredForSuperAdmin {
+		requireSuperAdmin = append([]gin.HandlerFunc{r.authMiddleware.RequireMFA()}, requireSuperAdmin...)
+	}
+
+	// Superadmins may act as other users to reproduce what they see
+	var impersonationUsecase usecase.ImpersonationUsecase
+	if r.config.Auth.Impersonation.Enabled {
+		impersonationUsecase = usecase.NewImpersonationUsecase(userRepo, r.tokenService, r.auditLog, &r.config.Auth, r.logger)
+	}
+	v1.RegisterRoutes(apiV1, r.db, r.logger, authUsecase, r.apiKeys, rbacUsecase, impersonationUsecase, authorizer, requireSuperAdmin...)
+
+	// Permission introspection is open to every authenticated caller, so it is
+	// registered outside the RBAC-protected group
+	if routeAuthorizer != nil {
+		permissionUsecase := usecase.NewPermissionUsecase(routeAuthorizer, apiRoutes{engine: r.engine, prefix: "/api/v1/", exclude: "/api/v1/me"}, r.logger)
+		meRoutes := r.engine.Group("/api/v1/me", r.authMiddleware.RequireAuthentication())
+		v1.RegisterMeRoutes(meRoutes, r.logger, permissionUsecase)
+	}
+}
+
+// apiRoutes lists the routes of the engine under prefix, except those under
+// exclude, sorted by route template and method
+type apiRoutes struct {
+	engine  *gin.Engine
+	prefix  string
+	exclude string
+}
+
+// Routes returns the routes the API serves
+func (a apiRoutes) Routes() []model.Route {
+	var routes []model.Route
+	for _, info := range a.engine.Routes() {
+		if !strings.HasPrefix(info.Path, a.prefix) || info.Path == a.exclude || strings.HasPrefix(info.Path, a.exclude+"/") {
+			continue
+		}
+		routes = append(routes, model.Route{Method: info.Method, Route: info.Path})
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Route != routes[j].Route {
+			return routes[i].Route < routes[j].Route
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes
+}
+
+// newRevocationStore creates the token revocation store selected by the auth configuration
+func newRevocationStore(database *db.Database, logger *logger.Logger, cfg *config.AuthConfig) domainrepo.RevocationStore {
+	switch cfg.RevocationStore {
+	case config.RevocationStoreMemory:
+		logger.Warn("Using in-memory token revocation store; revocations are lost on restart", nil)
+		return revocation.NewMemoryStore()
+	case config.RevocationStorePostgres, "":
+		return revocation.NewPostgresStore(database, logger)
+	default:
+		logger.Error("Unknown token revocation store, falling back to postgres", map[string]interface{}{
+			"store": cfg.RevocationStore,
+		})
+		return revocation.NewPostgresStore(database, logger)
+	}
+}
+
+// newLoginAttemptStore creates the login attempt store selected by the lockout configuration
+func newLoginAttemptStore(database *db.Database, logger *logger.Logger, cfg *config.LockoutConfig) domainrepo.LoginAttemptStore {
+	switch cfg.Store {
+	case config.LockoutStoreMemory:
+		logger.Warn("Using in-memory login attempt store; failures are lost on restart and counted per replica", nil)
+		return lockout.NewMemoryStore()
+	case config.LockoutStorePostgres, "":
+		return lockout.NewPostgresStore(database, logger)
+	default:
+		logger.Error("Unknown login attempt store, falling back to postgres", map[string]interface{}{
+			"store": cfg.Store,
+		})
+		return lockout.NewPostgresStore(database, logger)
+	}
+}
+
+// newMailer creates the mailer selected by the mailer configuration
+func newMailer(cfg *config.MailerConfig, logger *logger.Logger) usecase.Mailer {
+	switch cfg.Driver {
+	case config.MailerDriverSMTP:
+		return mailer.NewSMTPMailer(&cfg.SMTP, cfg.From)
+	case config.MailerDriverMemory:
+		logger.Warn("Using in-memory mailer; emails are not delivered", nil)
+		return mailer.NewMemoryMailer()
+	case config.MailerDriverFile, "":
+		logger.Warn("Using file mailer; emails are written to disk instead of being delivered", map[string]interface{}{
+			"dir": cfg.Dir,
+		})
+		return mailer.NewFileMailer(cfg.Dir, cfg.From)
+	default:
+		logger.Error("Unknown mailer driver, falling back to file", map[string]interface{}{
+			"driver": cfg.Driver,
+		})
+		return mailer.NewFileMailer(cfg.Dir, cfg.From)
+	}
 }
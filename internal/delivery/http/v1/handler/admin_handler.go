@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+// AdminHandler handles superadmin-only HTTP requests
+type AdminHandler struct {
+	authUsecase usecase.AuthUsecase
+	logger      *logger.Logger
+}
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler(authUsecase usecase.AuthUsecase, logger *logger.Logger) *AdminHandler {
+	return &AdminHandler{
+		authUsecase: authUsecase,
+		logger:      logger,
+	}
+}
+
+// RevokeUserTokens godoc
+// @Summary Revoke all tokens of a user
+// @Description Revoke every access and refresh token issued to the user so far. Requires superadmin privileges.
+// @Tags admin
+// @Param email path string true "User email"
+// @Success 204
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/admin/users/{email}/revoke-tokens [post]
+func (h *AdminHandler) RevokeUserTokens(c *gin.Context) {
+	if err := h.authUsecase.RevokeUserTokens(c.Request.Context(), c.Param("email")); err != nil {
+		handleError(c, h.logger, err, "User", "Failed to revoke tokens")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,204 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHandler handles superadmin requests managing the API keys of services
+type APIKeyHandler struct {
+	apiKeyUsecase usecase.APIKeyUsecase
+	logger        *logger.Logger
+}
+
+// APIKeyCreateRequest represents the request body for creating an API key.
+// Tenant defaults to the default tenant; keys without expires_at do not expire.
+type APIKeyCreateRequest struct {
+	Name      string     `json:"name" binding:"required"`
+	Service   string     `json:"service" binding:"required"`
+	Tenant    string     `json:"tenant"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// APIKeyUpdateRequest represents the request body for updating an API key.
+// Omitted fields are left unchanged.
+type APIKeyUpdateRequest struct {
+	Name      *string    `json:"name"`
+	Scopes    *[]string  `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// APIKeyCreateResponse represents a new API key with its secret value, which
+// is only ever returned here
+type APIKeyCreateResponse struct {
+	model.APIKey
+	Key string `json:"key"`
+}
+
+// APIKeyListResponse represents the list of API keys
+type APIKeyListResponse struct {
+	Data []model.APIKey `json:"data"`
+}
+
+// NewAPIKeyHandler creates a new API key handler
+func NewAPIKeyHandler(apiKeyUsecase usecase.APIKeyUsecase, logger *logger.Logger) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyUsecase: apiKeyUsecase,
+		logger:        logger,
+	}
+}
+
+// List godoc
+// @Summary List API keys
+// @Description List the API keys of every service, identified by their prefix. Requires superadmin privileges.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} APIKeyListResponse
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/admin/api-keys [get]
+func (h *APIKeyHandler) List(c *gin.Context) {
+	keys, err := h.apiKeyUsecase.List(c.Request.Context())
+	if err != nil {
+		handleError(c, h.logger, err, "API key", "Failed to list API keys")
+		return
+	}
+
+	c.JSON(http.StatusOK, APIKeyListResponse{Data: keys})
+}
+
+// Create godoc
+// @Summary Create an API key
+// @Description Create an API key for a service. The service is authorized as the Casbin subject "service:<service>",
+// @Description restricted to the roles named by scopes when any are given. The key is only returned in this response.
+// @Description Requires superadmin privileges.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param key body APIKeyCreateRequest true "API key"
+// @Success 201 {object} APIKeyCreateResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/admin/api-keys [post]
+func (h *APIKeyHandler) Create(c *gin.Context) {
+	var req APIKeyCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Name and service are required"})
+		return
+	}
+
+	created, err := h.apiKeyUsecase.Create(c.Request.Context(), usecase.APIKeyInput(req))
+	if err != nil {
+		handleError(c, h.logger, err, "API key", "Failed to create API key")
+		return
+	}
+
+	c.JSON(http.StatusCreated, APIKeyCreateResponse{APIKey: *created.APIKey, Key: created.Key})
+}
+
+// Get godoc
+// @Summary Get an API key
+// @Description Get an API key by its ID. Requires superadmin privileges.
+// @Tags admin
+// @Produce json
+// @Param id path int true "API key ID"
+// @Success 200 {object} model.APIKey
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/admin/api-keys/{id} [get]
+func (h *APIKeyHandler) Get(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	key, err := h.apiKeyUsecase.Get(c.Request.Context(), id)
+	if err != nil {
+		handleError(c, h.logger, err, "API key", "Failed to get API key")
+		return
+	}
+
+	c.JSON(http.StatusOK, key)
+}
+
+// Update godoc
+// @Summary Update an API key
+// @Description Change the name, scopes or expiry of an API key. Requires superadmin privileges.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "API key ID"
+// @Param key body APIKeyUpdateRequest true "Fields to change"
+// @Success 200 {object} model.APIKey
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/admin/api-keys/{id} [patch]
+func (h *APIKeyHandler) Update(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	var req APIKeyUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	key, err := h.apiKeyUsecase.Update(c.Request.Context(), id, usecase.APIKeyPatch(req))
+	if err != nil {
+		handleError(c, h.logger, err, "API key", "Failed to update API key")
+		return
+	}
+
+	c.JSON(http.StatusOK, key)
+}
+
+// Delete godoc
+// @Summary Delete an API key
+// @Description Delete an API key; it stops authenticating immediately. Requires superadmin privileges.
+// @Tags admin
+// @Param id path int true "API key ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/admin/api-keys/{id} [delete]
+func (h *APIKeyHandler) Delete(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.apiKeyUsecase.Delete(c.Request.Context(), id); err != nil {
+		handleError(c, h.logger, err, "API key", "Failed to delete API key")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// parseID parses the API key ID path parameter, responding with 400 if it is invalid
+func (h *APIKeyHandler) parseID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 0)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid API key ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
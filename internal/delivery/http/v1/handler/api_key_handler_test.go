@@ -0,0 +1,156 @@
+package handler_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/delivery/http/v1/handler"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/usecase"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockAPIKeyUsecase is a mock implementation of the APIKeyUsecase interface
+type MockAPIKeyUsecase struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyUsecase) Create(ctx context.Context, input usecase.APIKeyInput) (*usecase.CreatedAPIKey, error) {
+	args := m.Called(ctx, input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*usecase.CreatedAPIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyUsecase) List(ctx context.Context) ([]model.APIKey, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyUsecase) Get(ctx context.Context, id uint) (*model.APIKey, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyUsecase) Update(ctx context.Context, id uint, patch usecase.APIKeyPatch) (*model.APIKey, error) {
+	args := m.Called(ctx, id, patch)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyUsecase) Delete(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyUsecase) Authenticate(ctx context.Context, key string) (*model.APIKey, error) {
+	args := m.Called(ctx, key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.APIKey), args.Error(1)
+}
+
+func TestAPIKeyHandler_Create(t *testing.T) {
+	mockUsecase := new(MockAPIKeyUsecase)
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+	apiKeyHandler := handler.NewAPIKeyHandler(mockUsecase, log)
+	router := setupRouter()
+	router.POST("/api/v1/admin/api-keys", apiKeyHandler.Create)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPost, "/api/v1/admin/api-keys", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Success returns the key once", func(t *testing.T) {
+		input := usecase.APIKeyInput{Name: "Nightly export", Service: "batch", Scopes: []string{"user"}}
+		created := &usecase.CreatedAPIKey{
+			APIKey: &model.APIKey{ID: 1, Name: "Nightly export", Service: "batch", Tenant: "default", Prefix: "gmk_0123456789ab", Scopes: []string{"user"}},
+			Key:    "gmk_0123456789ab_secret",
+		}
+		mockUsecase.On("Create", mock.Anything, input).Return(created, nil).Once()
+
+		w := post(`{"name": "Nightly export", "service": "batch", "scopes": ["user"]}`)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var response map[string]interface{}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "gmk_0123456789ab_secret", response["key"])
+		assert.Equal(t, "gmk_0123456789ab", response["prefix"])
+		assert.Equal(t, "batch", response["service"])
+		assert.NotContains(t, response, "key_hash")
+	})
+
+	t.Run("Missing service", func(t *testing.T) {
+		w := post(`{"name": "Nightly export"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Validation error", func(t *testing.T) {
+		mockUsecase.On("Create", mock.Anything, mock.Anything).Return(nil, model.NewValidationError("service", "must be lowercase")).Once()
+
+		w := post(`{"name": "Nightly export", "service": "batch jobs"}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Contains(t, w.Body.String(), `"field":"service"`)
+	})
+}
+
+func TestAPIKeyHandler_UpdateAndDelete(t *testing.T) {
+	mockUsecase := new(MockAPIKeyUsecase)
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+	apiKeyHandler := handler.NewAPIKeyHandler(mockUsecase, log)
+	router := setupRouter()
+	router.PATCH("/api/v1/admin/api-keys/:id", apiKeyHandler.Update)
+	router.DELETE("/api/v1/admin/api-keys/:id", apiKeyHandler.Delete)
+
+	t.Run("Update applies provided fields", func(t *testing.T) {
+		scopes := []string{"admin"}
+		mockUsecase.On("Update", mock.Anything, uint(1), usecase.APIKeyPatch{Scopes: &scopes}).
+			Return(&model.APIKey{ID: 1, Scopes: scopes}, nil).Once()
+
+		req, _ := http.NewRequest(http.MethodPatch, "/api/v1/admin/api-keys/1", bytes.NewBufferString(`{"scopes": ["admin"]}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockUsecase.AssertExpectations(t)
+	})
+
+	t.Run("Delete unknown key", func(t *testing.T) {
+		mockUsecase.On("Delete", mock.Anything, uint(2)).Return(model.ErrNotFound).Once()
+
+		req, _ := http.NewRequest(http.MethodDelete, "/api/v1/admin/api-keys/2", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Invalid ID", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodDelete, "/api/v1/admin/api-keys/abc", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// handleError maps usecase errors to HTTP responses. resource names what the
+// handler serves in not found and conflict responses, such as "Todo"; other
+// errors are logged and answered with message.
+func handleError(c *gin.Context, logger *logger.Logger, err error, resource, message string) {
+	var validationErr *model.ValidationError
+	switch {
+	case errors.Is(err, model.ErrUnauthenticated):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+	case errors.Is(err, model.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+	case errors.Is(err, model.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": resource + " not found"})
+	case errors.Is(err, model.ErrConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": resource + " already exists"})
+	case errors.As(err, &validationErr):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":  "Validation failed",
+			"field":  validationErr.Field,
+			"reason": validationErr.Message,
+		})
+	default:
+		logger.Error(message, map[string]interface{}{
+			"error": err.Error(),
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+// ImpersonationHandler handles superadmin requests to act as other users
+type ImpersonationHandler struct {
+	impersonationUsecase usecase.ImpersonationUsecase
+	logger               *logger.Logger
+}
+
+// ImpersonationRequest represents the request body for impersonating a user.
+// Reason is recorded in the audit log.
+type ImpersonationRequest struct {
+	Email  string `json:"email" binding:"required"`
+	Reason string `json:"reason" binding:"required"`
+}
+
+// ImpersonationResponse represents an access token acting as another user.
+// It cannot be refreshed.
+type ImpersonationResponse struct {
+	Token     string `json:"token"`
+	TokenType string `json:"token_type"`
+	ExpiresIn int64  `json:"expires_in"`
+	Subject   string `json:"subject"`
+	Actor     string `json:"actor"`
+}
+
+// NewImpersonationHandler creates a new impersonation handler
+func NewImpersonationHandler(impersonationUsecase usecase.ImpersonationUsecase, logger *logger.Logger) *ImpersonationHandler {
+	return &ImpersonationHandler{
+		impersonationUsecase: impersonationUsecase,
+		logger:               logger,
+	}
+}
+
+// Impersonate godoc
+// @Summary Impersonate a user
+// @Description Issue a short-lived access token acting as the user, whose act claim names the calling superadmin.
+// @Description Requests made with it are authorized as the user, never as a superadmin, and are written to the audit log.
+// @Description The token cannot be refreshed; log out with it to end the session early. Requires superadmin privileges.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body ImpersonationRequest true "User to impersonate and why"
+// @Success 200 {object} ImpersonationResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/admin/impersonate [post]
+func (h *ImpersonationHandler) Impersonate(c *gin.Context) {
+	var req ImpersonationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Email and reason are required"})
+		return
+	}
+
+	token, err := h.impersonationUsecase.Impersonate(c.Request.Context(), req.Email, req.Reason)
+	if err != nil {
+		handleError(c, h.logger, err, "User", "Failed to impersonate user")
+		return
+	}
+
+	c.JSON(http.StatusOK, ImpersonationResponse{
+		Token:     token.AccessToken,
+		TokenType: "Bearer",
+		ExpiresIn: int64(token.ExpiresIn.Seconds()),
+		Subject:   token.Subject,
+		Actor:     token.Actor,
+	})
+}
@@ -0,0 +1,80 @@
+package handler_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/delivery/http/v1/handler"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/usecase"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockImpersonationUsecase is a mock implementation of the ImpersonationUsecase interface
+type MockImpersonationUsecase struct {
+	mock.Mock
+}
+
+func (m *MockImpersonationUsecase) Impersonate(ctx context.Context, email, reason string) (*usecase.ImpersonationToken, error) {
+	args := m.Called(ctx, email, reason)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*usecase.ImpersonationToken), args.Error(1)
+}
+
+func TestImpersonationHandler_Impersonate(t *testing.T) {
+	mockUsecase := new(MockImpersonationUsecase)
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+	impersonationHandler := handler.NewImpersonationHandler(mockUsecase, log)
+	router := setupRouter()
+	router.POST("/api/v1/admin/impersonate", impersonationHandler.Impersonate)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPost, "/api/v1/admin/impersonate", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		token := &usecase.ImpersonationToken{
+			AccessToken: "access-token",
+			ExpiresIn:   15 * time.Minute,
+			Subject:     "user@example.com",
+			Actor:       "admin@example.com",
+		}
+		mockUsecase.On("Impersonate", mock.Anything, "user@example.com", "ticket 42").Return(token, nil).Once()
+
+		w := post(`{"email": "user@example.com", "reason": "ticket 42"}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"token":"access-token","token_type":"Bearer","expires_in":900,"subject":"user@example.com","actor":"admin@example.com"}`, w.Body.String())
+	})
+
+	t.Run("Missing reason", func(t *testing.T) {
+		w := post(`{"email": "user@example.com"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Forbidden target", func(t *testing.T) {
+		mockUsecase.On("Impersonate", mock.Anything, "admin@example.com", "ticket 42").Return(nil, model.ErrForbidden).Once()
+
+		w := post(`{"email": "admin@example.com", "reason": "ticket 42"}`)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Unknown user", func(t *testing.T) {
+		mockUsecase.On("Impersonate", mock.Anything, "missing@example.com", "ticket 42").Return(nil, model.ErrNotFound).Once()
+
+		w := post(`{"email": "missing@example.com", "reason": "ticket 42"}`)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+// MeHandler handles requests introspecting the caller's permissions
+type MeHandler struct {
+	permissionUsecase usecase.PermissionUsecase
+	logger            *logger.Logger
+}
+
+// RouteRequest represents an API route in a request body
+type RouteRequest struct {
+	Method string `json:"method" binding:"required"`
+	Route  string `json:"route" binding:"required"`
+}
+
+// CanRequest represents a batch of routes to check
+type CanRequest struct {
+	Checks []RouteRequest `json:"checks" binding:"required,dive"`
+}
+
+// CanResponse represents the outcome of each check, in request order
+type CanResponse struct {
+	Data []model.RouteCheck `json:"data"`
+}
+
+// NewMeHandler creates a new me handler
+func NewMeHandler(permissionUsecase usecase.PermissionUsecase, logger *logger.Logger) *MeHandler {
+	return &MeHandler{
+		permissionUsecase: permissionUsecase,
+		logger:            logger,
+	}
+}
+
+// Me godoc
+// @Summary Describe the caller
+// @Description Return the caller's email, tenant, superadmin flag, the roles it holds in its tenant, directly or
+// @Description through other roles, and every method and route template of the API it may call.
+// @Tags me
+// @Produce json
+// @Success 200 {object} model.CallerPermissions
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/me [get]
+func (h *MeHandler) Me(c *gin.Context) {
+	permissions, err := h.permissionUsecase.Me(c.Request.Context())
+	if err != nil {
+		handleError(c, h.logger, err, "Route", "Failed to list permissions")
+		return
+	}
+
+	c.JSON(http.StatusOK, permissions)
+}
+
+// Can godoc
+// @Summary Check permissions
+// @Description Check whether the caller may call each route template with its method, without calling it.
+// @Description Routes the API does not serve are reported as not allowed. At most 100 routes are checked per request.
+// @Tags me
+// @Accept json
+// @Produce json
+// @Param checks body CanRequest true "Routes to check"
+// @Success 200 {object} CanResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/me/can [post]
+func (h *MeHandler) Can(c *gin.Context) {
+	var req CanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Checks with a method and route are required"})
+		return
+	}
+
+	routes := make([]model.Route, 0, len(req.Checks))
+	for _, check := range req.Checks {
+		routes = append(routes, model.Route(check))
+	}
+
+	checks, err := h.permissionUsecase.Can(c.Request.Context(), routes)
+	if err != nil {
+		handleError(c, h.logger, err, "Route", "Failed to check permissions")
+		return
+	}
+
+	c.JSON(http.StatusOK, CanResponse{Data: checks})
+}
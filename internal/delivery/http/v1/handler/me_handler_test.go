@@ -0,0 +1,128 @@
+package handler_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/delivery/http/v1/handler"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockPermissionUsecase is a mock implementation of the PermissionUsecase interface
+type MockPermissionUsecase struct {
+	mock.Mock
+}
+
+func (m *MockPermissionUsecase) Me(ctx context.Context) (*model.CallerPermissions, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.CallerPermissions), args.Error(1)
+}
+
+func (m *MockPermissionUsecase) Can(ctx context.Context, routes []model.Route) ([]model.RouteCheck, error) {
+	args := m.Called(ctx, routes)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.RouteCheck), args.Error(1)
+}
+
+func TestMeHandler_Me(t *testing.T) {
+	mockUsecase := new(MockPermissionUsecase)
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+	meHandler := handler.NewMeHandler(mockUsecase, log)
+	router := setupRouter()
+	router.GET("/api/v1/me", meHandler.Me)
+
+	t.Run("Success", func(t *testing.T) {
+		me := &model.CallerPermissions{
+			Email:       "bob@example.com",
+			Tenant:      "default",
+			Roles:       []string{"user"},
+			Permissions: []model.Route{{Method: "GET", Route: "/api/v1/todos"}},
+		}
+		mockUsecase.On("Me", mock.Anything).Return(me, nil).Once()
+
+		req, _ := http.NewRequest(http.MethodGet, "/api/v1/me", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{
+			"email": "bob@example.com",
+			"tenant": "default",
+			"is_superadmin": false,
+			"roles": ["user"],
+			"permissions": [{"method": "GET", "route": "/api/v1/todos"}]
+		}`, w.Body.String())
+	})
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		mockUsecase.On("Me", mock.Anything).Return(nil, model.ErrUnauthenticated).Once()
+
+		req, _ := http.NewRequest(http.MethodGet, "/api/v1/me", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+func TestMeHandler_Can(t *testing.T) {
+	mockUsecase := new(MockPermissionUsecase)
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+	meHandler := handler.NewMeHandler(mockUsecase, log)
+	router := setupRouter()
+	router.POST("/api/v1/me/can", meHandler.Can)
+
+	send := func(body interface{}) *httptest.ResponseRecorder {
+		reqBody, _ := json.Marshal(body)
+		req, _ := http.NewRequest(http.MethodPost, "/api/v1/me/can", bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	routes := []model.Route{
+		{Method: "GET", Route: "/api/v1/todos"},
+		{Method: "DELETE", Route: "/api/v1/todos/:id"},
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		mockUsecase.On("Can", mock.Anything, routes).Return([]model.RouteCheck{
+			{Route: routes[0], Allowed: true},
+			{Route: routes[1], Allowed: false},
+		}, nil).Once()
+
+		w := send(handler.CanRequest{Checks: []handler.RouteRequest{handler.RouteRequest(routes[0]), handler.RouteRequest(routes[1])}})
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"data": [
+			{"method": "GET", "route": "/api/v1/todos", "allowed": true},
+			{"method": "DELETE", "route": "/api/v1/todos/:id", "allowed": false}
+		]}`, w.Body.String())
+	})
+
+	t.Run("Missing fields", func(t *testing.T) {
+		w := send(map[string]interface{}{"checks": []map[string]string{{"method": "GET"}}})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Invalid check", func(t *testing.T) {
+		invalid := []model.Route{{Method: "FETCH", Route: "/api/v1/todos"}}
+		mockUsecase.On("Can", mock.Anything, invalid).Return(nil, model.NewValidationError("method", "must be an HTTP method")).Once()
+
+		w := send(handler.CanRequest{Checks: []handler.RouteRequest{handler.RouteRequest(invalid[0])}})
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	})
+}
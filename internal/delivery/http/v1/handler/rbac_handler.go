@@ -0,0 +1,322 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+// RBACHandler handles superadmin requests administering RBAC rules
+type RBACHandler struct {
+	rbacUsecase usecase.RBACUsecase
+	logger      *logger.Logger
+}
+
+// PolicyRequest represents a permission rule in a request body.
+// Tenant defaults to every tenant.
+type PolicyRequest struct {
+	Subject string `json:"subject" binding:"required"`
+	Tenant  string `json:"tenant"`
+	Object  string `json:"object" binding:"required"`
+	Action  string `json:"action" binding:"required"`
+}
+
+// RoleAssignmentRequest represents a role assignment in a request body
+type RoleAssignmentRequest struct {
+	Subject string `json:"subject" binding:"required"`
+	Role    string `json:"role" binding:"required"`
+	Tenant  string `json:"tenant" binding:"required"`
+}
+
+// PolicyQueryRequest represents a request to check against the permission rules
+type PolicyQueryRequest struct {
+	Subject string `json:"subject" binding:"required"`
+	Tenant  string `json:"tenant" binding:"required"`
+	Object  string `json:"object" binding:"required"`
+	Action  string `json:"action" binding:"required"`
+}
+
+// PolicyCaseRequest represents a policy query and the decision it is expected to get
+type PolicyCaseRequest struct {
+	Subject string `json:"subject" binding:"required"`
+	Tenant  string `json:"tenant" binding:"required"`
+	Object  string `json:"object" binding:"required"`
+	Action  string `json:"action" binding:"required"`
+	Allow   *bool  `json:"allow" binding:"required"`
+}
+
+// DryRunRequest represents a proposed policy, in the format of policy.csv,
+// and the cases to evaluate with it
+type DryRunRequest struct {
+	Policy string              `json:"policy" binding:"required"`
+	Cases  []PolicyCaseRequest `json:"cases" binding:"required,dive"`
+}
+
+// PolicyListResponse represents the list of permission rules
+type PolicyListResponse struct {
+	Data []model.Policy `json:"data"`
+}
+
+// RoleAssignmentListResponse represents the list of role assignments
+type RoleAssignmentListResponse struct {
+	Data []model.RoleAssignment `json:"data"`
+}
+
+// NewRBACHandler creates a new RBAC handler
+func NewRBACHandler(rbacUsecase usecase.RBACUsecase, logger *logger.Logger) *RBACHandler {
+	return &RBACHandler{
+		rbacUsecase: rbacUsecase,
+		logger:      logger,
+	}
+}
+
+// ListPolicies godoc
+// @Summary List permission rules
+// @Description List every Casbin permission (p) rule. Requires superadmin privileges.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} PolicyListResponse
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/admin/rbac/policies [get]
+func (h *RBACHandler) ListPolicies(c *gin.Context) {
+	policies, err := h.rbacUsecase.ListPolicies(c.Request.Context())
+	if err != nil {
+		handleError(c, h.logger, err, "Rule", "Failed to list policies")
+		return
+	}
+
+	c.JSON(http.StatusOK, PolicyListResponse{Data: policies})
+}
+
+// AddPolicy godoc
+// @Summary Add a permission rule
+// @Description Allow a subject (role or user email) to perform an action (HTTP method) on an object (route template)
+// @Description in a tenant, or in every tenant when tenant is omitted or "*".
+// @Description The rule is validated against the RBAC model, saved and enforced immediately. Requires superadmin privileges.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param policy body PolicyRequest true "Permission rule"
+// @Success 201 {object} model.Policy
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/admin/rbac/policies [post]
+func (h *RBACHandler) AddPolicy(c *gin.Context) {
+	var req PolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Subject, object and action are required"})
+		return
+	}
+
+	policy, err := h.rbacUsecase.AddPolicy(c.Request.Context(), model.Policy(req))
+	if err != nil {
+		handleError(c, h.logger, err, "Rule", "Failed to add policy")
+		return
+	}
+
+	c.JSON(http.StatusCreated, policy)
+}
+
+// RemovePolicy godoc
+// @Summary Remove a permission rule
+// @Description Remove a permission rule; it stops being enforced immediately. Requires superadmin privileges.
+// @Tags admin
+// @Accept json
+// @Param policy body PolicyRequest true "Permission rule"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/admin/rbac/policies [delete]
+func (h *RBACHandler) RemovePolicy(c *gin.Context) {
+	var req PolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Subject, object and action are required"})
+		return
+	}
+
+	if err := h.rbacUsecase.RemovePolicy(c.Request.Context(), model.Policy(req)); err != nil {
+		handleError(c, h.logger, err, "Rule", "Failed to remove policy")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListRoleAssignments godoc
+// @Summary List role assignments
+// @Description List every Casbin role (g) assignment. Requires superadmin privileges.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} RoleAssignmentListResponse
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/admin/rbac/role-assignments [get]
+func (h *RBACHandler) ListRoleAssignments(c *gin.Context) {
+	assignments, err := h.rbacUsecase.ListRoleAssignments(c.Request.Context())
+	if err != nil {
+		handleError(c, h.logger, err, "Rule", "Failed to list role assignments")
+		return
+	}
+
+	c.JSON(http.StatusOK, RoleAssignmentListResponse{Data: assignments})
+}
+
+// AddRoleAssignment godoc
+// @Summary Assign a role
+// @Description Make a subject (usually a user email) a member of a role within a tenant.
+// @Description The assignment is validated against the RBAC model, saved and enforced immediately. Requires superadmin privileges.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param assignment body RoleAssignmentRequest true "Role assignment"
+// @Success 201 {object} model.RoleAssignment
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/admin/rbac/role-assignments [post]
+func (h *RBACHandler) AddRoleAssignment(c *gin.Context) {
+	var req RoleAssignmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Subject, role and tenant are required"})
+		return
+	}
+
+	assignment, err := h.rbacUsecase.AddRoleAssignment(c.Request.Context(), model.RoleAssignment(req))
+	if err != nil {
+		handleError(c, h.logger, err, "Rule", "Failed to assign role")
+		return
+	}
+
+	c.JSON(http.StatusCreated, assignment)
+}
+
+// RemoveRoleAssignment godoc
+// @Summary Unassign a role
+// @Description Remove a role assignment; it stops being enforced immediately. Requires superadmin privileges.
+// @Tags admin
+// @Accept json
+// @Param assignment body RoleAssignmentRequest true "Role assignment"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/admin/rbac/role-assignments [delete]
+func (h *RBACHandler) RemoveRoleAssignment(c *gin.Context) {
+	var req RoleAssignmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Subject, role and tenant are required"})
+		return
+	}
+
+	if err := h.rbacUsecase.RemoveRoleAssignment(c.Request.Context(), model.RoleAssignment(req)); err != nil {
+		handleError(c, h.logger, err, "Rule", "Failed to unassign role")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ReloadStatus godoc
+// @Summary Get RBAC reload status
+// @Description Report whether the RBAC model and policy files are watched, how many reloads succeeded and failed,
+// @Description and the outcome of the last reload. Requires superadmin privileges.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} model.PolicyReloadStatus
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/admin/rbac/reload-status [get]
+func (h *RBACHandler) ReloadStatus(c *gin.Context) {
+	status, err := h.rbacUsecase.ReloadStatus(c.Request.Context())
+	if err != nil {
+		handleError(c, h.logger, err, "Rule", "Failed to get reload status")
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// Explain godoc
+// @Summary Explain a policy decision
+// @Description Evaluate a subject (role or user email) performing an action (HTTP method) on an object (route template)
+// @Description in a tenant with the active permission rules. Allowed decisions name the matching rule and the chain of
+// @Description role assignments leading to its subject. Route templates are mapped to resource objects as the RBAC
+// @Description middleware does. Requires superadmin privileges.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param query body PolicyQueryRequest true "Policy query"
+// @Success 200 {object} model.PolicyDecision
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/admin/rbac/explain [post]
+func (h *RBACHandler) Explain(c *gin.Context) {
+	var req PolicyQueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Subject, tenant, object and action are required"})
+		return
+	}
+
+	decision, err := h.rbacUsecase.Explain(c.Request.Context(), model.PolicyQuery(req))
+	if err != nil {
+		handleError(c, h.logger, err, "Rule", "Failed to explain policy decision")
+		return
+	}
+
+	c.JSON(http.StatusOK, decision)
+}
+
+// DryRun godoc
+// @Summary Dry-run a proposed policy
+// @Description Evaluate cases with expected decisions against a proposed policy, given in the format of policy.csv,
+// @Description under the active model. The active policy is not changed. Failing cases are reported with passed set
+// @Description to false. Requires superadmin privileges.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param dryRun body DryRunRequest true "Proposed policy and cases"
+// @Success 200 {object} model.PolicyDryRun
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/admin/rbac/dry-run [post]
+func (h *RBACHandler) DryRun(c *gin.Context) {
+	var req DryRunRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Policy and cases with a subject, tenant, object, action and allow are required"})
+		return
+	}
+
+	cases := make([]model.PolicyCase, 0, len(req.Cases))
+	for _, policyCase := range req.Cases {
+		cases = append(cases, model.PolicyCase{
+			PolicyQuery: model.PolicyQuery{Subject: policyCase.Subject, Tenant: policyCase.Tenant, Object: policyCase.Object, Action: policyCase.Action},
+			Allow:       *policyCase.Allow,
+		})
+	}
+
+	run, err := h.rbacUsecase.DryRun(c.Request.Context(), req.Policy, cases)
+	if err != nil {
+		handleError(c, h.logger, err, "Rule", "Failed to dry-run policy")
+		return
+	}
+
+	c.JSON(http.StatusOK, run)
+}
@@ -0,0 +1,333 @@
+package handler_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/delivery/http/v1/handler"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRBACUsecase is a mock implementation of the RBACUsecase interface
+type MockRBACUsecase struct {
+	mock.Mock
+}
+
+func (m *MockRBACUsecase) ListPolicies(ctx context.Context) ([]model.Policy, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.Policy), args.Error(1)
+}
+
+func (m *MockRBACUsecase) AddPolicy(ctx context.Context, policy model.Policy) (*model.Policy, error) {
+	args := m.Called(ctx, policy)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Policy), args.Error(1)
+}
+
+func (m *MockRBACUsecase) RemovePolicy(ctx context.Context, policy model.Policy) error {
+	args := m.Called(ctx, policy)
+	return args.Error(0)
+}
+
+func (m *MockRBACUsecase) ListRoleAssignments(ctx context.Context) ([]model.RoleAssignment, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.RoleAssignment), args.Error(1)
+}
+
+func (m *MockRBACUsecase) AddRoleAssignment(ctx context.Context, assignment model.RoleAssignment) (*model.RoleAssignment, error) {
+	args := m.Called(ctx, assignment)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.RoleAssignment), args.Error(1)
+}
+
+func (m *MockRBACUsecase) RemoveRoleAssignment(ctx context.Context, assignment model.RoleAssignment) error {
+	args := m.Called(ctx, assignment)
+	return args.Error(0)
+}
+
+func (m *MockRBACUsecase) ReloadStatus(ctx context.Context) (*model.PolicyReloadStatus, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.PolicyReloadStatus), args.Error(1)
+}
+
+func (m *MockRBACUsecase) Explain(ctx context.Context, query model.PolicyQuery) (*model.PolicyDecision, error) {
+	args := m.Called(ctx, query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.PolicyDecision), args.Error(1)
+}
+
+func (m *MockRBACUsecase) DryRun(ctx context.Context, policy string, cases []model.PolicyCase) (*model.PolicyDryRun, error) {
+	args := m.Called(ctx, policy, cases)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.PolicyDryRun), args.Error(1)
+}
+
+func TestRBACHandler_Policies(t *testing.T) {
+	mockUsecase := new(MockRBACUsecase)
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+	rbacHandler := handler.NewRBACHandler(mockUsecase, log)
+	router := setupRouter()
+	router.GET("/api/v1/admin/rbac/policies", rbacHandler.ListPolicies)
+	router.POST("/api/v1/admin/rbac/policies", rbacHandler.AddPolicy)
+	router.DELETE("/api/v1/admin/rbac/policies", rbacHandler.RemovePolicy)
+
+	policy := model.Policy{Subject: "user", Tenant: "*", Object: "/api/v1/todos", Action: "POST"}
+	send := func(method string, body interface{}) *httptest.ResponseRecorder {
+		reqBody, _ := json.Marshal(body)
+		req, _ := http.NewRequest(method, "/api/v1/admin/rbac/policies", bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("List", func(t *testing.T) {
+		mockUsecase.On("ListPolicies", mock.Anything).Return([]model.Policy{policy}, nil).Once()
+
+		w := send(http.MethodGet, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response handler.PolicyListResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, []model.Policy{policy}, response.Data)
+	})
+
+	t.Run("Add", func(t *testing.T) {
+		mockUsecase.On("AddPolicy", mock.Anything, policy).Return(&policy, nil).Once()
+
+		w := send(http.MethodPost, handler.PolicyRequest(policy))
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+
+	t.Run("Add missing fields", func(t *testing.T) {
+		w := send(http.MethodPost, map[string]string{"subject": "user"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Add existing rule", func(t *testing.T) {
+		mockUsecase.On("AddPolicy", mock.Anything, policy).Return(nil, model.ErrConflict).Once()
+
+		w := send(http.MethodPost, handler.PolicyRequest(policy))
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Add invalid rule", func(t *testing.T) {
+		mockUsecase.On("AddPolicy", mock.Anything, policy).Return(nil, model.NewValidationError("action", "must be an HTTP method")).Once()
+
+		w := send(http.MethodPost, handler.PolicyRequest(policy))
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		mockUsecase.On("RemovePolicy", mock.Anything, policy).Return(nil).Once()
+
+		w := send(http.MethodDelete, handler.PolicyRequest(policy))
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("Remove missing rule", func(t *testing.T) {
+		mockUsecase.On("RemovePolicy", mock.Anything, policy).Return(model.ErrNotFound).Once()
+
+		w := send(http.MethodDelete, handler.PolicyRequest(policy))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Forbidden", func(t *testing.T) {
+		mockUsecase.On("ListPolicies", mock.Anything).Return(nil, model.ErrForbidden).Once()
+
+		w := send(http.MethodGet, nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestRBACHandler_RoleAssignments(t *testing.T) {
+	mockUsecase := new(MockRBACUsecase)
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+	rbacHandler := handler.NewRBACHandler(mockUsecase, log)
+	router := setupRouter()
+	router.POST("/api/v1/admin/rbac/role-assignments", rbacHandler.AddRoleAssignment)
+	router.DELETE("/api/v1/admin/rbac/role-assignments", rbacHandler.RemoveRoleAssignment)
+
+	assignment := model.RoleAssignment{Subject: "carol@example.com", Role: "admin", Tenant: "default"}
+	send := func(method string, body interface{}) *httptest.ResponseRecorder {
+		reqBody, _ := json.Marshal(body)
+		req, _ := http.NewRequest(method, "/api/v1/admin/rbac/role-assignments", bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Add", func(t *testing.T) {
+		mockUsecase.On("AddRoleAssignment", mock.Anything, assignment).Return(&assignment, nil).Once()
+
+		w := send(http.MethodPost, handler.RoleAssignmentRequest(assignment))
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		var response model.RoleAssignment
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, assignment, response)
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		mockUsecase.On("RemoveRoleAssignment", mock.Anything, assignment).Return(nil).Once()
+
+		w := send(http.MethodDelete, handler.RoleAssignmentRequest(assignment))
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestRBACHandler_ReloadStatus(t *testing.T) {
+	mockUsecase := new(MockRBACUsecase)
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+	rbacHandler := handler.NewRBACHandler(mockUsecase, log)
+	router := setupRouter()
+	router.GET("/api/v1/admin/rbac/reload-status", rbacHandler.ReloadStatus)
+
+	mockUsecase.On("ReloadStatus", mock.Anything).Return(&model.PolicyReloadStatus{
+		Watching:     true,
+		ReloadCount:  3,
+		FailureCount: 1,
+		LastStatus:   model.PolicyReloadFailed,
+		LastError:    "invalid policy rule size",
+	}, nil).Once()
+
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/admin/rbac/reload-status", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, float64(3), response["reload_count"])
+	assert.Equal(t, "failed", response["last_status"])
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestRBACHandler_Explain(t *testing.T) {
+	mockUsecase := new(MockRBACUsecase)
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+	rbacHandler := handler.NewRBACHandler(mockUsecase, log)
+	router := setupRouter()
+	router.POST("/api/v1/admin/rbac/explain", rbacHandler.Explain)
+
+	query := model.PolicyQuery{Subject: "alice@example.com", Tenant: "default", Object: "/api/v1/todos", Action: "POST"}
+	send := func(body interface{}) *httptest.ResponseRecorder {
+		reqBody, _ := json.Marshal(body)
+		req, _ := http.NewRequest(http.MethodPost, "/api/v1/admin/rbac/explain", bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		mockUsecase.On("Explain", mock.Anything, query).Return(&model.PolicyDecision{
+			PolicyQuery: query,
+			Allowed:     true,
+			Rule:        &model.Policy{Subject: "admin", Tenant: "*", Object: "/api/v1/todos", Action: "POST"},
+			RoleChain:   []string{"alice@example.com", "admin"},
+			Roles:       []string{"admin"},
+		}, nil).Once()
+
+		w := send(handler.PolicyQueryRequest(query))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{
+			"subject": "alice@example.com", "tenant": "default", "object": "/api/v1/todos", "action": "POST",
+			"allowed": true,
+			"rule": {"subject": "admin", "tenant": "*", "object": "/api/v1/todos", "action": "POST"},
+			"role_chain": ["alice@example.com", "admin"],
+			"roles": ["admin"]
+		}`, w.Body.String())
+	})
+
+	t.Run("Missing fields", func(t *testing.T) {
+		w := send(map[string]string{"subject": "alice@example.com"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Forbidden", func(t *testing.T) {
+		mockUsecase.On("Explain", mock.Anything, query).Return(nil, model.ErrForbidden).Once()
+
+		w := send(handler.PolicyQueryRequest(query))
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
+
+func TestRBACHandler_DryRun(t *testing.T) {
+	mockUsecase := new(MockRBACUsecase)
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+	rbacHandler := handler.NewRBACHandler(mockUsecase, log)
+	router := setupRouter()
+	router.POST("/api/v1/admin/rbac/dry-run", rbacHandler.DryRun)
+
+	send := func(body interface{}) *httptest.ResponseRecorder {
+		reqBody, _ := json.Marshal(body)
+		req, _ := http.NewRequest(http.MethodPost, "/api/v1/admin/rbac/dry-run", bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	policy := "p, user, *, /api/v1/todos, GET"
+	deny := false
+	body := handler.DryRunRequest{
+		Policy: policy,
+		Cases:  []handler.PolicyCaseRequest{{Subject: "user", Tenant: "default", Object: "/api/v1/todos", Action: "POST", Allow: &deny}},
+	}
+	cases := []model.PolicyCase{{PolicyQuery: model.PolicyQuery{Subject: "user", Tenant: "default", Object: "/api/v1/todos", Action: "POST"}, Allow: false}}
+
+	t.Run("Success", func(t *testing.T) {
+		run := &model.PolicyDryRun{Passed: true, Results: []model.PolicyCaseResult{}}
+		mockUsecase.On("DryRun", mock.Anything, policy, cases).Return(run, nil).Once()
+
+		w := send(body)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"passed": true, "failures": 0, "results": []}`, w.Body.String())
+	})
+
+	t.Run("Case without expected decision", func(t *testing.T) {
+		w := send(map[string]interface{}{
+			"policy": policy,
+			"cases":  []map[string]string{{"subject": "user", "tenant": "default", "object": "/api/v1/todos", "action": "POST"}},
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Invalid policy", func(t *testing.T) {
+		mockUsecase.On("DryRun", mock.Anything, policy, cases).Return(nil, model.NewValidationError("policy", "line 1: x is not defined by the RBAC model")).Once()
+
+		w := send(body)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	})
+}
@@ -2,7 +2,9 @@ package handler
 
 import (
 	"net/http"
+	"strconv"
 
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
 	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
 	"github.com/bgaurav7/gin-microservice-boilerplate/internal/usecase"
 	"github.com/gin-gonic/gin"
@@ -19,6 +21,32 @@ type TodoCreateRequest struct {
 	Title string `json:"title" binding:"required"`
 }
 
+// TodoUpdateRequest represents the request body for replacing a todo
+type TodoUpdateRequest struct {
+	Title     string `json:"title" binding:"required"`
+	Completed *bool  `json:"completed" binding:"required"`
+}
+
+// TodoPatchRequest represents the request body for partially updating a todo
+type TodoPatchRequest struct {
+	Title     *string `json:"title"`
+	Completed *bool   `json:"completed"`
+}
+
+// TodoListResponse represents a page of todos
+type TodoListResponse struct {
+	Data []model.Todo `json:"data"`
+	Meta TodoListMeta `json:"meta"`
+}
+
+// TodoListMeta describes the position of a page within a todo listing
+type TodoListMeta struct {
+	Total      int64  `json:"total"`
+	Limit      int    `json:"limit"`
+	Offset     int    `json:"offset"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
 // NewTodoHandler creates a new todo handler
 func NewTodoHandler(todoUsecase usecase.TodoUsecase, logger *logger.Logger) *TodoHandler {
 	return &TodoHandler{
@@ -29,23 +57,85 @@ func NewTodoHandler(todoUsecase usecase.TodoUsecase, logger *logger.Logger) *Tod
 
 // GetAll godoc
 // @Summary Get all todos
-// @Description Get all todos
+// @Description Get a page of the caller's todos, or of another owner for superadmins.
+// @Description Supports offset and cursor pagination; a Link header points to the next page.
 // @Tags todos
 // @Accept json
 // @Produce json
-// @Success 200 {array} model.Todo
+// @Param owner query string false "Owner to read todos from (superadmin only)"
+// @Param completed query bool false "Filter by completion state"
+// @Param title query string false "Filter by case-insensitive title substring"
+// @Param created_after query string false "Only todos created at or after this RFC 3339 time"
+// @Param created_before query string false "Only todos created before this RFC 3339 time"
+// @Param updated_after query string false "Only todos updated at or after this RFC 3339 time"
+// @Param updated_before query string false "Only todos updated before this RFC 3339 time"
+// @Param sort query string false "Sort field (id, title, completed, created_at, updated_at), prefix with - for descending"
+// @Param limit query int false "Page size (1-100, default 20)"
+// @Param offset query int false "Number of todos to skip"
+// @Param cursor query string false "Opaque cursor from meta.next_cursor"
+// @Success 200 {object} TodoListResponse
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 422 {object} map[string]string "Validation failed"
 // @Router /api/v1/todos [get]
 func (h *TodoHandler) GetAll(c *gin.Context) {
-	todos, err := h.todoUsecase.List(c.Request.Context())
+	params, err := parseTodoListParams(c)
 	if err != nil {
-		h.logger.Error("Failed to get todos", map[string]interface{}{
-			"error": err.Error(),
-		})
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get todos"})
+		handleError(c, h.logger, err, "Todo", "Failed to get todos")
+		return
+	}
+
+	page, err := h.todoUsecase.List(c.Request.Context(), params)
+	if err != nil {
+		handleError(c, h.logger, err, "Todo", "Failed to get todos")
 		return
 	}
 
-	c.JSON(http.StatusOK, todos)
+	if links := todoListLinks(c, page); links != "" {
+		c.Header("Link", links)
+	}
+
+	items := page.Items
+	if items == nil {
+		items = []model.Todo{}
+	}
+
+	c.JSON(http.StatusOK, TodoListResponse{
+		Data: items,
+		Meta: TodoListMeta{
+			Total:      page.Total,
+			Limit:      page.Limit,
+			Offset:     page.Offset,
+			NextCursor: page.NextCursor,
+		},
+	})
+}
+
+// GetByID godoc
+// @Summary Get a todo
+// @Description Get a todo by ID
+// @Tags todos
+// @Accept json
+// @Produce json
+// @Param id path int true "Todo ID"
+// @Param owner query string false "Owner to read the todo from (superadmin only)"
+// @Success 200 {object} model.Todo
+// @Failure 400 {object} map[string]string "Invalid ID"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "Todo not found"
+// @Router /api/v1/todos/{id} [get]
+func (h *TodoHandler) GetByID(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	todo, err := h.todoUsecase.Get(c.Request.Context(), id, c.Query("owner"))
+	if err != nil {
+		handleError(c, h.logger, err, "Todo", "Failed to get todo")
+		return
+	}
+
+	c.JSON(http.StatusOK, todo)
 }
 
 // Create godoc
@@ -57,6 +147,7 @@ func (h *TodoHandler) GetAll(c *gin.Context) {
 // @Param todo body TodoCreateRequest true "Todo object"
 // @Success 201 {object} model.Todo
 // @Failure 400 {object} map[string]string "Invalid request"
+// @Failure 422 {object} map[string]string "Validation failed"
 // @Router /api/v1/todos [post]
 func (h *TodoHandler) Create(c *gin.Context) {
 	var req TodoCreateRequest
@@ -70,12 +161,124 @@ func (h *TodoHandler) Create(c *gin.Context) {
 
 	todo, err := h.todoUsecase.Create(c.Request.Context(), req.Title)
 	if err != nil {
-		h.logger.Error("Failed to create todo", map[string]interface{}{
+		handleError(c, h.logger, err, "Todo", "Failed to create todo")
+		return
+	}
+
+	c.JSON(http.StatusCreated, todo)
+}
+
+// Update godoc
+// @Summary Replace a todo
+// @Description Replace the title and completion state of a todo
+// @Tags todos
+// @Accept json
+// @Produce json
+// @Param id path int true "Todo ID"
+// @Param todo body TodoUpdateRequest true "Todo object"
+// @Success 200 {object} model.Todo
+// @Failure 400 {object} map[string]string "Invalid request"
+// @Failure 404 {object} map[string]string "Todo not found"
+// @Failure 422 {object} map[string]string "Validation failed"
+// @Router /api/v1/todos/{id} [put]
+func (h *TodoHandler) Update(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	var req TodoUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Invalid request", map[string]interface{}{
 			"error": err.Error(),
 		})
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create todo"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Title and completed are required"})
 		return
 	}
 
-	c.JSON(http.StatusCreated, todo)
+	todo, err := h.todoUsecase.Update(c.Request.Context(), id, req.Title, *req.Completed)
+	if err != nil {
+		handleError(c, h.logger, err, "Todo", "Failed to update todo")
+		return
+	}
+
+	c.JSON(http.StatusOK, todo)
+}
+
+// Patch godoc
+// @Summary Partially update a todo
+// @Description Update only the supplied fields of a todo
+// @Tags todos
+// @Accept json
+// @Produce json
+// @Param id path int true "Todo ID"
+// @Param todo body TodoPatchRequest true "Fields to update"
+// @Success 200 {object} model.Todo
+// @Failure 400 {object} map[string]string "Invalid request"
+// @Failure 404 {object} map[string]string "Todo not found"
+// @Failure 422 {object} map[string]string "Validation failed"
+// @Router /api/v1/todos/{id} [patch]
+func (h *TodoHandler) Patch(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	var req TodoPatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Invalid request", map[string]interface{}{
+			"error": err.Error(),
+		})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	if req.Title == nil && req.Completed == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "At least one of title or completed is required"})
+		return
+	}
+
+	todo, err := h.todoUsecase.Patch(c.Request.Context(), id, usecase.TodoPatch{
+		Title:     req.Title,
+		Completed: req.Completed,
+	})
+	if err != nil {
+		handleError(c, h.logger, err, "Todo", "Failed to update todo")
+		return
+	}
+
+	c.JSON(http.StatusOK, todo)
+}
+
+// Delete godoc
+// @Summary Delete a todo
+// @Description Delete a todo by ID
+// @Tags todos
+// @Param id path int true "Todo ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} map[string]string "Invalid ID"
+// @Failure 404 {object} map[string]string "Todo not found"
+// @Router /api/v1/todos/{id} [delete]
+func (h *TodoHandler) Delete(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.todoUsecase.Delete(c.Request.Context(), id); err != nil {
+		handleError(c, h.logger, err, "Todo", "Failed to delete todo")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// parseID extracts the todo ID from the path, writing a 400 response if it is invalid
+func (h *TodoHandler) parseID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 0)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid todo ID"})
+		return 0, false
+	}
+	return uint(id), true
 }
@@ -8,10 +8,12 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/bgaurav7/gin-microservice-boilerplate/internal/delivery/http/v1/handler"
 	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
 	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/usecase"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -22,12 +24,12 @@ type MockTodoUsecase struct {
 	mock.Mock
 }
 
-func (m *MockTodoUsecase) List(ctx context.Context) ([]model.Todo, error) {
-	args := m.Called(ctx)
+func (m *MockTodoUsecase) List(ctx context.Context, params usecase.TodoListParams) (*usecase.TodoPage, error) {
+	args := m.Called(ctx, params)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).([]model.Todo), args.Error(1)
+	return args.Get(0).(*usecase.TodoPage), args.Error(1)
 }
 
 func (m *MockTodoUsecase) Create(ctx context.Context, title string) (*model.Todo, error) {
@@ -38,6 +40,35 @@ func (m *MockTodoUsecase) Create(ctx context.Context, title string) (*model.Todo
 	return args.Get(0).(*model.Todo), args.Error(1)
 }
 
+func (m *MockTodoUsecase) Get(ctx context.Context, id uint, owner string) (*model.Todo, error) {
+	args := m.Called(ctx, id, owner)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Todo), args.Error(1)
+}
+
+func (m *MockTodoUsecase) Update(ctx context.Context, id uint, title string, completed bool) (*model.Todo, error) {
+	args := m.Called(ctx, id, title, completed)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Todo), args.Error(1)
+}
+
+func (m *MockTodoUsecase) Patch(ctx context.Context, id uint, patch usecase.TodoPatch) (*model.Todo, error) {
+	args := m.Called(ctx, id, patch)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Todo), args.Error(1)
+}
+
+func (m *MockTodoUsecase) Delete(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
 func setupRouter() *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	return gin.New()
@@ -56,39 +87,112 @@ func TestTodoHandler_GetAll(t *testing.T) {
 			{ID: 2, Title: "Test Todo 2", Completed: true},
 		}
 
-		mockUsecase.On("List", mock.Anything).Return(expectedTodos, nil).Once()
+		mockUsecase.On("List", mock.Anything, usecase.TodoListParams{}).Return(&usecase.TodoPage{
+			Items: expectedTodos,
+			Total: 2,
+			Limit: 20,
+		}, nil).Once()
 
 		req, _ := http.NewRequest(http.MethodGet, "/api/v1/todos", nil)
 		w := httptest.NewRecorder()
 		router.ServeHTTP(w, req)
 
 		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Link"))
 
-		var response []model.Todo
+		var response handler.TodoListResponse
 		err := json.Unmarshal(w.Body.Bytes(), &response)
 		assert.NoError(t, err)
-		assert.Equal(t, expectedTodos, response)
+		assert.Equal(t, expectedTodos, response.Data)
+		assert.Equal(t, int64(2), response.Meta.Total)
+		assert.Equal(t, 20, response.Meta.Limit)
 		mockUsecase.AssertExpectations(t)
 	})
 
 	t.Run("Empty List", func(t *testing.T) {
-		mockUsecase.On("List", mock.Anything).Return([]model.Todo{}, nil).Once()
+		mockUsecase.On("List", mock.Anything, usecase.TodoListParams{}).Return(&usecase.TodoPage{Limit: 20}, nil).Once()
 
 		req, _ := http.NewRequest(http.MethodGet, "/api/v1/todos", nil)
 		w := httptest.NewRecorder()
 		router.ServeHTTP(w, req)
 
 		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"data":[],"meta":{"total":0,"limit":20,"offset":0}}`, w.Body.String())
+		mockUsecase.AssertExpectations(t)
+	})
 
-		var response []model.Todo
+	t.Run("Filters And Pagination", func(t *testing.T) {
+		completed := true
+		createdAfter := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+		mockUsecase.On("List", mock.Anything, usecase.TodoListParams{
+			Completed:    &completed,
+			Title:        "milk",
+			CreatedAfter: &createdAfter,
+			Sort:         "-created_at",
+			Limit:        10,
+			Offset:       20,
+		}).Return(&usecase.TodoPage{
+			Items:      []model.Todo{{ID: 3, Title: "Buy milk", Completed: true}},
+			Total:      42,
+			Limit:      10,
+			Offset:     20,
+			NextCursor: "next-page",
+		}, nil).Once()
+
+		req, _ := http.NewRequest(http.MethodGet, "/api/v1/todos?completed=true&title=milk&created_after=2024-01-02T03:04:05Z&sort=-created_at&limit=10&offset=20", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		link := w.Header().Get("Link")
+		assert.Contains(t, link, `cursor=next-page`)
+		assert.Contains(t, link, `rel="next"`)
+		assert.Contains(t, link, `offset=10`)
+		assert.Contains(t, link, `rel="prev"`)
+
+		var response handler.TodoListResponse
 		err := json.Unmarshal(w.Body.Bytes(), &response)
 		assert.NoError(t, err)
-		assert.Empty(t, response)
+		assert.Equal(t, int64(42), response.Meta.Total)
+		assert.Equal(t, "next-page", response.Meta.NextCursor)
+		mockUsecase.AssertExpectations(t)
+	})
+
+	t.Run("Invalid Query", func(t *testing.T) {
+		for _, query := range []string{"limit=ten", "completed=maybe", "created_before=yesterday"} {
+			req, _ := http.NewRequest(http.MethodGet, "/api/v1/todos?"+query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusUnprocessableEntity, w.Code, query)
+		}
+	})
+
+	t.Run("Owner Filter", func(t *testing.T) {
+		mockUsecase.On("List", mock.Anything, usecase.TodoListParams{Owner: "bob@example.com"}).Return(&usecase.TodoPage{Limit: 20}, nil).Once()
+
+		req, _ := http.NewRequest(http.MethodGet, "/api/v1/todos?owner=bob@example.com", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockUsecase.AssertExpectations(t)
+	})
+
+	t.Run("Forbidden Owner Filter", func(t *testing.T) {
+		mockUsecase.On("List", mock.Anything, usecase.TodoListParams{Owner: "bob@example.com"}).Return(nil, model.ErrForbidden).Once()
+
+		req, _ := http.NewRequest(http.MethodGet, "/api/v1/todos?owner=bob@example.com", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
 		mockUsecase.AssertExpectations(t)
 	})
 
 	t.Run("Error", func(t *testing.T) {
-		mockUsecase.On("List", mock.Anything).Return(nil, errors.New("database error")).Once()
+		mockUsecase.On("List", mock.Anything, usecase.TodoListParams{}).Return(nil, errors.New("database error")).Once()
 
 		req, _ := http.NewRequest(http.MethodGet, "/api/v1/todos", nil)
 		w := httptest.NewRecorder()
@@ -167,3 +271,191 @@ func TestTodoHandler_Create(t *testing.T) {
 		mockUsecase.AssertExpectations(t)
 	})
 }
+
+func TestTodoHandler_GetByID(t *testing.T) {
+	mockUsecase := new(MockTodoUsecase)
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+	todoHandler := handler.NewTodoHandler(mockUsecase, log)
+	router := setupRouter()
+	router.GET("/api/v1/todos/:id", todoHandler.GetByID)
+
+	t.Run("Success", func(t *testing.T) {
+		expectedTodo := &model.Todo{ID: 1, Title: "Test Todo"}
+		mockUsecase.On("Get", mock.Anything, uint(1), "").Return(expectedTodo, nil).Once()
+
+		req, _ := http.NewRequest(http.MethodGet, "/api/v1/todos/1", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response model.Todo
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err)
+		assert.Equal(t, expectedTodo.Title, response.Title)
+		mockUsecase.AssertExpectations(t)
+	})
+
+	t.Run("Not Found", func(t *testing.T) {
+		mockUsecase.On("Get", mock.Anything, uint(2), "").Return(nil, model.ErrNotFound).Once()
+
+		req, _ := http.NewRequest(http.MethodGet, "/api/v1/todos/2", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		mockUsecase.AssertExpectations(t)
+	})
+
+	t.Run("Invalid ID", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/api/v1/todos/abc", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		mockUsecase.On("Get", mock.Anything, uint(3), "").Return(nil, errors.New("database error")).Once()
+
+		req, _ := http.NewRequest(http.MethodGet, "/api/v1/todos/3", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		mockUsecase.AssertExpectations(t)
+	})
+}
+
+func TestTodoHandler_Update(t *testing.T) {
+	mockUsecase := new(MockTodoUsecase)
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+	todoHandler := handler.NewTodoHandler(mockUsecase, log)
+	router := setupRouter()
+	router.PUT("/api/v1/todos/:id", todoHandler.Update)
+
+	t.Run("Success", func(t *testing.T) {
+		expectedTodo := &model.Todo{ID: 1, Title: "Renamed", Completed: true}
+		mockUsecase.On("Update", mock.Anything, uint(1), "Renamed", true).Return(expectedTodo, nil).Once()
+
+		reqBody, _ := json.Marshal(map[string]interface{}{"title": "Renamed", "completed": true})
+		req, _ := http.NewRequest(http.MethodPut, "/api/v1/todos/1", bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockUsecase.AssertExpectations(t)
+	})
+
+	t.Run("Missing Completed", func(t *testing.T) {
+		reqBody, _ := json.Marshal(map[string]interface{}{"title": "Renamed"})
+		req, _ := http.NewRequest(http.MethodPut, "/api/v1/todos/1", bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Validation Error", func(t *testing.T) {
+		mockUsecase.On("Update", mock.Anything, uint(1), "   ", false).
+			Return(nil, model.NewValidationError("title", "must not be empty")).Once()
+
+		reqBody, _ := json.Marshal(map[string]interface{}{"title": "   ", "completed": false})
+		req, _ := http.NewRequest(http.MethodPut, "/api/v1/todos/1", bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+		var response map[string]string
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err)
+		assert.Equal(t, "title", response["field"])
+		mockUsecase.AssertExpectations(t)
+	})
+
+	t.Run("Not Found", func(t *testing.T) {
+		mockUsecase.On("Update", mock.Anything, uint(9), "Renamed", true).Return(nil, model.ErrNotFound).Once()
+
+		reqBody, _ := json.Marshal(map[string]interface{}{"title": "Renamed", "completed": true})
+		req, _ := http.NewRequest(http.MethodPut, "/api/v1/todos/9", bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		mockUsecase.AssertExpectations(t)
+	})
+}
+
+func TestTodoHandler_Patch(t *testing.T) {
+	mockUsecase := new(MockTodoUsecase)
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+	todoHandler := handler.NewTodoHandler(mockUsecase, log)
+	router := setupRouter()
+	router.PATCH("/api/v1/todos/:id", todoHandler.Patch)
+
+	t.Run("Success", func(t *testing.T) {
+		expectedTodo := &model.Todo{ID: 1, Title: "Test Todo", Completed: true}
+		mockUsecase.On("Patch", mock.Anything, uint(1), mock.MatchedBy(func(patch usecase.TodoPatch) bool {
+			return patch.Title == nil && patch.Completed != nil && *patch.Completed
+		})).Return(expectedTodo, nil).Once()
+
+		reqBody, _ := json.Marshal(map[string]interface{}{"completed": true})
+		req, _ := http.NewRequest(http.MethodPatch, "/api/v1/todos/1", bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response model.Todo
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err)
+		assert.True(t, response.Completed)
+		mockUsecase.AssertExpectations(t)
+	})
+
+	t.Run("Empty Patch", func(t *testing.T) {
+		reqBody, _ := json.Marshal(map[string]interface{}{})
+		req, _ := http.NewRequest(http.MethodPatch, "/api/v1/todos/1", bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestTodoHandler_Delete(t *testing.T) {
+	mockUsecase := new(MockTodoUsecase)
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+	todoHandler := handler.NewTodoHandler(mockUsecase, log)
+	router := setupRouter()
+	router.DELETE("/api/v1/todos/:id", todoHandler.Delete)
+
+	t.Run("Success", func(t *testing.T) {
+		mockUsecase.On("Delete", mock.Anything, uint(1)).Return(nil).Once()
+
+		req, _ := http.NewRequest(http.MethodDelete, "/api/v1/todos/1", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		mockUsecase.AssertExpectations(t)
+	})
+
+	t.Run("Not Found", func(t *testing.T) {
+		mockUsecase.On("Delete", mock.Anything, uint(2)).Return(model.ErrNotFound).Once()
+
+		req, _ := http.NewRequest(http.MethodDelete, "/api/v1/todos/2", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		mockUsecase.AssertExpectations(t)
+	})
+}
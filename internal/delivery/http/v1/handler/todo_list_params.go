@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+// parseTodoListParams reads the listing filters and pagination from the query string
+func parseTodoListParams(c *gin.Context) (usecase.TodoListParams, error) {
+	params := usecase.TodoListParams{
+		Owner:  c.Query("owner"),
+		Title:  c.Query("title"),
+		Sort:   c.Query("sort"),
+		Cursor: c.Query("cursor"),
+	}
+
+	var err error
+	if params.Limit, err = queryInt(c, "limit"); err != nil {
+		return params, err
+	}
+	if params.Offset, err = queryInt(c, "offset"); err != nil {
+		return params, err
+	}
+
+	if raw, ok := c.GetQuery("completed"); ok {
+		completed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return params, model.NewValidationError("completed", "must be true or false")
+		}
+		params.Completed = &completed
+	}
+
+	for name, target := range map[string]**time.Time{
+		"created_after":  &params.CreatedAfter,
+		"created_before": &params.CreatedBefore,
+		"updated_after":  &params.UpdatedAfter,
+		"updated_before": &params.UpdatedBefore,
+	} {
+		if *target, err = queryTime(c, name); err != nil {
+			return params, err
+		}
+	}
+
+	return params, nil
+}
+
+// queryInt parses an optional integer query parameter
+func queryInt(c *gin.Context, name string) (int, error) {
+	raw, ok := c.GetQuery(name)
+	if !ok || raw == "" {
+		return 0, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, model.NewValidationError(name, "must be an integer")
+	}
+	return value, nil
+}
+
+// queryTime parses an optional RFC 3339 timestamp query parameter
+func queryTime(c *gin.Context, name string) (*time.Time, error) {
+	raw, ok := c.GetQuery(name)
+	if !ok || raw == "" {
+		return nil, nil
+	}
+	value, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return nil, model.NewValidationError(name, "must be an RFC 3339 timestamp")
+	}
+	return &value, nil
+}
+
+// todoListLinks builds an RFC 8288 Link header value for the neighbouring pages
+func todoListLinks(c *gin.Context, page *usecase.TodoPage) string {
+	var links []string
+
+	if page.NextCursor != "" {
+		query := cloneQuery(c.Request.URL.Query())
+		query.Del("offset")
+		query.Set("cursor", page.NextCursor)
+		links = append(links, pageLink(c, query, "next"))
+	}
+
+	if page.Offset > 0 {
+		query := cloneQuery(c.Request.URL.Query())
+		query.Del("cursor")
+		prev := page.Offset - page.Limit
+		if prev < 0 {
+			prev = 0
+		}
+		query.Set("offset", strconv.Itoa(prev))
+		links = append(links, pageLink(c, query, "prev"))
+	}
+
+	return strings.Join(links, ", ")
+}
+
+// pageLink formats a single Link header entry for the current path
+func pageLink(c *gin.Context, query url.Values, rel string) string {
+	target := url.URL{Path: c.Request.URL.Path, RawQuery: query.Encode()}
+	return fmt.Sprintf("<%s>; rel=%q", target.String(), rel)
+}
+
+// cloneQuery copies query values so they can be modified independently
+func cloneQuery(query url.Values) url.Values {
+	clone := make(url.Values, len(query))
+	for key, values := range query {
+		clone[key] = append([]string(nil), values...)
+	}
+	return clone
+}
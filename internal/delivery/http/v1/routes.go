@@ -9,21 +9,74 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// RegisterRoutes registers all API v1 routes
-func RegisterRoutes(router *gin.RouterGroup, database *db.Database, logger *logger.Logger) {
+// RegisterRoutes registers all API v1 routes.
+// requireSuperAdmin guards the /admin routes. The RBAC administration routes
+// are skipped when rbacUsecase is nil, and impersonation when
+// impersonationUsecase is nil. authorizer checks updates and deletes
+// of individual todos and may be nil.
+func RegisterRoutes(router *gin.RouterGroup, database *db.Database, logger *logger.Logger, authUsecase usecase.AuthUsecase, apiKeyUsecase usecase.APIKeyUsecase, rbacUsecase usecase.RBACUsecase, impersonationUsecase usecase.ImpersonationUsecase, authorizer usecase.ResourceAuthorizer, requireSuperAdmin ...gin.HandlerFunc) {
 	// Initialize repositories
 	todoRepo := repository.NewTodoRepository(database, logger)
 
 	// Initialize usecases
-	todoUsecase := usecase.NewTodoUsecase(todoRepo, logger)
+	todoUsecase := usecase.NewTodoUsecase(todoRepo, authorizer, logger)
 
 	// Initialize handlers
 	todoHandler := handler.NewTodoHandler(todoUsecase, logger)
+	adminHandler := handler.NewAdminHandler(authUsecase, logger)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyUsecase, logger)
 
 	// Register todo routes
 	todoRoutes := router.Group("/todos")
 	{
 		todoRoutes.GET("", todoHandler.GetAll)
 		todoRoutes.POST("", todoHandler.Create)
+		todoRoutes.GET("/:id", todoHandler.GetByID)
+		todoRoutes.PUT("/:id", todoHandler.Update)
+		todoRoutes.PATCH("/:id", todoHandler.Patch)
+		todoRoutes.DELETE("/:id", todoHandler.Delete)
 	}
+
+	// Register superadmin routes
+	adminRoutes := router.Group("/admin", requireSuperAdmin...)
+	{
+		adminRoutes.POST("/users/:email/revoke-tokens", adminHandler.RevokeUserTokens)
+		adminRoutes.GET("/api-keys", apiKeyHandler.List)
+		adminRoutes.POST("/api-keys", apiKeyHandler.Create)
+		adminRoutes.GET("/api-keys/:id", apiKeyHandler.Get)
+		adminRoutes.PATCH("/api-keys/:id", apiKeyHandler.Update)
+		adminRoutes.DELETE("/api-keys/:id", apiKeyHandler.Delete)
+	}
+
+	// Register impersonation routes
+	if impersonationUsecase != nil {
+		impersonationHandler := handler.NewImpersonationHandler(impersonationUsecase, logger)
+		adminRoutes.POST("/impersonate", impersonationHandler.Impersonate)
+	}
+
+	// Register RBAC administration routes
+	if rbacUsecase != nil {
+		rbacHandler := handler.NewRBACHandler(rbacUsecase, logger)
+		rbacRoutes := adminRoutes.Group("/rbac")
+		{
+			rbacRoutes.GET("/policies", rbacHandler.ListPolicies)
+			rbacRoutes.POST("/policies", rbacHandler.AddPolicy)
+			rbacRoutes.DELETE("/policies", rbacHandler.RemovePolicy)
+			rbacRoutes.GET("/role-assignments", rbacHandler.ListRoleAssignments)
+			rbacRoutes.POST("/role-assignments", rbacHandler.AddRoleAssignment)
+			rbacRoutes.DELETE("/role-assignments", rbacHandler.RemoveRoleAssignment)
+			rbacRoutes.GET("/reload-status", rbacHandler.ReloadStatus)
+			rbacRoutes.POST("/explain", rbacHandler.Explain)
+			rbacRoutes.POST("/dry-run", rbacHandler.DryRun)
+		}
+	}
+}
+
+// RegisterMeRoutes registers the routes introspecting the caller's
+// permissions. They are open to every authenticated caller, so router must not
+// apply the RBAC middleware.
+func RegisterMeRoutes(router *gin.RouterGroup, logger *logger.Logger, permissionUsecase usecase.PermissionUsecase) {
+	meHandler := handler.NewMeHandler(permissionUsecase, logger)
+	router.GET("", meHandler.Me)
+	router.POST("/can", meHandler.Can)
 }
@@ -0,0 +1,18 @@
+package auth
+
+import "context"
+
+// clientIPKey is the context key under which the client IP is stored
+type clientIPKey struct{}
+
+// WithClientIP returns a copy of ctx carrying the IP address of the client
+// that made the request
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey{}, ip)
+}
+
+// ClientIPFromContext returns the client IP stored in ctx, or "" if none is
+func ClientIPFromContext(ctx context.Context) string {
+	ip, _ := ctx.Value(clientIPKey{}).(string)
+	return ip
+}
@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// Principal represents the authenticated caller of a request
+type Principal struct {
+	ID           string
+	Email        string
+	IsSuperAdmin bool
+
+	// Roles are roles asserted by the token issuer, such as OIDC groups
+	Roles []string
+
+	// Tenant is the tenant the caller acts in; roles and data are scoped to it
+	Tenant string
+
+	// Scopes restrict the caller to the named roles; when empty the caller acts
+	// with every role it holds
+	Scopes []string
+
+	// Service names the service authenticated by API key; empty for users
+	Service string
+
+	// AMR lists the methods the caller authenticated with, such as AMRPassword
+	// and AMRMFA
+	AMR []string
+
+	// Actor is the email of the superadmin impersonating the caller; empty
+	// unless the token was issued for impersonation
+	Actor string
+
+	// TokenID and TokenExpiresAt identify the access token the caller presented
+	TokenID        string
+	TokenExpiresAt time.Time
+}
+
+// HasAMR reports whether the caller authenticated with the given method
+func (p *Principal) HasAMR(method string) bool {
+	return slices.Contains(p.AMR, method)
+}
+
+// HasScopes reports whether the caller may act with every named role as far
+// as its scopes go. Callers without scopes are unrestricted.
+func (p *Principal) HasScopes(scopes ...string) bool {
+	if len(p.Scopes) == 0 {
+		return true
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(p.Scopes, scope) {
+			return false
+		}
+	}
+	return true
+}
+
+// Impersonated reports whether a superadmin is acting as the caller
+func (p *Principal) Impersonated() bool {
+	return p.Actor != ""
+}
+
+// ServiceSubjectPrefix starts the subject of services, such as
+// "service:billing", keeping them apart from user emails in Casbin rules
+const ServiceSubjectPrefix = "service:"
+
+// ServiceSubject returns the subject a service is authorized as
+func ServiceSubject(service string) string {
+	return ServiceSubjectPrefix + service
+}
+
+// principalKey is the context key under which the principal is stored
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying the given principal
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the principal stored in ctx, if any
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
+
+// TenantFromContext returns the tenant of the principal stored in ctx, if any
+func TenantFromContext(ctx context.Context) (string, bool) {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok || principal.Tenant == "" {
+		return "", false
+	}
+	return principal.Tenant, true
+}
@@ -0,0 +1,21 @@
+package auth
+
+// Resource types checked by attribute-based authorization
+const (
+	ResourceTodo = "todo"
+)
+
+// Actions on resources checked by attribute-based authorization
+const (
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+// Resource describes the attributes of a concrete resource that
+// attribute-based authorization rules compare with the caller
+type Resource struct {
+	Type   string
+	ID     string
+	Owner  string
+	Tenant string
+}
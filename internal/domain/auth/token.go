@@ -0,0 +1,41 @@
+package auth
+
+import "time"
+
+// Authentication methods recorded in the amr claim of access tokens. The
+// values follow RFC 8176 where it defines one.
+const (
+	// AMRPassword is a password login
+	AMRPassword = "pwd"
+
+	// AMREmail is a login link or code sent by email
+	AMREmail = "email"
+
+	// AMROTP is a one-time password from an authenticator app, or a recovery code
+	AMROTP = "otp"
+
+	// AMRMFA is recorded when more than one factor was verified
+	AMRMFA = "mfa"
+)
+
+// TokenGrant describes the access token to issue for an authenticated user
+type TokenGrant struct {
+	Email string
+
+	// Tenant is the tenant the user acts in
+	Tenant string
+
+	// AMR lists the methods the user authenticated with
+	AMR []string
+
+	// Scopes restrict the token to the named roles; when empty the token
+	// carries every role the user holds
+	Scopes []string
+
+	// Actor is the email of the superadmin impersonating the user; empty when
+	// the user acts as itself
+	Actor string
+
+	// TTL overrides the lifetime of the access token when positive
+	TTL time.Duration
+}
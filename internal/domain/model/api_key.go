@@ -0,0 +1,33 @@
+package model
+
+import (
+	"time"
+)
+
+// APIKey represents a persisted API key authenticating a service.
+// Only a hash of the key is stored; Prefix is the public start of the key,
+// which identifies it without revealing it.
+type APIKey struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	Name       string     `json:"name" gorm:"size:255;not null"`
+	Service    string     `json:"service" gorm:"size:64;not null;index:idx_api_keys_service"`
+	Tenant     string     `json:"tenant" gorm:"size:255;not null"`
+	Prefix     string     `json:"prefix" gorm:"size:32;not null;uniqueIndex:idx_api_keys_prefix"`
+	KeyHash    string     `json:"-" gorm:"size:64;not null"`
+	Scopes     []string   `json:"scopes" gorm:"serializer:json;not null"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedBy  string     `json:"created_by" gorm:"size:255;not null"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt  time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName returns the table name for the APIKey model
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// Expired reports whether the key has expired at the given time
+func (k *APIKey) Expired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
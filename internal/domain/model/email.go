@@ -0,0 +1,8 @@
+package model
+
+// Email represents a plain-text email to a single recipient
+type Email struct {
+	To      string
+	Subject string
+	Body    string
+}
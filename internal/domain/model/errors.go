@@ -0,0 +1,45 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNotFound is returned when a requested resource does not exist
+	ErrNotFound = errors.New("resource not found")
+
+	// ErrUnauthenticated is returned when an operation requires a caller but none is known
+	ErrUnauthenticated = errors.New("caller is not authenticated")
+
+	// ErrForbidden is returned when the caller may not perform an operation
+	ErrForbidden = errors.New("operation not permitted")
+
+	// ErrConflict is returned when a resource collides with an existing one
+	ErrConflict = errors.New("resource already exists")
+
+	// ErrInvalidCredentials is returned when an email and password do not match an account
+	ErrInvalidCredentials = errors.New("invalid email or password")
+
+	// ErrInvalidToken is returned when a token is unknown, expired, revoked or reused
+	ErrInvalidToken = errors.New("invalid or expired token")
+)
+
+// ValidationError represents an invalid value supplied for a field
+type ValidationError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// NewValidationError creates a new validation error for the given field
+func NewValidationError(field, message string) *ValidationError {
+	return &ValidationError{
+		Field:   field,
+		Message: message,
+	}
+}
+
+// Error implements the error interface
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// LoginAttempts counts the recent failed logins of one key, such as an
+// account or a client IP
+type LoginAttempts struct {
+	Failures      int
+	LastFailureAt time.Time
+}
+
+// AuditEvent records a security-relevant action
+type AuditEvent struct {
+	// Action names what happened, such as "login.failed"
+	Action string
+	// Actor is the account or principal the action is about, if known
+	Actor string
+	// IP is the client address of the request, if known
+	IP string
+	// Details carries action-specific fields
+	Details map[string]interface{}
+}
@@ -0,0 +1,28 @@
+package model
+
+import (
+	"time"
+)
+
+// LoginChallenge represents a pending passwordless login.
+// The email sent for it carries a link naming the challenge and a 6-digit
+// code, of which only a hash is stored. Using either consumes the challenge.
+type LoginChallenge struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	Email     string     `json:"email" gorm:"size:255;not null;index:idx_login_challenges_email"`
+	CodeHash  string     `json:"-" gorm:"size:64;not null"`
+	Attempts  int        `json:"attempts" gorm:"not null;default:0"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TableName returns the table name for the LoginChallenge model
+func (LoginChallenge) TableName() string {
+	return "login_challenges"
+}
+
+// Expired reports whether the challenge can no longer be used at the given time
+func (c *LoginChallenge) Expired(now time.Time) bool {
+	return !now.Before(c.ExpiresAt)
+}
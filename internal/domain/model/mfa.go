@@ -0,0 +1,44 @@
+package model
+
+import (
+	"time"
+)
+
+// TOTPFactor represents the authenticator app enrolled by a user for
+// multi-factor authentication. It is pending until a first code confirms it.
+type TOTPFactor struct {
+	UserID uint `json:"user_id" gorm:"primaryKey;autoIncrement:false"`
+	// Secret is the base32 shared secret; it must be readable to verify codes
+	Secret      string     `json:"-" gorm:"size:64;not null"`
+	ConfirmedAt *time.Time `json:"confirmed_at,omitempty"`
+	// LastStep is the time step of the last accepted code, so a code cannot
+	// be used twice
+	LastStep  int64     `json:"-" gorm:"not null;default:0"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName returns the table name for the TOTPFactor model
+func (TOTPFactor) TableName() string {
+	return "totp_factors"
+}
+
+// Active reports whether the factor was confirmed and is required at login
+func (f *TOTPFactor) Active() bool {
+	return f.ConfirmedAt != nil
+}
+
+// RecoveryCode represents a single-use code that replaces a TOTP code when
+// the authenticator app is unavailable. Only a hash of the code is stored.
+type RecoveryCode struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"not null;index:idx_recovery_codes_user_id"`
+	CodeHash  string     `json:"-" gorm:"size:64;not null"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TableName returns the table name for the RecoveryCode model
+func (RecoveryCode) TableName() string {
+	return "recovery_codes"
+}
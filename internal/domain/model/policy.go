@@ -0,0 +1,117 @@
+package model
+
+import (
+	"time"
+)
+
+// AllTenants is the tenant of permission rules that apply in every tenant
+const AllTenants = "*"
+
+// Policy is a permission rule (p) allowing a subject, a role or user email, to
+// perform an action on an object in a tenant, or in every tenant
+type Policy struct {
+	Subject string `json:"subject"`
+	Tenant  string `json:"tenant"`
+	Object  string `json:"object"`
+	Action  string `json:"action"`
+}
+
+// RoleAssignment is a role rule (g) making a subject, usually a user email, a
+// member of a role within a tenant
+type RoleAssignment struct {
+	Subject string `json:"subject"`
+	Role    string `json:"role"`
+	Tenant  string `json:"tenant"`
+}
+
+// PolicyQuery is a request checked against the permission rules: a subject,
+// a user email or role, performing an action on an object in a tenant
+type PolicyQuery struct {
+	Subject string `json:"subject"`
+	Tenant  string `json:"tenant"`
+	Object  string `json:"object"`
+	Action  string `json:"action"`
+}
+
+// PolicyDecision explains the decision for a policy query
+type PolicyDecision struct {
+	PolicyQuery
+	Allowed bool `json:"allowed"`
+	// Rule is the permission rule that allowed the query
+	Rule *Policy `json:"rule,omitempty"`
+	// RoleChain leads from the subject through role assignments to the
+	// subject of Rule, starting with the subject itself
+	RoleChain []string `json:"role_chain,omitempty"`
+	// Roles are the roles the subject holds in the tenant, directly or through other roles
+	Roles []string `json:"roles"`
+}
+
+// PolicyCase is a policy query with the decision it is expected to get
+type PolicyCase struct {
+	PolicyQuery
+	Allow bool `json:"allow"`
+}
+
+// PolicyCaseResult is the outcome of a policy case
+type PolicyCaseResult struct {
+	PolicyDecision
+	Expected bool `json:"expected"`
+	Passed   bool `json:"passed"`
+}
+
+// PolicyDryRun is the outcome of evaluating policy cases against a proposed policy
+type PolicyDryRun struct {
+	Passed   bool               `json:"passed"`
+	Failures int                `json:"failures"`
+	Results  []PolicyCaseResult `json:"results"`
+}
+
+// Policy reload outcomes
+const (
+	PolicyReloadNone   = "none"
+	PolicyReloadOK     = "ok"
+	PolicyReloadFailed = "failed"
+)
+
+// PolicyReloadStatus describes the hot reloads of the RBAC model and policy
+type PolicyReloadStatus struct {
+	// Watching reports whether the model and policy files are being watched
+	Watching bool `json:"watching"`
+	// ReloadCount is the number of successful reloads since startup
+	ReloadCount int64 `json:"reload_count"`
+	// FailureCount is the number of failed reloads since startup
+	FailureCount int64 `json:"failure_count"`
+	// LastReloadAt is when the last reload was attempted
+	LastReloadAt *time.Time `json:"last_reload_at,omitempty"`
+	// LastStatus is the outcome of the last reload: none, ok or failed
+	LastStatus string `json:"last_status"`
+	// LastError is the error of the last reload if it failed
+	LastError string `json:"last_error,omitempty"`
+}
+
+// Route is an API route: an HTTP method and a route template such as /api/v1/todos/:id
+type Route struct {
+	Method string `json:"method"`
+	Route  string `json:"route"`
+}
+
+// RouteCheck is the outcome of checking whether the caller may call a route
+type RouteCheck struct {
+	Route
+	Allowed bool `json:"allowed"`
+}
+
+// CallerPermissions describes the caller of a request and the API routes it may call
+type CallerPermissions struct {
+	Email        string `json:"email"`
+	Tenant       string `json:"tenant"`
+	IsSuperAdmin bool   `json:"is_superadmin"`
+	// Roles are the roles the caller holds in its tenant, directly or through other roles
+	Roles []string `json:"roles"`
+	// Scopes restrict the caller to the named roles; empty when unrestricted
+	Scopes []string `json:"scopes,omitempty"`
+	// ImpersonatedBy is the superadmin acting as the caller, if any
+	ImpersonatedBy string `json:"impersonated_by,omitempty"`
+	// Permissions are the routes the caller may call
+	Permissions []Route `json:"permissions"`
+}
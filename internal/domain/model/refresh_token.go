@@ -0,0 +1,27 @@
+package model
+
+import (
+	"time"
+)
+
+// RefreshToken represents a persisted refresh token.
+// Only a hash of the opaque token is stored. Tokens that descend from the same
+// login share a FamilyID so the whole chain can be revoked at once, and carry
+// the AMR and scopes of that login into the access tokens they are exchanged for.
+type RefreshToken struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"not null;index:idx_refresh_tokens_user_id"`
+	FamilyID  string     `json:"family_id" gorm:"size:64;not null;index:idx_refresh_tokens_family_id"`
+	TokenHash string     `json:"-" gorm:"size:64;not null;uniqueIndex:idx_refresh_tokens_token_hash"`
+	AMR       []string   `json:"amr" gorm:"serializer:json;not null"`
+	Scopes    []string   `json:"scopes" gorm:"serializer:json;not null"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	RotatedAt *time.Time `json:"rotated_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TableName returns the table name for the RefreshToken model
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
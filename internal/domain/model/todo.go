@@ -7,6 +7,8 @@ import (
 // Todo represents a todo item
 type Todo struct {
 	ID        uint      `json:"id" gorm:"primaryKey"`
+	OwnerID   string    `json:"owner_id" gorm:"size:255;not null;index:idx_todos_owner_id"`
+	Tenant    string    `json:"tenant" gorm:"size:255;not null"`
 	Title     string    `json:"title" gorm:"not null"`
 	Completed bool      `json:"completed" gorm:"default:false"`
 	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
@@ -0,0 +1,20 @@
+package model
+
+import (
+	"time"
+)
+
+// User represents a registered user account
+type User struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	Email        string    `json:"email" gorm:"size:255;not null;uniqueIndex:idx_users_email"`
+	PasswordHash string    `json:"-" gorm:"size:255;not null"`
+	Tenant       string    `json:"tenant" gorm:"size:255;not null"`
+	CreatedAt    time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt    time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName returns the table name for the User model
+func (User) TableName() string {
+	return "users"
+}
@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+)
+
+// APIKeyRepository defines the interface for API key repository operations
+type APIKeyRepository interface {
+	// Create adds a new API key to the repository.
+	// It returns model.ErrConflict if a key with the same prefix exists.
+	Create(ctx context.Context, key *model.APIKey) error
+
+	// GetByID retrieves an API key by its ID.
+	// It returns model.ErrNotFound if no key has the ID.
+	GetByID(ctx context.Context, id uint) (*model.APIKey, error)
+
+	// GetByPrefix retrieves an API key by its public prefix.
+	// It returns model.ErrNotFound if no key has the prefix.
+	GetByPrefix(ctx context.Context, prefix string) (*model.APIKey, error)
+
+	// List returns every API key, ordered by ID
+	List(ctx context.Context) ([]model.APIKey, error)
+
+	// Update saves the name, scopes and expiry of an API key.
+	// It returns model.ErrNotFound if the key does not exist.
+	Update(ctx context.Context, key *model.APIKey) error
+
+	// Delete removes an API key by its ID.
+	// It returns model.ErrNotFound if the key does not exist.
+	Delete(ctx context.Context, id uint) error
+
+	// TouchLastUsed records when an API key was last used
+	TouchLastUsed(ctx context.Context, id uint, usedAt time.Time) error
+}
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+)
+
+// LoginAttemptStore counts failed login attempts per key, such as an account
+// or a client IP. Failures before the since time passed to its methods are
+// forgotten.
+type LoginAttemptStore interface {
+	// Get returns the failures of the key since the given time
+	Get(ctx context.Context, key string, since time.Time) (model.LoginAttempts, error)
+
+	// RecordFailure counts a failed attempt at now and returns the failures
+	// of the key since the given time, including it
+	RecordFailure(ctx context.Context, key string, now, since time.Time) (model.LoginAttempts, error)
+
+	// Reset forgets the failures of the key
+	Reset(ctx context.Context, key string) error
+}
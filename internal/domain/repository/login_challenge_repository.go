@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+)
+
+// LoginChallengeRepository defines the interface for login challenge repository operations
+type LoginChallengeRepository interface {
+	// Create adds a new login challenge to the repository
+	Create(ctx context.Context, challenge *model.LoginChallenge) error
+
+	// GetByID retrieves a login challenge by its ID.
+	// It returns model.ErrNotFound if no challenge has the ID.
+	GetByID(ctx context.Context, id uint) (*model.LoginChallenge, error)
+
+	// GetLatestActive retrieves the most recent unused, unexpired login
+	// challenge for the email. It returns model.ErrNotFound if there is none.
+	GetLatestActive(ctx context.Context, email string) (*model.LoginChallenge, error)
+
+	// RecordAttempt counts an attempt to enter the code of the challenge. It
+	// reports false, without counting, once maxAttempts have been made.
+	RecordAttempt(ctx context.Context, id uint, maxAttempts int) (bool, error)
+
+	// Consume marks the challenge as used. It returns model.ErrConflict if the
+	// challenge was already used.
+	Consume(ctx context.Context, id uint) error
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+)
+
+// MFARepository defines the interface for multi-factor authentication repository operations
+type MFARepository interface {
+	// GetTOTP retrieves the TOTP factor of the user, pending or active.
+	// It returns model.ErrNotFound if the user has none.
+	GetTOTP(ctx context.Context, userID uint) (*model.TOTPFactor, error)
+
+	// SaveTOTP stores a pending TOTP factor, replacing any factor the user has
+	SaveTOTP(ctx context.Context, factor *model.TOTPFactor) error
+
+	// ConfirmTOTP activates the pending TOTP factor of the user, recording the
+	// time step of the code that confirmed it, and replaces the user's
+	// recovery codes with the given hashes atomically
+	ConfirmTOTP(ctx context.Context, userID uint, step int64, recoveryCodeHashes []string) error
+
+	// UseTOTPStep records that a code for the time step was accepted. It
+	// reports false if a code for the step or a later one was already
+	// accepted, which means the code is being replayed.
+	UseTOTPStep(ctx context.Context, userID uint, step int64) (bool, error)
+
+	// DeleteTOTP removes the TOTP factor and recovery codes of the user
+	DeleteTOTP(ctx context.Context, userID uint) error
+
+	// ReplaceRecoveryCodes replaces the recovery codes of the user with the given hashes
+	ReplaceRecoveryCodes(ctx context.Context, userID uint, codeHashes []string) error
+
+	// UseRecoveryCode marks the unused recovery code of the user with the hash
+	// as used. It returns model.ErrNotFound if there is no such code.
+	UseRecoveryCode(ctx context.Context, userID uint, codeHash string) error
+
+	// CountRecoveryCodes returns how many unused recovery codes the user has
+	CountRecoveryCodes(ctx context.Context, userID uint) (int, error)
+}
@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+)
+
+// PolicyRepository manages the RBAC rules enforced on requests. Changes take
+// effect on the next request.
+type PolicyRepository interface {
+	// ListPolicies returns every permission rule
+	ListPolicies(ctx context.Context) ([]model.Policy, error)
+
+	// AddPolicy adds a permission rule. It returns model.ErrConflict if the rule
+	// already exists and a model.ValidationError if the RBAC model rejects it.
+	AddPolicy(ctx context.Context, policy model.Policy) error
+
+	// RemovePolicy removes a permission rule. It returns model.ErrNotFound if the rule does not exist.
+	RemovePolicy(ctx context.Context, policy model.Policy) error
+
+	// ListRoleAssignments returns every role assignment
+	ListRoleAssignments(ctx context.Context) ([]model.RoleAssignment, error)
+
+	// AddRoleAssignment adds a role assignment. It returns model.ErrConflict if
+	// the assignment already exists and a model.ValidationError if the RBAC model rejects it.
+	AddRoleAssignment(ctx context.Context, assignment model.RoleAssignment) error
+
+	// RemoveRoleAssignment removes a role assignment. It returns model.ErrNotFound if the assignment does not exist.
+	RemoveRoleAssignment(ctx context.Context, assignment model.RoleAssignment) error
+}
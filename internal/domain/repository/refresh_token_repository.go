@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+)
+
+// RefreshTokenRepository defines the interface for refresh token repository operations
+type RefreshTokenRepository interface {
+	// Create adds a new refresh token to the repository
+	Create(ctx context.Context, token *model.RefreshToken) error
+
+	// GetByHash retrieves a refresh token by the hash of its opaque value.
+	// It returns model.ErrNotFound if no token has the hash.
+	GetByHash(ctx context.Context, tokenHash string) (*model.RefreshToken, error)
+
+	// Rotate marks the current token as rotated and stores its replacement
+	// atomically. It returns model.ErrConflict if the current token was already
+	// rotated or revoked, which means it is being reused.
+	Rotate(ctx context.Context, current *model.RefreshToken, next *model.RefreshToken) error
+
+	// RevokeFamily revokes every unrevoked token in the family
+	RevokeFamily(ctx context.Context, familyID string) error
+
+	// RevokeUser revokes every unrevoked token of the user
+	RevokeUser(ctx context.Context, userID uint) error
+}
@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// RevocationStore records access tokens that must be rejected before they expire
+type RevocationStore interface {
+	// RevokeToken revokes the token with the given ID. The entry only needs to
+	// be kept until expiresAt, after which the token is rejected anyway.
+	RevokeToken(ctx context.Context, tokenID string, expiresAt time.Time) error
+
+	// RevokeSubject revokes every token of the subject issued at or before the given time
+	RevokeSubject(ctx context.Context, subject string, before time.Time) error
+
+	// IsRevoked reports whether a token with the given ID, subject and issue
+	// time has been revoked, either individually or along with its subject
+	IsRevoked(ctx context.Context, tokenID, subject string, issuedAt time.Time) (bool, error)
+}
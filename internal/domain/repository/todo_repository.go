@@ -2,15 +2,91 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
 )
 
-// TodoRepository defines the interface for todo repository operations
+// Fields todos can be sorted by
+const (
+	TodoSortID        = "id"
+	TodoSortTitle     = "title"
+	TodoSortCompleted = "completed"
+	TodoSortCreatedAt = "created_at"
+	TodoSortUpdatedAt = "updated_at"
+)
+
+// TodoSortFields lists the fields todos can be sorted by
+var TodoSortFields = []string{
+	TodoSortID,
+	TodoSortTitle,
+	TodoSortCompleted,
+	TodoSortCreatedAt,
+	TodoSortUpdatedAt,
+}
+
+// TodoSort describes the order of a todo listing.
+// Ties are always broken by ID in the same direction.
+type TodoSort struct {
+	Field string
+	Desc  bool
+}
+
+// TodoCursor identifies a position in a sorted todo listing
+type TodoCursor struct {
+	// Value is the sort field value of the last todo seen
+	Value interface{}
+	// ID is the ID of the last todo seen
+	ID uint
+}
+
+// TodoListOptions describes which todos to list and how to page through them
+type TodoListOptions struct {
+	OwnerID string
+
+	// Filters; nil or empty values are ignored
+	Completed     *bool
+	Title         string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	UpdatedAfter  *time.Time
+	UpdatedBefore *time.Time
+
+	Sort TodoSort
+
+	// Limit is the maximum number of todos to return
+	Limit int
+	// Offset skips the given number of todos; ignored when After is set
+	Offset int
+	// After restricts the listing to todos strictly after the cursor
+	After *TodoCursor
+}
+
+// TodoRepository defines the interface for todo repository operations.
+// Every operation except FindByID is scoped to a single owner; todos
+// belonging to other owners are never returned or modified.
 type TodoRepository interface {
-	// GetAll retrieves all todos from the repository
-	GetAll(ctx context.Context) ([]model.Todo, error)
+	// List retrieves a page of the owner's todos matching the options, along
+	// with the total number of matching todos regardless of pagination
+	List(ctx context.Context, opts TodoListOptions) ([]model.Todo, int64, error)
+
+	// GetByID retrieves a single todo belonging to the owner by its ID.
+	// It returns model.ErrNotFound if the todo does not exist.
+	GetByID(ctx context.Context, ownerID string, id uint) (*model.Todo, error)
+
+	// FindByID retrieves a single todo by its ID whatever its owner, so the
+	// caller can be authorized against its attributes.
+	// It returns model.ErrNotFound if the todo does not exist.
+	FindByID(ctx context.Context, id uint) (*model.Todo, error)
 
 	// Create adds a new todo to the repository
 	Create(ctx context.Context, todo *model.Todo) error
+
+	// Update persists all fields of an existing todo, matching on its ID and owner.
+	// It returns model.ErrNotFound if the todo does not exist.
+	Update(ctx context.Context, todo *model.Todo) error
+
+	// Delete removes a todo belonging to the owner by its ID.
+	// It returns model.ErrNotFound if the todo does not exist.
+	Delete(ctx context.Context, ownerID string, id uint) error
 }
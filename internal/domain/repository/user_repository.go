@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+)
+
+// UserRepository defines the interface for user repository operations
+type UserRepository interface {
+	// Create adds a new user to the repository.
+	// It returns model.ErrConflict if the email is already registered.
+	Create(ctx context.Context, user *model.User) error
+
+	// GetByEmail retrieves a user by their normalized email.
+	// It returns model.ErrNotFound if no user has the email.
+	GetByEmail(ctx context.Context, email string) (*model.User, error)
+
+	// GetByID retrieves a user by their ID.
+	// It returns model.ErrNotFound if the user does not exist.
+	GetByID(ctx context.Context, id uint) (*model.User, error)
+}
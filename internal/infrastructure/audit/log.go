@@ -0,0 +1,43 @@
+// Package audit records security-relevant events.
+package audit
+
+import (
+	"context"
+	"sort"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+)
+
+// Log writes audit events to the application log as entries with
+// "audit": true, so log pipelines can route them to long-term storage
+type Log struct {
+	logger *logger.Logger
+}
+
+// NewLog creates an audit log writing to the given logger
+func NewLog(logger *logger.Logger) *Log {
+	return &Log{
+		logger: logger.With("audit", true),
+	}
+}
+
+// Record writes the event
+func (l *Log) Record(ctx context.Context, event model.AuditEvent) {
+	fields := []interface{}{"action", event.Action}
+	if event.Actor != "" {
+		fields = append(fields, "actor", event.Actor)
+	}
+	if event.IP != "" {
+		fields = append(fields, "ip", event.IP)
+	}
+	keys := make([]string, 0, len(event.Details))
+	for key := range event.Details {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fields = append(fields, key, event.Details[key])
+	}
+	l.logger.Info("Audit event", fields...)
+}
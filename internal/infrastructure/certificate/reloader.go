@@ -0,0 +1,168 @@
+package certificate
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/config"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/filewatch"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+)
+
+// credentials are the certificate served and the CAs client certificates are
+// verified against, loaded together so they can be swapped atomically
+type credentials struct {
+	certificate *tls.Certificate
+	clientCAs   *x509.CertPool
+}
+
+// Reloader holds the server's TLS certificate and client CAs. Reload reads
+// the files again and swaps them in atomically; if loading fails the
+// previous ones stay in use. Watch reloads whenever the files change.
+type Reloader struct {
+	config *config.TLSConfig
+	active atomic.Pointer[credentials]
+	logger *logger.Logger
+
+	mu      sync.Mutex
+	watcher *filewatch.Watcher
+}
+
+// NewReloader loads the certificate, key and client CAs configured in cfg
+func NewReloader(cfg *config.TLSConfig, logger *logger.Logger) (*Reloader, error) {
+	if cfg.CertFile == "" || cfg.KeyFile == "" {
+		return nil, errors.New("TLS requires a certificate and key file")
+	}
+	switch cfg.ClientAuth {
+	case "", config.TLSClientAuthNone:
+	case config.TLSClientAuthOptional, config.TLSClientAuthRequire:
+		if cfg.ClientCAFile == "" {
+			return nil, fmt.Errorf("TLS client auth %q requires a client CA file", cfg.ClientAuth)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported TLS client auth %q", cfg.ClientAuth)
+	}
+
+	r := &Reloader{
+		config: cfg,
+		logger: logger,
+	}
+	loaded, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+	r.active.Store(loaded)
+	return r, nil
+}
+
+// TLSConfig returns the server TLS configuration. Every handshake uses the
+// certificate and client CAs active at that moment.
+func (r *Reloader) TLSConfig() *tls.Config {
+	clientAuth := r.clientAuth()
+	// The configuration returned for a handshake replaces the server's, so it
+	// must offer the protocols net/http serves itself, or ALPN never selects
+	// HTTP/2
+	nextProtos := []string{"h2", "http/1.1"}
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: nextProtos,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			active := r.active.Load()
+			return &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				NextProtos:   nextProtos,
+				Certificates: []tls.Certificate{*active.certificate},
+				ClientAuth:   clientAuth,
+				ClientCAs:    active.clientCAs,
+			}, nil
+		},
+	}
+}
+
+// Reload loads the files again and swaps in the new certificate and client
+// CAs. On failure the active ones are kept and the error is returned.
+func (r *Reloader) Reload() error {
+	loaded, err := r.load()
+	if err != nil {
+		r.logger.Error("Failed to reload TLS certificate, keeping the active one", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return err
+	}
+
+	r.active.Store(loaded)
+	r.logger.Info("Reloaded TLS certificate", map[string]interface{}{
+		"subject":   loaded.certificate.Leaf.Subject.String(),
+		"not_after": loaded.certificate.Leaf.NotAfter,
+	})
+	return nil
+}
+
+// Watch reloads whenever the certificate, key or client CA file changes,
+// until Close is called.
+func (r *Reloader) Watch() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.watcher != nil {
+		return nil
+	}
+
+	paths := []string{r.config.CertFile, r.config.KeyFile, r.config.ClientCAFile}
+	watcher, err := filewatch.New(paths, func() { _ = r.Reload() }, r.logger)
+	if err != nil {
+		return err
+	}
+	r.watcher = watcher
+	return nil
+}
+
+// Close stops watching the files
+func (r *Reloader) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.watcher == nil {
+		return nil
+	}
+	err := r.watcher.Close()
+	r.watcher = nil
+	return err
+}
+
+// load reads the certificate, key and client CAs
+func (r *Reloader) load() (*credentials, error) {
+	certificate, err := tls.LoadX509KeyPair(r.config.CertFile, r.config.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+
+	loaded := &credentials{certificate: &certificate}
+	if r.config.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.config.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client CA file: %w", err)
+		}
+		loaded.clientCAs = x509.NewCertPool()
+		if !loaded.clientCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in client CA file %s", r.config.ClientCAFile)
+		}
+	}
+	return loaded, nil
+}
+
+// clientAuth returns how client certificates are requested and verified
+func (r *Reloader) clientAuth() tls.ClientAuthType {
+	switch r.config.ClientAuth {
+	case config.TLSClientAuthOptional:
+		return tls.VerifyClientCertIfGiven
+	case config.TLSClientAuthRequire:
+		return tls.RequireAndVerifyClientCert
+	default:
+		return tls.NoClientCert
+	}
+}
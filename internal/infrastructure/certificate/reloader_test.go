@@ -0,0 +1,247 @@
+package certificate
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/config"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCA issues certificates for the tests
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns the PEM certificate and key of a new leaf certificate
+func (ca *testCA) issue(t *testing.T, serial int64, commonName string, dnsNames []string, usage x509.ExtKeyUsage) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     dnsNames,
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// writeFile writes a file by renaming a temporary one into place, as
+// certificate managers do
+func writeFile(t *testing.T, path string, data []byte) {
+	tmp := path + ".tmp"
+	require.NoError(t, os.WriteFile(tmp, data, 0o600))
+	require.NoError(t, os.Rename(tmp, path))
+}
+
+// serve runs an HTTPS server with the reloader's TLS config, as the server
+// binary does, that responds with the service named by the verified client
+// certificate, if any
+func serve(t *testing.T, reloader *Reloader) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if len(r.TLS.VerifiedChains) > 0 {
+				w.Write([]byte(r.TLS.VerifiedChains[0][0].Subject.CommonName))
+			}
+		}),
+		TLSConfig: reloader.TLSConfig(),
+	}
+	go server.ServeTLS(listener, "", "")
+	t.Cleanup(func() { server.Close() })
+	return "https://" + listener.Addr().String()
+}
+
+// client returns an HTTP client trusting the CA and presenting the given
+// client certificates
+func client(ca *testCA, certificates ...tls.Certificate) *http.Client {
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	return &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   &tls.Config{RootCAs: roots, Certificates: certificates},
+		DisableKeepAlives: true,
+	}}
+}
+
+// servedSerial returns the serial number of the certificate the server presents
+func servedSerial(t *testing.T, httpClient *http.Client, url string) int64 {
+	resp, err := httpClient.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	return resp.TLS.PeerCertificates[0].SerialNumber.Int64()
+}
+
+func TestReloader(t *testing.T) {
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+	ca := newTestCA(t)
+	dir := t.TempDir()
+	cfg := &config.TLSConfig{
+		Enabled:  true,
+		CertFile: filepath.Join(dir, "tls.crt"),
+		KeyFile:  filepath.Join(dir, "tls.key"),
+	}
+	cert, key := ca.issue(t, 10, "localhost", []string{"localhost"}, x509.ExtKeyUsageServerAuth)
+	writeFile(t, cfg.CertFile, cert)
+	writeFile(t, cfg.KeyFile, key)
+
+	reloader, err := NewReloader(cfg, log)
+	require.NoError(t, err)
+	url := serve(t, reloader)
+	httpClient := client(ca)
+
+	assert.Equal(t, int64(10), servedSerial(t, httpClient, url))
+
+	t.Run("HTTP/2 is negotiated", func(t *testing.T) {
+		httpClient := client(ca)
+		httpClient.Transport.(*http.Transport).ForceAttemptHTTP2 = true
+
+		resp, err := httpClient.Get(url)
+
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, 2, resp.ProtoMajor)
+	})
+
+	t.Run("Reload swaps in the new certificate", func(t *testing.T) {
+		cert, key := ca.issue(t, 11, "localhost", []string{"localhost"}, x509.ExtKeyUsageServerAuth)
+		writeFile(t, cfg.CertFile, cert)
+		writeFile(t, cfg.KeyFile, key)
+
+		require.NoError(t, reloader.Reload())
+		assert.Equal(t, int64(11), servedSerial(t, httpClient, url))
+	})
+
+	t.Run("A failed reload keeps the active certificate", func(t *testing.T) {
+		writeFile(t, cfg.KeyFile, []byte("not a key"))
+
+		assert.Error(t, reloader.Reload())
+		assert.Equal(t, int64(11), servedSerial(t, httpClient, url))
+	})
+
+	t.Run("Watch reloads when the files change", func(t *testing.T) {
+		require.NoError(t, reloader.Watch())
+		defer reloader.Close()
+
+		cert, key := ca.issue(t, 12, "localhost", []string{"localhost"}, x509.ExtKeyUsageServerAuth)
+		writeFile(t, cfg.CertFile, cert)
+		writeFile(t, cfg.KeyFile, key)
+
+		assert.Eventually(t, func() bool {
+			return servedSerial(t, httpClient, url) == 12
+		}, 5*time.Second, 50*time.Millisecond)
+	})
+}
+
+func TestReloader_ClientCertificates(t *testing.T) {
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+	ca := newTestCA(t)
+	dir := t.TempDir()
+	cfg := &config.TLSConfig{
+		Enabled:      true,
+		CertFile:     filepath.Join(dir, "tls.crt"),
+		KeyFile:      filepath.Join(dir, "tls.key"),
+		ClientAuth:   config.TLSClientAuthRequire,
+		ClientCAFile: filepath.Join(dir, "ca.crt"),
+	}
+	cert, key := ca.issue(t, 10, "localhost", []string{"localhost"}, x509.ExtKeyUsageServerAuth)
+	writeFile(t, cfg.CertFile, cert)
+	writeFile(t, cfg.KeyFile, key)
+	writeFile(t, cfg.ClientCAFile, ca.pem)
+
+	reloader, err := NewReloader(cfg, log)
+	require.NoError(t, err)
+	url := serve(t, reloader)
+
+	t.Run("Verified client certificates are accepted", func(t *testing.T) {
+		clientCert, clientKey := ca.issue(t, 20, "billing", nil, x509.ExtKeyUsageClientAuth)
+		certificate, err := tls.X509KeyPair(clientCert, clientKey)
+		require.NoError(t, err)
+
+		resp, err := client(ca, certificate).Get(url)
+
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body := make([]byte, 16)
+		n, _ := resp.Body.Read(body)
+		assert.Equal(t, "billing", string(body[:n]))
+	})
+
+	t.Run("Connections without a client certificate are refused", func(t *testing.T) {
+		_, err := client(ca).Get(url)
+		assert.Error(t, err)
+	})
+
+	t.Run("Certificates from other CAs are refused", func(t *testing.T) {
+		clientCert, clientKey := newTestCA(t).issue(t, 21, "billing", nil, x509.ExtKeyUsageClientAuth)
+		certificate, err := tls.X509KeyPair(clientCert, clientKey)
+		require.NoError(t, err)
+
+		_, err = client(ca, certificate).Get(url)
+		assert.Error(t, err)
+	})
+}
+
+func TestNewReloader_InvalidConfig(t *testing.T) {
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+
+	tests := []struct {
+		name string
+		cfg  config.TLSConfig
+	}{
+		{name: "Missing key", cfg: config.TLSConfig{CertFile: "tls.crt"}},
+		{name: "Client auth without CA", cfg: config.TLSConfig{CertFile: "tls.crt", KeyFile: "tls.key", ClientAuth: config.TLSClientAuthRequire}},
+		{name: "Unknown client auth", cfg: config.TLSConfig{CertFile: "tls.crt", KeyFile: "tls.key", ClientAuth: "sometimes"}},
+		{name: "Missing files", cfg: config.TLSConfig{CertFile: "missing.crt", KeyFile: "missing.key"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewReloader(&tt.cfg, log)
+			assert.Error(t, err)
+		})
+	}
+}
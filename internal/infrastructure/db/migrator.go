@@ -0,0 +1,348 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+)
+
+// migrationFilePattern matches migration file names such as 000001_init_schema.up.sql
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Migration represents a single versioned schema migration
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus describes whether a migration has been applied
+type MigrationStatus struct {
+	Version uint
+	Name    string
+	Applied bool
+}
+
+// Migrator applies versioned SQL migrations. The applied version is kept in a
+// schema_migrations table compatible with golang-migrate, and every operation
+// holds a Postgres advisory lock so concurrent replicas do not race.
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+	lockID     int64
+	logger     *logger.Logger
+}
+
+// NewMigrator creates a migrator for the database using the migrations in source
+func NewMigrator(database *Database, source fs.FS) (*Migrator, error) {
+	sqlDB, err := database.DB.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database connection: %w", err)
+	}
+
+	migrations, err := LoadMigrations(source)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		db:         sqlDB,
+		migrations: migrations,
+		lockID:     int64(crc32.ChecksumIEEE([]byte(database.Config.Name + ":schema_migrations"))),
+		logger:     database.Logger,
+	}, nil
+}
+
+// LoadMigrations reads and pairs the up and down migration files in source, ordered by version
+func LoadMigrations(source fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(source, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[uint]*Migration)
+	for _, entry := range entries {
+		matches := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || matches == nil {
+			continue
+		}
+
+		version, err := strconv.ParseUint(matches[1], 10, 0)
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("invalid migration version in %s", entry.Name())
+		}
+
+		contents, err := fs.ReadFile(source, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		migration, ok := byVersion[uint(version)]
+		if !ok {
+			migration = &Migration{Version: uint(version), Name: matches[2]}
+			byVersion[uint(version)] = migration
+		} else if migration.Name != matches[2] {
+			return nil, fmt.Errorf("conflicting migrations for version %d: %s and %s", version, migration.Name, matches[2])
+		}
+
+		if matches[3] == "up" {
+			migration.Up = string(contents)
+		} else {
+			migration.Down = string(contents)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Up applies all pending migrations
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		current, err := m.cleanVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		applied := 0
+		for _, migration := range m.migrations {
+			if migration.Version <= current {
+				continue
+			}
+			if err := m.apply(ctx, conn, migration.Version, migration.Name, migration.Up, migration.Version); err != nil {
+				return err
+			}
+			applied++
+		}
+
+		m.logger.Info("Database migrations applied", map[string]interface{}{
+			"applied": applied,
+		})
+		return nil
+	})
+}
+
+// Down reverts the most recently applied n migrations
+func (m *Migrator) Down(ctx context.Context, n int) error {
+	if n <= 0 {
+		return errors.New("number of migrations to revert must be positive")
+	}
+
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		current, err := m.cleanVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && n > 0; i-- {
+			migration := m.migrations[i]
+			if migration.Version > current {
+				continue
+			}
+			if migration.Down == "" {
+				return fmt.Errorf("migration %d_%s has no down file", migration.Version, migration.Name)
+			}
+
+			var previous uint
+			if i > 0 {
+				previous = m.migrations[i-1].Version
+			}
+			if err := m.apply(ctx, conn, migration.Version, migration.Name, migration.Down, previous); err != nil {
+				return err
+			}
+			n--
+		}
+		return nil
+	})
+}
+
+// Status returns the current schema version, whether it is dirty and the state of every migration
+func (m *Migrator) Status(ctx context.Context) (uint, bool, []MigrationStatus, error) {
+	var (
+		current  uint
+		dirty    bool
+		statuses []MigrationStatus
+	)
+
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		var err error
+		current, dirty, err = m.version(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		statuses = make([]MigrationStatus, 0, len(m.migrations))
+		for _, migration := range m.migrations {
+			statuses = append(statuses, MigrationStatus{
+				Version: migration.Version,
+				Name:    migration.Name,
+				Applied: migration.Version <= current,
+			})
+		}
+		return nil
+	})
+
+	return current, dirty, statuses, err
+}
+
+// Force records the given version as applied and clears the dirty flag without
+// running any migration. Version 0 marks the schema as having no migrations applied.
+func (m *Migrator) Force(ctx context.Context, version uint) error {
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		if version != 0 && !m.known(version) {
+			return fmt.Errorf("unknown migration version %d", version)
+		}
+
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		if err := setVersion(ctx, tx, version); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit forced version: %w", err)
+		}
+
+		m.logger.Warn("Database migration version forced", map[string]interface{}{
+			"version": version,
+		})
+		return nil
+	})
+}
+
+// apply runs a migration script and records the resulting version in one transaction
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, version uint, name, script string, resulting uint) error {
+	m.logger.Info("Applying database migration", map[string]interface{}{
+		"version": version,
+		"name":    name,
+	})
+
+	// The script and the version bump commit together, so a failed migration
+	// leaves both the schema and the recorded version untouched
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("migration %d_%s failed: %w", version, name, err)
+	}
+	if err := setVersion(ctx, tx, resulting); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration %d_%s: %w", version, name, err)
+	}
+	return nil
+}
+
+// withLock runs fn on a dedicated connection holding the migration advisory lock
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get database connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", m.lockID); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer func() {
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", m.lockID); err != nil {
+			m.logger.Error("Failed to release migration lock", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+	}()
+
+	if _, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT NOT NULL PRIMARY KEY,
+		dirty BOOLEAN NOT NULL
+	)`); err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	return fn(conn)
+}
+
+// cleanVersion returns the current version, failing if a previous migration left it dirty
+func (m *Migrator) cleanVersion(ctx context.Context, conn *sql.Conn) (uint, error) {
+	current, dirty, err := m.version(ctx, conn)
+	if err != nil {
+		return 0, err
+	}
+	if dirty {
+		return 0, fmt.Errorf("database schema is dirty at version %d; fix it manually and run migrate force", current)
+	}
+	return current, nil
+}
+
+// version reads the recorded schema version
+func (m *Migrator) version(ctx context.Context, conn *sql.Conn) (uint, bool, error) {
+	var (
+		version int64
+		dirty   bool
+	)
+	err := conn.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if version < 0 {
+		return 0, dirty, nil
+	}
+	return uint(version), dirty, nil
+}
+
+// known reports whether a migration with the given version exists
+func (m *Migrator) known(version uint) bool {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+// execer is satisfied by both *sql.Conn and *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// setVersion replaces the recorded schema version with a clean one
+func setVersion(ctx context.Context, db execer, version uint) error {
+	if _, err := db.ExecContext(ctx, "DELETE FROM schema_migrations"); err != nil {
+		return fmt.Errorf("failed to clear schema version: %w", err)
+	}
+	if version == 0 {
+		return nil
+	}
+	if _, err := db.ExecContext(ctx, "INSERT INTO schema_migrations (version, dirty) VALUES ($1, FALSE)", int64(version)); err != nil {
+		return fmt.Errorf("failed to record schema version: %w", err)
+	}
+	return nil
+}
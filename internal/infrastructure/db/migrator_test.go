@@ -0,0 +1,63 @@
+package db
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/migrations"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	t.Run("Pairs and orders migrations by version", func(t *testing.T) {
+		source := fstest.MapFS{
+			"000002_add_owner.up.sql":   {Data: []byte("ALTER TABLE todos ADD COLUMN owner_id TEXT;")},
+			"000002_add_owner.down.sql": {Data: []byte("ALTER TABLE todos DROP COLUMN owner_id;")},
+			"000001_init.up.sql":        {Data: []byte("CREATE TABLE todos (id SERIAL);")},
+			"000001_init.down.sql":      {Data: []byte("DROP TABLE todos;")},
+			"README.md":                 {Data: []byte("not a migration")},
+		}
+
+		loaded, err := LoadMigrations(source)
+
+		assert.NoError(t, err)
+		assert.Len(t, loaded, 2)
+		assert.Equal(t, uint(1), loaded[0].Version)
+		assert.Equal(t, "init", loaded[0].Name)
+		assert.Equal(t, "DROP TABLE todos;", loaded[0].Down)
+		assert.Equal(t, uint(2), loaded[1].Version)
+		assert.Equal(t, "add_owner", loaded[1].Name)
+	})
+
+	t.Run("Rejects migrations without an up file", func(t *testing.T) {
+		source := fstest.MapFS{
+			"000001_init.down.sql": {Data: []byte("DROP TABLE todos;")},
+		}
+
+		_, err := LoadMigrations(source)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("Rejects conflicting names for a version", func(t *testing.T) {
+		source := fstest.MapFS{
+			"000001_init.up.sql":  {Data: []byte("CREATE TABLE todos (id SERIAL);")},
+			"000001_other.up.sql": {Data: []byte("CREATE TABLE other (id SERIAL);")},
+		}
+
+		_, err := LoadMigrations(source)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("Embedded migrations are valid", func(t *testing.T) {
+		loaded, err := LoadMigrations(migrations.FS)
+
+		assert.NoError(t, err)
+		assert.NotEmpty(t, loaded)
+		for i, migration := range loaded {
+			assert.Equal(t, uint(i+1), migration.Version, "migration versions should be contiguous")
+			assert.NotEmpty(t, migration.Down, "migration %d should have a down file", migration.Version)
+		}
+	})
+}
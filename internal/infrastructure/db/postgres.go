@@ -5,7 +5,6 @@ import (
 	"time"
 
 	"github.com/bgaurav7/gin-microservice-boilerplate/config"
-	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
 	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -36,6 +35,8 @@ func NewDatabase(cfg *config.DatabaseConfig, logger *logger.Logger) (*Database,
 		NamingStrategy: schema.NamingStrategy{
 			SingularTable: true,
 		},
+		// Surface constraint violations as gorm.ErrDuplicatedKey and friends
+		TranslateError: true,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
@@ -58,11 +59,6 @@ func NewDatabase(cfg *config.DatabaseConfig, logger *logger.Logger) (*Database,
 		Logger: logger,
 	}
 
-	// Auto-migrate models
-	if err := database.AutoMigrate(); err != nil {
-		return nil, fmt.Errorf("failed to auto-migrate models: %w", err)
-	}
-
 	logger.Info("Connected to database", map[string]interface{}{
 		"host": cfg.Host,
 		"port": cfg.Port,
@@ -72,11 +68,6 @@ func NewDatabase(cfg *config.DatabaseConfig, logger *logger.Logger) (*Database,
 	return database, nil
 }
 
-// AutoMigrate automatically migrates the database schema
-func (d *Database) AutoMigrate() error {
-	return d.DB.AutoMigrate(&model.Todo{})
-}
-
 // Ping checks if the database connection is alive
 func (d *Database) Ping() error {
 	sqlDB, err := d.DB.DB()
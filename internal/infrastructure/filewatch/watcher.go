@@ -0,0 +1,127 @@
+// Package filewatch reloads files, such as policies and certificates, when
+// they change on disk.
+package filewatch
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDelay is how long file events are coalesced before reloading, so files
+// written in several steps, or one after the other, are only loaded once they
+// are complete
+const reloadDelay = 250 * time.Millisecond
+
+// kubernetesDataDir is the symlink Kubernetes swaps when a mounted ConfigMap
+// or Secret changes
+const kubernetesDataDir = "..data"
+
+// Watcher calls its reload function, after a short delay, whenever one of the
+// watched files changes. Parent directories are watched so files replaced by
+// renaming, as editors, cert-manager and Kubernetes volume updates do, are
+// picked up.
+type Watcher struct {
+	watcher *fsnotify.Watcher
+	reload  func()
+	logger  *logger.Logger
+
+	// mu guards the pending reload, so Close can cancel it
+	mu     sync.Mutex
+	timer  *time.Timer
+	closed bool
+}
+
+// New starts watching the files until Close is called. Empty paths are ignored.
+func New(paths []string, reload func(), logger *logger.Logger) (*Watcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file watcher: %w", err)
+	}
+
+	files := make(map[string]bool, len(paths))
+	dirs := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			watcher.Close()
+			return nil, err
+		}
+		files[abs] = true
+		dirs[filepath.Dir(abs)] = true
+	}
+	for dir := range dirs {
+		if err := watcher.Add(dir); err != nil {
+			watcher.Close()
+			return nil, fmt.Errorf("failed to watch %s: %w", dir, err)
+		}
+	}
+
+	w := &Watcher{
+		watcher: watcher,
+		reload:  reload,
+		logger:  logger,
+	}
+	go w.watch(files)
+	return w, nil
+}
+
+// watch reloads, after a short delay, when an event touches a watched file
+func (w *Watcher) watch(files map[string]bool) {
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			if !files[filepath.Clean(event.Name)] && filepath.Base(event.Name) != kubernetesDataDir {
+				continue
+			}
+
+			w.schedule()
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			w.logger.Error("File watcher error", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+	}
+}
+
+// schedule reloads after reloadDelay, postponing a pending reload
+func (w *Watcher) schedule() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return
+	}
+	if w.timer == nil {
+		w.timer = time.AfterFunc(reloadDelay, w.reload)
+	} else {
+		w.timer.Reset(reloadDelay)
+	}
+}
+
+// Close stops watching the files and cancels a pending reload. A reload
+// already running is not waited for.
+func (w *Watcher) Close() error {
+	w.mu.Lock()
+	w.closed = true
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	w.mu.Unlock()
+	return w.watcher.Close()
+}
@@ -0,0 +1,71 @@
+package filewatch
+
+import (
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatcher(t *testing.T) {
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+	dir := t.TempDir()
+	watched := filepath.Join(dir, "watched.txt")
+	other := filepath.Join(dir, "other.txt")
+	require.NoError(t, os.WriteFile(watched, []byte("v1"), 0o600))
+
+	var reloads atomic.Int32
+	watcher, err := New([]string{watched, ""}, func() { reloads.Add(1) }, log)
+	require.NoError(t, err)
+	defer watcher.Close()
+
+	t.Run("Changes to other files are ignored", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(other, []byte("v1"), 0o600))
+
+		time.Sleep(2 * reloadDelay)
+		assert.Equal(t, int32(0), reloads.Load())
+	})
+
+	t.Run("Writes in quick succession reload once", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(watched, []byte("v2"), 0o600))
+		require.NoError(t, os.WriteFile(watched+".tmp", []byte("v3"), 0o600))
+		require.NoError(t, os.Rename(watched+".tmp", watched))
+
+		assert.Eventually(t, func() bool { return reloads.Load() == 1 }, 5*time.Second, 20*time.Millisecond)
+		time.Sleep(2 * reloadDelay)
+		assert.Equal(t, int32(1), reloads.Load())
+	})
+}
+
+func TestWatcher_CloseCancelsPendingReload(t *testing.T) {
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+	watched := filepath.Join(t.TempDir(), "watched.txt")
+	require.NoError(t, os.WriteFile(watched, []byte("v1"), 0o600))
+
+	var reloads atomic.Int32
+	watcher, err := New([]string{watched}, func() { reloads.Add(1) }, log)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(watched, []byte("v2"), 0o600))
+	require.Eventually(t, func() bool {
+		watcher.mu.Lock()
+		defer watcher.mu.Unlock()
+		return watcher.timer != nil
+	}, 5*time.Second, 5*time.Millisecond)
+	require.NoError(t, watcher.Close())
+
+	time.Sleep(2 * reloadDelay)
+	assert.Equal(t, int32(0), reloads.Load())
+}
+
+func TestNew_MissingDirectory(t *testing.T) {
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+
+	_, err := New([]string{filepath.Join(t.TempDir(), "missing", "file.txt")}, func() {}, log)
+	assert.Error(t, err)
+}
@@ -0,0 +1,201 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Supported signing algorithms
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+	AlgorithmES256 = "ES256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+// verificationKey is a key tokens can be verified with
+type verificationKey struct {
+	id     string
+	method jwt.SigningMethod
+	// key is the public key, or the secret for HMAC
+	key interface{}
+}
+
+// signingKey is the key new tokens are signed with
+type signingKey struct {
+	verificationKey
+	// private is the private key, or the secret for HMAC
+	private interface{}
+}
+
+// loadSigningKey loads the key configured for signing new tokens
+func loadSigningKey(cfg *config.AuthConfig) (*signingKey, error) {
+	algorithm := cfg.JWTAlgorithm
+	if algorithm == "" {
+		algorithm = AlgorithmHS256
+	}
+
+	if algorithm == AlgorithmHS256 {
+		if cfg.JWTSecret == "" {
+			return nil, errors.New("auth.jwt_secret is required for HS256")
+		}
+		secret := []byte(cfg.JWTSecret)
+		return &signingKey{
+			verificationKey: verificationKey{id: cfg.JWTSigningKey.ID, method: jwt.SigningMethodHS256, key: secret},
+			private:         secret,
+		}, nil
+	}
+
+	if cfg.JWTSigningKey.PrivateKeyPath == "" {
+		return nil, fmt.Errorf("auth.jwt_signing_key.private_key_path is required for %s", algorithm)
+	}
+	data, err := os.ReadFile(cfg.JWTSigningKey.PrivateKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key: %w", err)
+	}
+	private, err := parsePrivateKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing key: %w", err)
+	}
+
+	public := private.Public()
+	method, err := methodForKey(public)
+	if err != nil {
+		return nil, err
+	}
+	if method.Alg() != algorithm {
+		return nil, fmt.Errorf("signing key is a %s key but auth.jwt_algorithm is %s", method.Alg(), algorithm)
+	}
+
+	id, err := keyID(cfg.JWTSigningKey.ID, public)
+	if err != nil {
+		return nil, err
+	}
+
+	return &signingKey{
+		verificationKey: verificationKey{id: id, method: method, key: public},
+		private:         private,
+	}, nil
+}
+
+// loadVerificationKeys loads the additional public keys tokens may be verified with
+func loadVerificationKeys(cfg *config.AuthConfig) ([]*verificationKey, error) {
+	keys := make([]*verificationKey, 0, len(cfg.JWTVerificationKeys))
+	for i, keyConfig := range cfg.JWTVerificationKeys {
+		if keyConfig.PublicKeyPath == "" {
+			return nil, fmt.Errorf("auth.jwt_verification_keys[%d].public_key_path is required", i)
+		}
+		data, err := os.ReadFile(keyConfig.PublicKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read verification key %d: %w", i, err)
+		}
+		public, err := parsePublicKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse verification key %d: %w", i, err)
+		}
+		method, err := methodForKey(public)
+		if err != nil {
+			return nil, err
+		}
+		id, err := keyID(keyConfig.ID, public)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, &verificationKey{id: id, method: method, key: public})
+	}
+	return keys, nil
+}
+
+// parsePrivateKey parses a PEM-encoded PKCS#8, PKCS#1 or SEC 1 private key
+func parsePrivateKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var (
+		key interface{}
+		err error
+	)
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	return signer, nil
+}
+
+// parsePublicKey parses a PEM-encoded PKIX or PKCS#1 public key, or the public key of a certificate
+func parsePublicKey(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return cert.PublicKey, nil
+	default:
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	}
+}
+
+// methodForKey returns the signing method matching the type of a public key
+func methodForKey(public crypto.PublicKey) (jwt.SigningMethod, error) {
+	switch key := public.(type) {
+	case *rsa.PublicKey:
+		return jwt.SigningMethodRS256, nil
+	case *ecdsa.PublicKey:
+		switch key.Curve {
+		case elliptic.P256():
+			return jwt.SigningMethodES256, nil
+		case elliptic.P384():
+			return jwt.SigningMethodES384, nil
+		case elliptic.P521():
+			return jwt.SigningMethodES512, nil
+		default:
+			return nil, fmt.Errorf("unsupported elliptic curve %s", key.Curve.Params().Name)
+		}
+	case ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", public)
+	}
+}
+
+// keyID returns the configured key ID, or the JWK thumbprint of the public key
+func keyID(configured string, public crypto.PublicKey) (string, error) {
+	if id := strings.TrimSpace(configured); id != "" {
+		return id, nil
+	}
+	return thumbprint(public)
+}
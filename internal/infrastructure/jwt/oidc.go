@@ -0,0 +1,343 @@
+package jwt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// defaultJWKSRefresh is how long fetched provider keys are used before refetching
+	defaultJWKSRefresh = time.Hour
+
+	// minJWKSRefetch limits how often an unknown kid can trigger a refetch
+	minJWKSRefetch = time.Minute
+)
+
+// oidcAlgorithms are the algorithms accepted from an external provider.
+// HMAC is never accepted since the provider does not share a secret with us.
+var oidcAlgorithms = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// Identity is the caller identity carried by a verified token
+type Identity struct {
+	Subject   string
+	Email     string
+	Roles     []string
+	Tenant    string
+	AMR       []string
+	Scopes    []string
+	Actor     string
+	TokenID   string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+
+	// External is set for identities asserted by an external OIDC provider
+	// rather than issued by this service
+	External bool
+}
+
+// Identity returns the caller identity carried by locally issued claims
+func (c *Claims) Identity() *Identity {
+	identity := &Identity{
+		Subject: c.Subject(),
+		Email:   c.Email,
+		Tenant:  c.Tenant,
+		AMR:     c.AMR,
+		TokenID: c.ID,
+	}
+	if c.Scope != "" {
+		identity.Scopes = strings.Fields(c.Scope)
+	}
+	if c.Act != nil {
+		identity.Actor = c.Act.Subject
+	}
+	if c.IssuedAt != nil {
+		identity.IssuedAt = c.IssuedAt.Time
+	}
+	if c.ExpiresAt != nil {
+		identity.ExpiresAt = c.ExpiresAt.Time
+	}
+	return identity
+}
+
+// UnverifiedIssuer returns the iss claim of a token without verifying it, so
+// the token can be routed to the verifier for its issuer
+func UnverifiedIssuer(tokenString string) string {
+	var claims jwt.RegisteredClaims
+	if _, _, err := jwt.NewParser().ParseUnverified(tokenString, &claims); err != nil {
+		return ""
+	}
+	return claims.Issuer
+}
+
+// providerMetadata is the subset of the OpenID Provider Metadata we use
+type providerMetadata struct {
+	Issuer  string `json:"issuer"`
+	JWKSURI string `json:"jwks_uri"`
+}
+
+// OIDCVerifier verifies tokens issued by an external OpenID Connect provider.
+// The provider's signing keys are discovered from its metadata and cached.
+type OIDCVerifier struct {
+	config *config.OIDCConfig
+	client *http.Client
+
+	mu          sync.Mutex
+	jwksURI     string
+	keys        map[string]*verificationKey
+	fetchedAt   time.Time
+	attemptedAt time.Time
+	refreshErr  error
+}
+
+// NewOIDCVerifier creates a new verifier for the configured provider.
+// Discovery happens on first use so the provider may be unavailable at startup.
+func NewOIDCVerifier(cfg *config.OIDCConfig, client *http.Client) (*OIDCVerifier, error) {
+	if cfg.IssuerURL == "" {
+		return nil, errors.New("auth.oidc.issuer_url is required")
+	}
+	if cfg.Audience == "" {
+		return nil, errors.New("auth.oidc.audience is required")
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	return &OIDCVerifier{
+		config: cfg,
+		client: client,
+	}, nil
+}
+
+// Issuer returns the issuer identifier tokens from the provider carry
+func (v *OIDCVerifier) Issuer() string {
+	return v.config.IssuerURL
+}
+
+// Verify validates a token issued by the provider and maps its claims into an identity
+func (v *OIDCVerifier) Verify(ctx context.Context, tokenString string) (*Identity, error) {
+	parser := jwt.NewParser(
+		jwt.WithValidMethods(oidcAlgorithms),
+		jwt.WithIssuer(v.config.IssuerURL),
+		jwt.WithAudience(v.config.Audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Duration(v.config.ClockSkewSeconds)*time.Second),
+	)
+
+	claims := jwt.MapClaims{}
+	token, err := parser.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, err := v.key(ctx, kid)
+		if err != nil {
+			return nil, err
+		}
+		if token.Method.Alg() != key.method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return key.key, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse token: %w", err)
+	}
+	if !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+
+	return v.identity(claims)
+}
+
+// identity maps provider claims into an identity using the configured claim names
+func (v *OIDCVerifier) identity(claims jwt.MapClaims) (*Identity, error) {
+	subject, _ := lookupClaim(claims, claimName(v.config.SubjectClaim, "sub")).(string)
+	if subject == "" {
+		return nil, errors.New("token has no subject claim")
+	}
+
+	email, _ := lookupClaim(claims, claimName(v.config.EmailClaim, "email")).(string)
+	if email == "" {
+		return nil, errors.New("token has no email claim")
+	}
+	// RBAC decisions key off the email, so the provider must vouch for it
+	if verified, _ := claims["email_verified"].(bool); !verified {
+		return nil, errors.New("token email is not verified")
+	}
+
+	identity := &Identity{
+		Subject:  subject,
+		Email:    strings.ToLower(strings.TrimSpace(email)),
+		Roles:    stringList(lookupClaim(claims, claimName(v.config.RolesClaim, "groups"))),
+		AMR:      stringList(claims["amr"]),
+		External: true,
+	}
+	identity.Tenant, _ = lookupClaim(claims, claimName(v.config.TenantClaim, "tenant")).(string)
+	identity.TokenID, _ = claims["jti"].(string)
+	if iat, err := claims.GetIssuedAt(); err == nil && iat != nil {
+		identity.IssuedAt = iat.Time
+	}
+	if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+		identity.ExpiresAt = exp.Time
+	}
+	return identity, nil
+}
+
+// key returns the provider key with the given ID, refreshing the key set when
+// it is stale or does not contain the key. Refreshes are throttled so unknown
+// key IDs cannot be used to flood the provider.
+func (v *OIDCVerifier) key(ctx context.Context, kid string) (*verificationKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	refresh := time.Duration(v.config.JWKSRefreshMinutes) * time.Minute
+	if refresh <= 0 {
+		refresh = defaultJWKSRefresh
+	}
+
+	key, found := v.lookup(kid)
+	due := !found || time.Since(v.fetchedAt) > refresh
+	if due && time.Since(v.attemptedAt) > minJWKSRefetch {
+		v.attemptedAt = time.Now()
+		v.refreshErr = v.refresh(ctx)
+		if v.refreshErr == nil {
+			key, found = v.lookup(kid)
+		}
+	}
+
+	// Cached keys stay usable if the provider is briefly unavailable
+	if !found {
+		if v.refreshErr != nil {
+			return nil, v.refreshErr
+		}
+		return nil, fmt.Errorf("unknown key ID %q", kid)
+	}
+	return key, nil
+}
+
+// lookup finds a cached key. Tokens without a kid are only accepted when the
+// provider publishes a single key.
+func (v *OIDCVerifier) lookup(kid string) (*verificationKey, bool) {
+	if kid == "" {
+		if len(v.keys) != 1 {
+			return nil, false
+		}
+		for _, key := range v.keys {
+			return key, true
+		}
+	}
+	key, ok := v.keys[kid]
+	return key, ok
+}
+
+// refresh discovers the provider's JWKS URI if needed and refetches its keys
+func (v *OIDCVerifier) refresh(ctx context.Context) error {
+	if v.jwksURI == "" {
+		var metadata providerMetadata
+		discoveryURL := strings.TrimSuffix(v.config.IssuerURL, "/") + "/.well-known/openid-configuration"
+		if err := v.getJSON(ctx, discoveryURL, &metadata); err != nil {
+			return fmt.Errorf("failed to discover OIDC provider: %w", err)
+		}
+		if metadata.Issuer != v.config.IssuerURL {
+			return fmt.Errorf("OIDC provider reports issuer %q, expected %q", metadata.Issuer, v.config.IssuerURL)
+		}
+		if metadata.JWKSURI == "" {
+			return errors.New("OIDC provider metadata has no jwks_uri")
+		}
+		v.jwksURI = metadata.JWKSURI
+	}
+
+	var jwks JWKS
+	if err := v.getJSON(ctx, v.jwksURI, &jwks); err != nil {
+		return fmt.Errorf("failed to fetch OIDC provider keys: %w", err)
+	}
+
+	keys := make(map[string]*verificationKey, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		public, err := jwk.PublicKey()
+		if err != nil {
+			// Skip keys of types we do not support rather than failing the whole set
+			continue
+		}
+		method, err := methodForKey(public)
+		if jwk.Alg != "" {
+			method, err = jwt.GetSigningMethod(jwk.Alg), nil
+		}
+		if err != nil || method == nil {
+			continue
+		}
+		keys[jwk.Kid] = &verificationKey{id: jwk.Kid, method: method, key: public}
+	}
+
+	v.keys = keys
+	v.fetchedAt = time.Now()
+	return nil
+}
+
+// getJSON fetches a URL and decodes its JSON body into out
+func (v *OIDCVerifier) getJSON(ctx context.Context, url string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, url)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// claimName returns the configured claim name or the default
+func claimName(configured, fallback string) string {
+	if configured == "" {
+		return fallback
+	}
+	return configured
+}
+
+// lookupClaim resolves a dotted claim path such as "realm_access.roles"
+func lookupClaim(claims map[string]interface{}, path string) interface{} {
+	var value interface{} = claims
+	for _, part := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = object[part]
+	}
+	return value
+}
+
+// stringList converts a claim holding a list of strings, or a single
+// space or comma separated string, into a slice
+func stringList(value interface{}) []string {
+	switch v := value.(type) {
+	case []interface{}:
+		list := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok && s != "" {
+				list = append(list, s)
+			}
+		}
+		return list
+	case string:
+		return strings.FieldsFunc(v, func(r rune) bool {
+			return r == ' ' || r == ','
+		})
+	default:
+		return nil
+	}
+}
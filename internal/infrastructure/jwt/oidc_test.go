@@ -0,0 +1,237 @@
+package jwt
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/config"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubIssuer is a minimal OpenID provider serving discovery metadata and a JWKS
+type stubIssuer struct {
+	server *httptest.Server
+
+	mu   sync.Mutex
+	keys map[string]*rsa.PrivateKey
+}
+
+// newStubIssuer starts a provider publishing a single RSA key with the given kid
+func newStubIssuer(t *testing.T, kid string) *stubIssuer {
+	t.Helper()
+
+	issuer := &stubIssuer{keys: map[string]*rsa.PrivateKey{}}
+	issuer.addKey(t, kid)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(providerMetadata{
+			Issuer:  issuer.server.URL,
+			JWKSURI: issuer.server.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		issuer.mu.Lock()
+		defer issuer.mu.Unlock()
+
+		var jwks JWKS
+		for kid, key := range issuer.keys {
+			jwk, err := newJWK(&key.PublicKey)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			jwk.Kid = kid
+			jwk.Alg = "RS256"
+			jwks.Keys = append(jwks.Keys, jwk)
+		}
+		_ = json.NewEncoder(w).Encode(jwks)
+	})
+	issuer.server = httptest.NewServer(mux)
+	t.Cleanup(issuer.server.Close)
+
+	return issuer
+}
+
+// addKey generates and publishes a new signing key
+func (s *stubIssuer) addKey(t *testing.T, kid string) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[kid] = key
+}
+
+// sign returns a token for claims signed with the key with the given kid
+func (s *stubIssuer) sign(t *testing.T, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+
+	s.mu.Lock()
+	key := s.keys[kid]
+	s.mu.Unlock()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+// claims returns valid claims for the issuer, overridden by extra
+func (s *stubIssuer) claims(extra jwt.MapClaims) jwt.MapClaims {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":            s.server.URL,
+		"aud":            "gin-api",
+		"sub":            "00u1abcd",
+		"email":          "Alice@Example.com",
+		"email_verified": true,
+		"groups":         []string{"admin", "engineering"},
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+		"jti":            "provider-token-1",
+	}
+	for name, value := range extra {
+		if value == nil {
+			delete(claims, name)
+			continue
+		}
+		claims[name] = value
+	}
+	return claims
+}
+
+func newTestVerifier(t *testing.T, issuer *stubIssuer, configure func(*config.OIDCConfig)) *OIDCVerifier {
+	t.Helper()
+
+	cfg := &config.OIDCConfig{
+		Enabled:          true,
+		IssuerURL:        issuer.server.URL,
+		Audience:         "gin-api",
+		ClockSkewSeconds: 0,
+	}
+	if configure != nil {
+		configure(cfg)
+	}
+	verifier, err := NewOIDCVerifier(cfg, issuer.server.Client())
+	require.NoError(t, err)
+	return verifier
+}
+
+func TestOIDCVerifier_Verify(t *testing.T) {
+	issuer := newStubIssuer(t, "key-1")
+	verifier := newTestVerifier(t, issuer, nil)
+
+	identity, err := verifier.Verify(context.Background(), issuer.sign(t, "key-1", issuer.claims(nil)))
+	require.NoError(t, err)
+	assert.Equal(t, "00u1abcd", identity.Subject)
+	assert.Equal(t, "alice@example.com", identity.Email)
+	assert.Equal(t, []string{"admin", "engineering"}, identity.Roles)
+	assert.Equal(t, "provider-token-1", identity.TokenID)
+	assert.False(t, identity.ExpiresAt.IsZero())
+	assert.True(t, identity.External)
+	assert.Equal(t, issuer.server.URL, UnverifiedIssuer(issuer.sign(t, "key-1", issuer.claims(nil))))
+}
+
+func TestOIDCVerifier_RejectsInvalidTokens(t *testing.T) {
+	issuer := newStubIssuer(t, "key-1")
+	verifier := newTestVerifier(t, issuer, nil)
+	now := time.Now()
+
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+	}{
+		{name: "wrong audience", claims: jwt.MapClaims{"aud": "another-api"}},
+		{name: "wrong issuer", claims: jwt.MapClaims{"iss": "https://evil.example.com"}},
+		{name: "expired", claims: jwt.MapClaims{"exp": now.Add(-time.Minute).Unix()}},
+		{name: "missing expiry", claims: jwt.MapClaims{"exp": nil}},
+		{name: "not yet valid", claims: jwt.MapClaims{"nbf": now.Add(time.Hour).Unix()}},
+		{name: "missing email", claims: jwt.MapClaims{"email": nil}},
+		{name: "unverified email", claims: jwt.MapClaims{"email_verified": false}},
+		{name: "missing email verification", claims: jwt.MapClaims{"email_verified": nil}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := verifier.Verify(context.Background(), issuer.sign(t, "key-1", issuer.claims(tt.claims)))
+			assert.Error(t, err)
+		})
+	}
+
+	t.Run("HMAC signed", func(t *testing.T) {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, issuer.claims(nil)).SignedString([]byte("secret"))
+		require.NoError(t, err)
+
+		_, err = verifier.Verify(context.Background(), token)
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown signing key", func(t *testing.T) {
+		other := newStubIssuer(t, "key-1")
+		_, err := verifier.Verify(context.Background(), other.sign(t, "key-1", issuer.claims(nil)))
+		assert.Error(t, err)
+	})
+}
+
+func TestOIDCVerifier_ClaimMapping(t *testing.T) {
+	issuer := newStubIssuer(t, "key-1")
+	verifier := newTestVerifier(t, issuer, func(cfg *config.OIDCConfig) {
+		cfg.EmailClaim = "preferred_username"
+		cfg.SubjectClaim = "oid"
+		cfg.RolesClaim = "realm_access.roles"
+		cfg.TenantClaim = "org"
+	})
+
+	token := issuer.sign(t, "key-1", issuer.claims(jwt.MapClaims{
+		"preferred_username": "bob@example.com",
+		"oid":                "object-42",
+		"realm_access":       map[string]interface{}{"roles": []string{"user"}},
+		"org":                "acme",
+	}))
+
+	identity, err := verifier.Verify(context.Background(), token)
+	require.NoError(t, err)
+	assert.Equal(t, "object-42", identity.Subject)
+	assert.Equal(t, "bob@example.com", identity.Email)
+	assert.Equal(t, []string{"user"}, identity.Roles)
+	assert.Equal(t, "acme", identity.Tenant)
+}
+
+func TestOIDCVerifier_KeyRotation(t *testing.T) {
+	issuer := newStubIssuer(t, "key-1")
+	verifier := newTestVerifier(t, issuer, nil)
+
+	_, err := verifier.Verify(context.Background(), issuer.sign(t, "key-1", issuer.claims(nil)))
+	require.NoError(t, err)
+
+	// A token signed with a newly published key is accepted once the key set is refetched
+	issuer.addKey(t, "key-2")
+	token := issuer.sign(t, "key-2", issuer.claims(nil))
+
+	_, err = verifier.Verify(context.Background(), token)
+	assert.Error(t, err, "refetches are throttled")
+
+	verifier.attemptedAt = time.Time{}
+	_, err = verifier.Verify(context.Background(), token)
+	assert.NoError(t, err)
+}
+
+func TestStringList(t *testing.T) {
+	assert.Equal(t, []string{"a", "b"}, stringList([]interface{}{"a", "", "b", 3}))
+	assert.Equal(t, []string{"read", "write"}, stringList("read write"))
+	assert.Equal(t, []string{"x", "y"}, stringList("x,y"))
+	assert.Nil(t, stringList(nil))
+}
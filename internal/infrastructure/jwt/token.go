@@ -1,20 +1,53 @@
 package jwt
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/bgaurav7/gin-microservice-boilerplate/config"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/auth"
 	"github.com/golang-jwt/jwt/v5"
 )
 
+const (
+	// PurposeLogin marks tokens sent in login links. They prove the holder
+	// received the email, and are only exchanged for access tokens.
+	PurposeLogin = "login"
+
+	// PurposeMFA marks tokens proving the first factor of a login that still
+	// needs a second one. They are only exchanged, along with a one-time
+	// code, for access tokens.
+	PurposeMFA = "mfa"
+)
+
 // Claims represents the JWT claims
 type Claims struct {
 	Email string `json:"email"`
+	// Tenant is the tenant the caller acts in; absent for the default tenant
+	Tenant string `json:"tenant,omitempty"`
+	// Purpose is absent for access tokens. Tokens with a purpose, such as
+	// PurposeLogin, are never accepted as access tokens.
+	Purpose string `json:"purpose,omitempty"`
+	// AMR lists the methods the caller authenticated with (RFC 8176)
+	AMR []string `json:"amr,omitempty"`
+	// Scope is the space-delimited list of roles the token is restricted to
+	// (RFC 8693); absent for tokens carrying every role of the subject
+	Scope string `json:"scope,omitempty"`
+	// Act names the superadmin impersonating the subject (RFC 8693); absent
+	// unless the token was issued for impersonation
+	Act *ActorClaim `json:"act,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// ActorClaim identifies the party acting on behalf of the subject of a token
+type ActorClaim struct {
+	Subject string `json:"sub"`
+}
+
 // Subject returns the subject from the registered claims
 func (c *Claims) Subject() string {
 	return c.RegisteredClaims.Subject
@@ -23,39 +56,181 @@ func (c *Claims) Subject() string {
 // TokenService handles JWT token operations
 type TokenService struct {
 	config *config.AuthConfig
+
+	// signing signs new tokens
+	signing *signingKey
+	// keys holds every key tokens are verified with, indexed by key ID
+	keys map[string]*verificationKey
+	// jwks publishes the public keys
+	jwks JWKS
 }
 
-// NewTokenService creates a new token service
-func NewTokenService(config *config.AuthConfig) *TokenService {
-	return &TokenService{
-		config: config,
+// NewTokenService creates a new token service, loading the keys configured in auth
+func NewTokenService(config *config.AuthConfig) (*TokenService, error) {
+	signing, err := loadSigningKey(config)
+	if err != nil {
+		return nil, err
+	}
+	verification, err := loadVerificationKeys(config)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &TokenService{
+		config:  config,
+		signing: signing,
+		keys:    make(map[string]*verificationKey),
+		jwks:    JWKS{Keys: []JWK{}},
+	}
+	for _, key := range append([]*verificationKey{&signing.verificationKey}, verification...) {
+		if _, exists := s.keys[key.id]; exists {
+			return nil, fmt.Errorf("duplicate JWT key ID %q", key.id)
+		}
+		s.keys[key.id] = key
+
+		// HMAC secrets are never published
+		if key.method == jwt.SigningMethodHS256 {
+			continue
+		}
+		jwk, err := newJWK(key.key)
+		if err != nil {
+			return nil, err
+		}
+		jwk.Use = "sig"
+		jwk.Alg = key.method.Alg()
+		jwk.Kid = key.id
+		s.jwks.Keys = append(s.jwks.Keys, jwk)
 	}
+
+	return s, nil
 }
 
-// GenerateToken generates a new JWT token for the given email
-func (s *TokenService) GenerateToken(email string) (string, error) {
-	if email == "" {
+// GenerateToken generates a new JWT token for the given email in the given tenant
+func (s *TokenService) GenerateToken(email, tenant string) (string, error) {
+	return s.IssueToken(auth.TokenGrant{Email: email, Tenant: tenant})
+}
+
+// IssueToken generates a new JWT access token carrying the grant
+func (s *TokenService) IssueToken(grant auth.TokenGrant) (string, error) {
+	if grant.Email == "" {
 		return "", errors.New("email cannot be empty")
 	}
 
 	// Set expiration time
-	expirationTime := time.Now().Add(time.Duration(s.config.JWTExpiryHours) * time.Hour)
+	ttl := s.config.AccessTokenTTL()
+	if grant.TTL > 0 {
+		ttl = grant.TTL
+	}
+	expirationTime := time.Now().Add(ttl)
+
+	// Generate a unique token ID so the token can be revoked individually
+	tokenID, err := newTokenID()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate token ID: %w", err)
+	}
 
 	// Create claims
 	claims := &Claims{
-		Email: email,
+		Email:  grant.Email,
+		Tenant: grant.Tenant,
+		AMR:    grant.AMR,
+		Scope:  strings.Join(grant.Scopes, " "),
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Subject:   grant.Email,
+		},
+	}
+
+	if grant.Actor != "" {
+		claims.Act = &ActorClaim{Subject: grant.Actor}
+	}
+
+	return s.sign(claims)
+}
+
+// GenerateLoginToken generates a token for a login link sent to the email.
+// challengeID names the login challenge the link belongs to, so the link can
+// only be used once.
+func (s *TokenService) GenerateLoginToken(email, challengeID string, ttl time.Duration) (string, error) {
+	if email == "" || challengeID == "" {
+		return "", errors.New("email and challenge ID cannot be empty")
+	}
+
+	now := time.Now()
+	return s.sign(&Claims{
+		Email:   email,
+		Purpose: PurposeLogin,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        challengeID,
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
 			Subject:   email,
 		},
+	})
+}
+
+// ValidateLoginToken validates a login link token and returns the email it
+// was sent to and the ID of its login challenge
+func (s *TokenService) ValidateLoginToken(tokenString string) (email, challengeID string, err error) {
+	claims, err := s.parse(tokenString)
+	if err != nil {
+		return "", "", err
 	}
+	if claims.Purpose != PurposeLogin {
+		return "", "", errors.New("not a login token")
+	}
+	return claims.Email, claims.ID, nil
+}
 
-	// Create token with claims
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+// GenerateMFAToken generates a token for a login whose first factor, recorded
+// in amr, was verified and which still needs a second factor
+func (s *TokenService) GenerateMFAToken(email string, amr []string, ttl time.Duration) (string, error) {
+	if email == "" {
+		return "", errors.New("email cannot be empty")
+	}
+	tokenID, err := newTokenID()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate token ID: %w", err)
+	}
+
+	now := time.Now()
+	return s.sign(&Claims{
+		Email:   email,
+		Purpose: PurposeMFA,
+		AMR:     amr,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			Subject:   email,
+		},
+	})
+}
+
+// ValidateMFAToken validates an MFA token and returns the email of the login
+// and the methods of its first factor
+func (s *TokenService) ValidateMFAToken(tokenString string) (email string, amr []string, err error) {
+	claims, err := s.parse(tokenString)
+	if err != nil {
+		return "", nil, err
+	}
+	if claims.Purpose != PurposeMFA {
+		return "", nil, errors.New("not an MFA token")
+	}
+	return claims.Email, claims.AMR, nil
+}
+
+// sign signs the claims with the signing key, naming the key so verifiers
+// can pick it from the JWKS
+func (s *TokenService) sign(claims *Claims) (string, error) {
+	token := jwt.NewWithClaims(s.signing.method, claims)
+	if s.signing.id != "" {
+		token.Header["kid"] = s.signing.id
+	}
 
-	// Sign the token with the secret key
-	tokenString, err := token.SignedString([]byte(s.config.JWTSecret))
+	tokenString, err := token.SignedString(s.signing.private)
 	if err != nil {
 		return "", fmt.Errorf("failed to sign token: %w", err)
 	}
@@ -63,16 +238,25 @@ func (s *TokenService) GenerateToken(email string) (string, error) {
 	return tokenString, nil
 }
 
-// ValidateToken validates a JWT token and returns the claims
+// ValidateToken validates a JWT access token and returns the claims
 func (s *TokenService) ValidateToken(tokenString string) (*Claims, error) {
+	claims, err := s.parse(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tokens issued for another purpose must not grant access
+	if claims.Purpose != "" {
+		return nil, errors.New("not an access token")
+	}
+
+	return claims, nil
+}
+
+// parse verifies a token issued by the service and returns its claims
+func (s *TokenService) parse(tokenString string) (*Claims, error) {
 	// Parse the token
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		// Validate the signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(s.config.JWTSecret), nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, s.verificationKey)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse token: %w", err)
@@ -92,7 +276,40 @@ func (s *TokenService) ValidateToken(tokenString string) (*Claims, error) {
 	return claims, nil
 }
 
-// IsSuperAdmin checks if the user is a super admin based on email
+// JWKS returns the public keys tokens may be verified with
+func (s *TokenService) JWKS() JWKS {
+	return s.jwks
+}
+
+// verificationKey selects the key a token is verified with from its kid header.
+// Tokens without a kid are verified with the signing key.
+func (s *TokenService) verificationKey(token *jwt.Token) (interface{}, error) {
+	key := &s.signing.verificationKey
+	if kid, ok := token.Header["kid"].(string); ok {
+		if key, ok = s.keys[kid]; !ok {
+			return nil, fmt.Errorf("unknown key ID %q", kid)
+		}
+	}
+
+	// The algorithm is pinned to the key so a token cannot pick a weaker one
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.key, nil
+}
+
+// newTokenID returns a random identifier for the jti claim
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// IsSuperAdmin checks if the user is a super admin based on email. Emails
+// are compared case-insensitively, as they are normalized at registration.
 func (s *TokenService) IsSuperAdmin(email string) bool {
-	return email == s.config.SuperAdminEmail
+	superAdmin := strings.ToLower(strings.TrimSpace(s.config.SuperAdminEmail))
+	return superAdmin != "" && strings.ToLower(strings.TrimSpace(email)) == superAdmin
 }
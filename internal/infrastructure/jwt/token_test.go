@@ -0,0 +1,347 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/config"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/auth"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeKeyPair writes the PKCS#8 private key and PKIX public key of signer to
+// PEM files in dir and returns their paths
+func writeKeyPair(t *testing.T, dir, name string, signer crypto.Signer) (string, string) {
+	t.Helper()
+
+	private, err := x509.MarshalPKCS8PrivateKey(signer)
+	require.NoError(t, err)
+	public, err := x509.MarshalPKIXPublicKey(signer.Public())
+	require.NoError(t, err)
+
+	privatePath := filepath.Join(dir, name+".key")
+	publicPath := filepath.Join(dir, name+".pub")
+	require.NoError(t, os.WriteFile(privatePath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: private}), 0o600))
+	require.NoError(t, os.WriteFile(publicPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: public}), 0o600))
+	return privatePath, publicPath
+}
+
+func TestTokenService_HS256(t *testing.T) {
+	service, err := NewTokenService(&config.AuthConfig{
+		JWTSecret:             "test-secret",
+		AccessTokenTTLMinutes: 15,
+	})
+	require.NoError(t, err)
+
+	token, err := service.GenerateToken("user@example.com", "acme")
+	require.NoError(t, err)
+
+	claims, err := service.ValidateToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, "user@example.com", claims.Email)
+	assert.Equal(t, "acme", claims.Tenant)
+	assert.NotEmpty(t, claims.ID)
+
+	// HMAC secrets are never published
+	assert.Empty(t, service.JWKS().Keys)
+}
+
+func TestTokenService_LoginTokens(t *testing.T) {
+	service, err := NewTokenService(&config.AuthConfig{
+		JWTSecret:             "test-secret",
+		AccessTokenTTLMinutes: 15,
+	})
+	require.NoError(t, err)
+
+	loginToken, err := service.GenerateLoginToken("user@example.com", "42", 15*time.Minute)
+	require.NoError(t, err)
+
+	email, challengeID, err := service.ValidateLoginToken(loginToken)
+	require.NoError(t, err)
+	assert.Equal(t, "user@example.com", email)
+	assert.Equal(t, "42", challengeID)
+
+	// Login tokens do not grant access, and access tokens do not log in
+	_, err = service.ValidateToken(loginToken)
+	assert.Error(t, err)
+
+	accessToken, err := service.GenerateToken("user@example.com", "")
+	require.NoError(t, err)
+	_, _, err = service.ValidateLoginToken(accessToken)
+	assert.Error(t, err)
+
+	expired, err := service.GenerateLoginToken("user@example.com", "42", -time.Minute)
+	require.NoError(t, err)
+	_, _, err = service.ValidateLoginToken(expired)
+	assert.Error(t, err)
+}
+
+func TestTokenService_MFATokens(t *testing.T) {
+	service, err := NewTokenService(&config.AuthConfig{
+		JWTSecret:             "test-secret",
+		AccessTokenTTLMinutes: 15,
+	})
+	require.NoError(t, err)
+
+	mfaToken, err := service.GenerateMFAToken("user@example.com", []string{auth.AMRPassword}, 5*time.Minute)
+	require.NoError(t, err)
+
+	email, amr, err := service.ValidateMFAToken(mfaToken)
+	require.NoError(t, err)
+	assert.Equal(t, "user@example.com", email)
+	assert.Equal(t, []string{auth.AMRPassword}, amr)
+
+	// MFA tokens do not grant access, and login tokens do not complete MFA
+	_, err = service.ValidateToken(mfaToken)
+	assert.Error(t, err)
+	loginToken, err := service.GenerateLoginToken("user@example.com", "42", time.Minute)
+	require.NoError(t, err)
+	_, _, err = service.ValidateMFAToken(loginToken)
+	assert.Error(t, err)
+
+	// The amr claim of access tokens is carried into the identity
+	accessToken, err := service.IssueToken(auth.TokenGrant{Email: "user@example.com", AMR: []string{auth.AMRPassword, auth.AMROTP, auth.AMRMFA}})
+	require.NoError(t, err)
+	claims, err := service.ValidateToken(accessToken)
+	require.NoError(t, err)
+	assert.Equal(t, []string{auth.AMRPassword, auth.AMROTP, auth.AMRMFA}, claims.Identity().AMR)
+}
+
+func TestTokenService_Asymmetric(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		algorithm string
+		signer    crypto.Signer
+		kty       string
+	}{
+		{AlgorithmRS256, rsaKey, "RSA"},
+		{AlgorithmES256, ecKey, "EC"},
+		{AlgorithmEdDSA, edKey, "OKP"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.algorithm, func(t *testing.T) {
+			privatePath, _ := writeKeyPair(t, t.TempDir(), "signing", tt.signer)
+
+			service, err := NewTokenService(&config.AuthConfig{
+				JWTAlgorithm:          tt.algorithm,
+				JWTSigningKey:         config.JWTKeyConfig{PrivateKeyPath: privatePath},
+				AccessTokenTTLMinutes: 15,
+			})
+			require.NoError(t, err)
+
+			token, err := service.GenerateToken("user@example.com", "")
+			require.NoError(t, err)
+
+			claims, err := service.ValidateToken(token)
+			require.NoError(t, err)
+			assert.Equal(t, "user@example.com", claims.Email)
+
+			// The kid header names the published key
+			parsed, _, err := jwt.NewParser().ParseUnverified(token, &Claims{})
+			require.NoError(t, err)
+			assert.Equal(t, tt.algorithm, parsed.Method.Alg())
+
+			jwks := service.JWKS()
+			require.Len(t, jwks.Keys, 1)
+			assert.Equal(t, parsed.Header["kid"], jwks.Keys[0].Kid)
+			assert.Equal(t, tt.kty, jwks.Keys[0].Kty)
+			assert.Equal(t, tt.algorithm, jwks.Keys[0].Alg)
+			assert.Equal(t, "sig", jwks.Keys[0].Use)
+		})
+	}
+}
+
+func TestTokenService_Rotation(t *testing.T) {
+	dir := t.TempDir()
+	oldKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	newKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	oldPrivate, oldPublic := writeKeyPair(t, dir, "old", oldKey)
+	newPrivate, _ := writeKeyPair(t, dir, "new", newKey)
+
+	oldService, err := NewTokenService(&config.AuthConfig{
+		JWTAlgorithm:          AlgorithmES256,
+		JWTSigningKey:         config.JWTKeyConfig{ID: "2024-01", PrivateKeyPath: oldPrivate},
+		AccessTokenTTLMinutes: 15,
+	})
+	require.NoError(t, err)
+	oldToken, err := oldService.GenerateToken("user@example.com", "")
+	require.NoError(t, err)
+
+	// After rotation the old public key stays trusted for verification only
+	newService, err := NewTokenService(&config.AuthConfig{
+		JWTAlgorithm:          AlgorithmRS256,
+		JWTSigningKey:         config.JWTKeyConfig{ID: "2024-02", PrivateKeyPath: newPrivate},
+		JWTVerificationKeys:   []config.JWTKeyConfig{{ID: "2024-01", PublicKeyPath: oldPublic}},
+		AccessTokenTTLMinutes: 15,
+	})
+	require.NoError(t, err)
+
+	claims, err := newService.ValidateToken(oldToken)
+	require.NoError(t, err)
+	assert.Equal(t, "user@example.com", claims.Email)
+
+	newToken, err := newService.GenerateToken("user@example.com", "")
+	require.NoError(t, err)
+	_, err = newService.ValidateToken(newToken)
+	assert.NoError(t, err)
+
+	// Both keys are published
+	jwks := newService.JWKS()
+	require.Len(t, jwks.Keys, 2)
+	assert.Equal(t, "2024-02", jwks.Keys[0].Kid)
+	assert.Equal(t, "2024-01", jwks.Keys[1].Kid)
+
+	// Tokens signed with keys that are no longer configured are rejected
+	_, err = oldService.ValidateToken(newToken)
+	assert.Error(t, err)
+}
+
+func TestTokenService_RejectsAlgorithmConfusion(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	privatePath, publicPath := writeKeyPair(t, t.TempDir(), "signing", rsaKey)
+
+	service, err := NewTokenService(&config.AuthConfig{
+		JWTAlgorithm:          AlgorithmRS256,
+		JWTSigningKey:         config.JWTKeyConfig{ID: "rsa", PrivateKeyPath: privatePath},
+		AccessTokenTTLMinutes: 15,
+	})
+	require.NoError(t, err)
+
+	// An HS256 token keyed with the public key bytes must not verify
+	publicPEM, err := os.ReadFile(publicPath)
+	require.NoError(t, err)
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{Email: "admin@example.com"})
+	forged.Header["kid"] = "rsa"
+	forgedString, err := forged.SignedString(publicPEM)
+	require.NoError(t, err)
+
+	_, err = service.ValidateToken(forgedString)
+	assert.Error(t, err)
+
+	// Unknown key IDs are rejected
+	unknown := jwt.NewWithClaims(jwt.SigningMethodRS256, &Claims{Email: "user@example.com"})
+	unknown.Header["kid"] = "unknown"
+	unknownString, err := unknown.SignedString(rsaKey)
+	require.NoError(t, err)
+
+	_, err = service.ValidateToken(unknownString)
+	assert.Error(t, err)
+}
+
+func TestNewTokenService_InvalidConfig(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	privatePath, _ := writeKeyPair(t, t.TempDir(), "signing", ecKey)
+
+	tests := []struct {
+		name   string
+		config *config.AuthConfig
+	}{
+		{"Missing HS256 secret", &config.AuthConfig{}},
+		{"Missing signing key", &config.AuthConfig{JWTAlgorithm: AlgorithmRS256}},
+		{"Key does not match algorithm", &config.AuthConfig{
+			JWTAlgorithm:  AlgorithmRS256,
+			JWTSigningKey: config.JWTKeyConfig{PrivateKeyPath: privatePath},
+		}},
+		{"Missing key file", &config.AuthConfig{
+			JWTAlgorithm:  AlgorithmES256,
+			JWTSigningKey: config.JWTKeyConfig{PrivateKeyPath: filepath.Join(t.TempDir(), "missing.key")},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewTokenService(tt.config)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestThumbprint(t *testing.T) {
+	// RFC 7638 section 3.1 example key
+	n, err := base64.RawURLEncoding.DecodeString("0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw")
+	require.NoError(t, err)
+	public := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: 65537}
+
+	id, err := thumbprint(public)
+	require.NoError(t, err)
+	assert.Equal(t, "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs", id)
+}
+
+func TestTokenService_ImpersonationToken(t *testing.T) {
+	service, err := NewTokenService(&config.AuthConfig{
+		JWTSecret:             "test-secret",
+		AccessTokenTTLMinutes: 60,
+	})
+	require.NoError(t, err)
+
+	token, err := service.IssueToken(auth.TokenGrant{Email: "user@example.com", Actor: "admin@example.com", TTL: 5 * time.Minute})
+	require.NoError(t, err)
+
+	claims, err := service.ValidateToken(token)
+	require.NoError(t, err)
+	require.NotNil(t, claims.Act)
+	assert.Equal(t, "admin@example.com", claims.Act.Subject)
+	assert.Equal(t, "user@example.com", claims.Subject())
+	assert.WithinDuration(t, time.Now().Add(5*time.Minute), claims.ExpiresAt.Time, 5*time.Second)
+
+	identity := claims.Identity()
+	assert.Equal(t, "user@example.com", identity.Email)
+	assert.Equal(t, "admin@example.com", identity.Actor)
+
+	// Ordinary tokens carry no act claim
+	token, err = service.IssueToken(auth.TokenGrant{Email: "user@example.com"})
+	require.NoError(t, err)
+	claims, err = service.ValidateToken(token)
+	require.NoError(t, err)
+	assert.Nil(t, claims.Act)
+	assert.Empty(t, claims.Identity().Actor)
+}
+
+func TestTokenService_ScopedToken(t *testing.T) {
+	service, err := NewTokenService(&config.AuthConfig{
+		JWTSecret:             "test-secret",
+		AccessTokenTTLMinutes: 60,
+	})
+	require.NoError(t, err)
+
+	token, err := service.IssueToken(auth.TokenGrant{Email: "user@example.com", Scopes: []string{"viewer", "auditor"}})
+	require.NoError(t, err)
+
+	claims, err := service.ValidateToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, "viewer auditor", claims.Scope)
+	assert.Equal(t, []string{"viewer", "auditor"}, claims.Identity().Scopes)
+
+	// Unscoped tokens carry no scope claim
+	token, err = service.IssueToken(auth.TokenGrant{Email: "user@example.com"})
+	require.NoError(t, err)
+	claims, err = service.ValidateToken(token)
+	require.NoError(t, err)
+	assert.Empty(t, claims.Scope)
+	assert.Empty(t, claims.Identity().Scopes)
+}
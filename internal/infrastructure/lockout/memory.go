@@ -0,0 +1,67 @@
+package lockout
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/repository"
+)
+
+// memoryStore implements the LoginAttemptStore interface in process memory.
+// Counts are lost on restart and not shared between replicas, so each
+// replica enforces the limits on its own; it is intended for development,
+// tests and single-instance deployments.
+type memoryStore struct {
+	mu       sync.Mutex
+	attempts map[string]model.LoginAttempts
+}
+
+// NewMemoryStore creates a new in-memory login attempt store
+func NewMemoryStore() repository.LoginAttemptStore {
+	return &memoryStore{
+		attempts: make(map[string]model.LoginAttempts),
+	}
+}
+
+// Get returns the failures of the key since the given time
+func (s *memoryStore) Get(ctx context.Context, key string, since time.Time) (model.LoginAttempts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attempts, ok := s.attempts[key]
+	if !ok || attempts.LastFailureAt.Before(since) {
+		return model.LoginAttempts{}, nil
+	}
+	return attempts, nil
+}
+
+// RecordFailure counts a failed attempt of the key
+func (s *memoryStore) RecordFailure(ctx context.Context, key string, now, since time.Time) (model.LoginAttempts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Drop keys whose failures no longer count, so addresses seen once do
+	// not accumulate
+	for k, attempts := range s.attempts {
+		if attempts.LastFailureAt.Before(since) {
+			delete(s.attempts, k)
+		}
+	}
+
+	attempts := s.attempts[key]
+	attempts.Failures++
+	attempts.LastFailureAt = now
+	s.attempts[key] = attempts
+	return attempts, nil
+}
+
+// Reset forgets the failures of the key
+func (s *memoryStore) Reset(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.attempts, key)
+	return nil
+}
@@ -0,0 +1,56 @@
+package lockout
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	since := now.Add(-15 * time.Minute)
+
+	t.Run("Failures are counted per key", func(t *testing.T) {
+		store := NewMemoryStore()
+		_, err := store.RecordFailure(ctx, "account:user@example.com", now.Add(-time.Second), since)
+		require.NoError(t, err)
+		attempts, err := store.RecordFailure(ctx, "account:user@example.com", now, since)
+		require.NoError(t, err)
+		assert.Equal(t, 2, attempts.Failures)
+		assert.Equal(t, now, attempts.LastFailureAt)
+
+		attempts, err = store.Get(ctx, "ip:192.0.2.1", since)
+		require.NoError(t, err)
+		assert.Zero(t, attempts.Failures)
+	})
+
+	t.Run("Reset forgets failures", func(t *testing.T) {
+		store := NewMemoryStore()
+		_, err := store.RecordFailure(ctx, "account:user@example.com", now, since)
+		require.NoError(t, err)
+		require.NoError(t, store.Reset(ctx, "account:user@example.com"))
+
+		attempts, err := store.Get(ctx, "account:user@example.com", since)
+		require.NoError(t, err)
+		assert.Zero(t, attempts.Failures)
+	})
+
+	t.Run("Old failures no longer count", func(t *testing.T) {
+		store := NewMemoryStore().(*memoryStore)
+		_, err := store.RecordFailure(ctx, "ip:192.0.2.1", now.Add(-time.Hour), now.Add(-2*time.Hour))
+		require.NoError(t, err)
+
+		attempts, err := store.Get(ctx, "ip:192.0.2.1", since)
+		require.NoError(t, err)
+		assert.Zero(t, attempts.Failures)
+
+		attempts, err = store.RecordFailure(ctx, "ip:192.0.2.2", now, since)
+		require.NoError(t, err)
+		assert.Equal(t, 1, attempts.Failures)
+		assert.NotContains(t, store.attempts, "ip:192.0.2.1")
+	})
+}
@@ -0,0 +1,101 @@
+package lockout
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/repository"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/db"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+	"gorm.io/gorm"
+)
+
+// loginAttempt is a row of the login_attempts table
+type loginAttempt struct {
+	Key           string    `gorm:"primaryKey;size:320"`
+	Failures      int       `gorm:"not null"`
+	LastFailureAt time.Time `gorm:"not null"`
+}
+
+// TableName returns the table name for login attempts
+func (loginAttempt) TableName() string {
+	return "login_attempts"
+}
+
+// postgresStore implements the LoginAttemptStore interface on top of
+// Postgres, so the limits hold across restarts and replicas
+type postgresStore struct {
+	db     *db.Database
+	logger *logger.Logger
+}
+
+// NewPostgresStore creates a new Postgres-backed login attempt store
+func NewPostgresStore(db *db.Database, logger *logger.Logger) repository.LoginAttemptStore {
+	return &postgresStore{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Get returns the failures of the key since the given time
+func (s *postgresStore) Get(ctx context.Context, key string, since time.Time) (model.LoginAttempts, error) {
+	var row loginAttempt
+	err := s.db.DB.WithContext(ctx).
+		Where("key = ? AND last_failure_at >= ?", key, since).
+		First(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.LoginAttempts{}, nil
+	}
+	if err != nil {
+		s.logger.Error("Failed to get login attempts", map[string]interface{}{
+			"error": err.Error(),
+			"key":   key,
+		})
+		return model.LoginAttempts{}, err
+	}
+	return model.LoginAttempts{Failures: row.Failures, LastFailureAt: row.LastFailureAt}, nil
+}
+
+// RecordFailure counts a failed attempt of the key. The count restarts when
+// the previous failure is older than since; concurrent failures are all
+// counted.
+func (s *postgresStore) RecordFailure(ctx context.Context, key string, now, since time.Time) (model.LoginAttempts, error) {
+	// Drop keys whose failures no longer count
+	if err := s.db.DB.WithContext(ctx).Where("last_failure_at < ?", since).Delete(&loginAttempt{}).Error; err != nil {
+		s.logger.Warn("Failed to prune login attempts", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
+	var row loginAttempt
+	err := s.db.DB.WithContext(ctx).Raw(
+		`INSERT INTO login_attempts (key, failures, last_failure_at) VALUES (?, 1, ?)
+			ON CONFLICT (key) DO UPDATE SET
+				failures = CASE WHEN login_attempts.last_failure_at < ? THEN 1 ELSE login_attempts.failures + 1 END,
+				last_failure_at = GREATEST(login_attempts.last_failure_at, EXCLUDED.last_failure_at)
+			RETURNING key, failures, last_failure_at`,
+		key, now, since,
+	).Scan(&row).Error
+	if err != nil {
+		s.logger.Error("Failed to record login failure", map[string]interface{}{
+			"error": err.Error(),
+			"key":   key,
+		})
+		return model.LoginAttempts{}, err
+	}
+	return model.LoginAttempts{Failures: row.Failures, LastFailureAt: row.LastFailureAt}, nil
+}
+
+// Reset forgets the failures of the key
+func (s *postgresStore) Reset(ctx context.Context, key string) error {
+	if err := s.db.DB.WithContext(ctx).Where("key = ?", key).Delete(&loginAttempt{}).Error; err != nil {
+		s.logger.Error("Failed to reset login attempts", map[string]interface{}{
+			"error": err.Error(),
+			"key":   key,
+		})
+		return err
+	}
+	return nil
+}
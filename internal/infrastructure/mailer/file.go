@@ -0,0 +1,50 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+)
+
+// unsafeFileChars matches characters replaced in the recipient part of file names
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9@._-]`)
+
+// FileMailer writes each email as an .eml file in a directory instead of
+// sending it. It is intended for local development: the files open in any
+// mail client.
+type FileMailer struct {
+	dir  string
+	from string
+}
+
+// NewFileMailer creates a new file mailer writing to dir
+func NewFileMailer(dir, from string) *FileMailer {
+	return &FileMailer{
+		dir:  dir,
+		from: from,
+	}
+}
+
+// Send writes the email to a new file named after its time and recipient
+func (m *FileMailer) Send(ctx context.Context, email model.Email) error {
+	now := time.Now()
+	msg, err := message(m.from, email, now)
+	if err != nil {
+		return err
+	}
+
+	// Emails may carry login links, so they are only readable by the owner
+	if err := os.MkdirAll(m.dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create mail directory: %w", err)
+	}
+	name := fmt.Sprintf("%d-%s.eml", now.UnixNano(), unsafeFileChars.ReplaceAllString(email.To, "_"))
+	if err := os.WriteFile(filepath.Join(m.dir, name), msg, 0o600); err != nil {
+		return fmt.Errorf("failed to write email: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package mailer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileMailer_Send(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+	mailer := NewFileMailer(dir, "no-reply@example.com")
+
+	err := mailer.Send(context.Background(), model.Email{
+		To:      "user@example.com",
+		Subject: "Your sign-in link",
+		Body:    "Line one\nLine two\n",
+	})
+	require.NoError(t, err)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*-user@example.com.eml"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	info, err := os.Stat(files[0])
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	content, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	msg := string(content)
+	assert.Contains(t, msg, "From: no-reply@example.com\r\n")
+	assert.Contains(t, msg, "To: user@example.com\r\n")
+	assert.Contains(t, msg, "Subject: Your sign-in link\r\n")
+	assert.True(t, strings.HasSuffix(msg, "\r\n\r\nLine one\r\nLine two\r\n"))
+}
+
+func TestMemoryMailer_Send(t *testing.T) {
+	mailer := NewMemoryMailer()
+
+	require.NoError(t, mailer.Send(context.Background(), model.Email{To: "a@example.com", Subject: "First"}))
+	require.NoError(t, mailer.Send(context.Background(), model.Email{To: "b@example.com", Subject: "Second"}))
+
+	messages := mailer.Messages()
+	require.Len(t, messages, 2)
+	assert.Equal(t, "First", messages[0].Subject)
+	assert.Equal(t, "b@example.com", messages[1].To)
+}
+
+func TestMessage_RejectsInvalidHeaders(t *testing.T) {
+	tests := []struct {
+		name  string
+		from  string
+		email model.Email
+	}{
+		{name: "invalid sender", from: "not an address", email: model.Email{To: "user@example.com"}},
+		{name: "invalid recipient", from: "no-reply@example.com", email: model.Email{To: "user"}},
+		{name: "header injection", from: "no-reply@example.com", email: model.Email{To: "user@example.com", Subject: "Hi\r\nBcc: attacker@example.com"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mailer := NewFileMailer(t.TempDir(), tt.from)
+			assert.Error(t, mailer.Send(context.Background(), tt.email))
+		})
+	}
+}
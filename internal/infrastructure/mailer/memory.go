@@ -0,0 +1,35 @@
+package mailer
+
+import (
+	"context"
+	"sync"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+)
+
+// MemoryMailer keeps sent emails in process memory. It is intended for tests
+// and for development without a mail server.
+type MemoryMailer struct {
+	mu       sync.Mutex
+	messages []model.Email
+}
+
+// NewMemoryMailer creates a new in-memory mailer
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{}
+}
+
+// Send records the email
+func (m *MemoryMailer) Send(ctx context.Context, email model.Email) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, email)
+	return nil
+}
+
+// Messages returns the emails sent so far, oldest first
+func (m *MemoryMailer) Messages() []model.Email {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]model.Email(nil), m.messages...)
+}
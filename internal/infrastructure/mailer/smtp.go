@@ -0,0 +1,68 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/config"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+)
+
+// SMTPMailer sends emails through an SMTP server. The connection is upgraded
+// with STARTTLS when the server supports it, and credentials are only sent
+// over TLS or to localhost.
+type SMTPMailer struct {
+	addr string
+	host string
+	from string
+	auth smtp.Auth
+}
+
+// NewSMTPMailer creates a new SMTP mailer sending as from
+func NewSMTPMailer(cfg *config.SMTPConfig, from string) *SMTPMailer {
+	port := cfg.Port
+	if port == 0 {
+		port = 587
+	}
+
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+
+	return &SMTPMailer{
+		addr: net.JoinHostPort(cfg.Host, strconv.Itoa(port)),
+		host: cfg.Host,
+		from: from,
+		auth: auth,
+	}
+}
+
+// Send delivers the email through the SMTP server
+func (m *SMTPMailer) Send(ctx context.Context, email model.Email) error {
+	msg, err := message(m.from, email, time.Now())
+	if err != nil {
+		return err
+	}
+
+	// smtp.SendMail cannot be cancelled, so the send runs until it finishes
+	// and the caller stops waiting when the context is done
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(m.addr, m.auth, m.from, []string{email.To}, msg)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("failed to send email via %s: %w", m.addr, err)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
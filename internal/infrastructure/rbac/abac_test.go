@@ -0,0 +1,72 @@
+package rbac
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/config"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/auth"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceAuthorizer(t *testing.T) {
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+	enforcer, err := NewEnforcer(&config.RBACConfig{
+		ModelPath:   "model.conf",
+		PolicyPath:  "policy.csv",
+		PolicyStore: config.PolicyStoreFile,
+	}, nil, log)
+	require.NoError(t, err)
+	authorizer := NewResourceAuthorizer(enforcer, log)
+
+	bob := &auth.Principal{ID: "bob@example.com", Email: "bob@example.com", Tenant: "default"}
+	alice := &auth.Principal{ID: "alice@example.com", Email: "alice@example.com", Tenant: "default"}
+	carol := &auth.Principal{ID: "carol@example.com", Email: "carol@example.com", Tenant: "default"}
+	bobsTodo := auth.Resource{Type: auth.ResourceTodo, ID: "1", Owner: "bob@example.com", Tenant: "default"}
+	carolsTodo := auth.Resource{Type: auth.ResourceTodo, ID: "2", Owner: "carol@example.com", Tenant: "default"}
+	acmeTodo := auth.Resource{Type: auth.ResourceTodo, ID: "3", Owner: "bob@example.com", Tenant: "acme"}
+
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		action    string
+		resource  auth.Resource
+		allowed   bool
+	}{
+		{name: "user updates own todo", principal: bob, action: auth.ActionUpdate, resource: bobsTodo, allowed: true},
+		{name: "user deletes own todo", principal: bob, action: auth.ActionDelete, resource: bobsTodo, allowed: true},
+		{name: "user cannot update another user's todo", principal: bob, action: auth.ActionUpdate, resource: carolsTodo, allowed: false},
+		{name: "admin updates any todo", principal: alice, action: auth.ActionUpdate, resource: carolsTodo, allowed: true},
+		{name: "admin deletes any todo", principal: alice, action: auth.ActionDelete, resource: bobsTodo, allowed: true},
+		{name: "caller without a role cannot update own todo", principal: carol, action: auth.ActionUpdate, resource: carolsTodo, allowed: false},
+		{
+			name:      "role from the identity provider grants access",
+			principal: &auth.Principal{ID: "carol@example.com", Email: "carol@example.com", Tenant: "default", Roles: []string{"user"}},
+			action:    auth.ActionUpdate,
+			resource:  carolsTodo,
+			allowed:   true,
+		},
+		{name: "admin cannot update todos of another tenant", principal: alice, action: auth.ActionUpdate, resource: acmeTodo, allowed: false},
+		{name: "owner cannot update own todo in another tenant", principal: bob, action: auth.ActionUpdate, resource: acmeTodo, allowed: false},
+		{
+			name:      "roles do not carry over to other tenants",
+			principal: &auth.Principal{ID: "alice@example.com", Email: "alice@example.com", Tenant: "acme"},
+			action:    auth.ActionUpdate,
+			resource:  auth.Resource{Type: auth.ResourceTodo, Owner: "bob@example.com", Tenant: "acme"},
+			allowed:   false,
+		},
+		{name: "unknown action", principal: alice, action: "archive", resource: bobsTodo, allowed: false},
+		{name: "unknown resource type", principal: alice, action: auth.ActionUpdate, resource: auth.Resource{Type: "note", Owner: "alice@example.com", Tenant: "default"}, allowed: false},
+		{name: "resource without owner is owned by nobody", principal: bob, action: auth.ActionUpdate, resource: auth.Resource{Type: auth.ResourceTodo, Tenant: "default"}, allowed: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allowed, err := authorizer.AuthorizeResource(context.Background(), tt.principal, tt.action, tt.resource)
+			require.NoError(t, err)
+			assert.Equal(t, tt.allowed, allowed)
+		})
+	}
+}
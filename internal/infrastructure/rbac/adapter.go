@@ -0,0 +1,218 @@
+package rbac
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/db"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+	"github.com/casbin/casbin/v2/model"
+	"github.com/casbin/casbin/v2/persist"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxRuleFields is the number of value columns in the casbin_rule table
+const maxRuleFields = 6
+
+// casbinRule is a row of the casbin_rule table. The layout matches the
+// upstream Casbin GORM adapter so existing tooling can read it.
+type casbinRule struct {
+	ID    uint   `gorm:"primaryKey"`
+	Ptype string `gorm:"size:100;not null"`
+	V0    string `gorm:"size:100;not null"`
+	V1    string `gorm:"size:100;not null"`
+	V2    string `gorm:"size:100;not null"`
+	V3    string `gorm:"size:100;not null"`
+	V4    string `gorm:"size:100;not null"`
+	V5    string `gorm:"size:100;not null"`
+}
+
+// TableName returns the table name for Casbin rules
+func (casbinRule) TableName() string {
+	return "casbin_rule"
+}
+
+// newCasbinRule converts a policy rule into a row
+func newCasbinRule(ptype string, rule []string) (*casbinRule, error) {
+	if len(rule) > maxRuleFields {
+		return nil, fmt.Errorf("policy rule has %d fields, at most %d are supported", len(rule), maxRuleFields)
+	}
+
+	values := make([]string, maxRuleFields)
+	copy(values, rule)
+	return &casbinRule{
+		Ptype: ptype,
+		V0:    values[0],
+		V1:    values[1],
+		V2:    values[2],
+		V3:    values[3],
+		V4:    values[4],
+		V5:    values[5],
+	}, nil
+}
+
+// values returns the policy line of the row, starting with the policy type.
+// Trailing empty fields are dropped so rules round-trip with their original length.
+func (r *casbinRule) values() []string {
+	line := []string{r.Ptype, r.V0, r.V1, r.V2, r.V3, r.V4, r.V5}
+	for len(line) > 1 && line[len(line)-1] == "" {
+		line = line[:len(line)-1]
+	}
+	return line
+}
+
+// postgresAdapter stores Casbin policy rules in the casbin_rule table
+type postgresAdapter struct {
+	db     *db.Database
+	logger *logger.Logger
+}
+
+// NewPostgresAdapter creates a Casbin adapter backed by the casbin_rule table.
+// Rules added or removed through the enforcer are saved immediately.
+func NewPostgresAdapter(db *db.Database, logger *logger.Logger) persist.BatchAdapter {
+	return &postgresAdapter{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// LoadPolicy loads all policy rules from the table into the model
+func (a *postgresAdapter) LoadPolicy(m model.Model) error {
+	var rules []casbinRule
+	if err := a.db.DB.Order("id").Find(&rules).Error; err != nil {
+		a.logger.Error("Failed to load policy rules", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return err
+	}
+
+	for i := range rules {
+		if err := persist.LoadPolicyArray(rules[i].values(), m); err != nil {
+			return fmt.Errorf("invalid policy rule %d: %w", rules[i].ID, err)
+		}
+	}
+	return nil
+}
+
+// SavePolicy replaces every stored rule with the rules in the model
+func (a *postgresAdapter) SavePolicy(m model.Model) error {
+	var rows []*casbinRule
+	for _, sec := range []string{"p", "g"} {
+		for ptype, assertion := range m[sec] {
+			for _, rule := range assertion.Policy {
+				row, err := newCasbinRule(ptype, rule)
+				if err != nil {
+					return err
+				}
+				rows = append(rows, row)
+			}
+		}
+	}
+
+	err := a.db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&casbinRule{}).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error
+	})
+	if err != nil {
+		a.logger.Error("Failed to save policy rules", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+	return err
+}
+
+// AddPolicy stores a policy rule
+func (a *postgresAdapter) AddPolicy(sec string, ptype string, rule []string) error {
+	return a.AddPolicies(sec, ptype, [][]string{rule})
+}
+
+// AddPolicies stores policy rules. Rules that are already stored are skipped.
+func (a *postgresAdapter) AddPolicies(sec string, ptype string, rules [][]string) error {
+	rows := make([]*casbinRule, 0, len(rules))
+	for _, rule := range rules {
+		row, err := newCasbinRule(ptype, rule)
+		if err != nil {
+			return err
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	if err := a.db.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error; err != nil {
+		a.logger.Error("Failed to add policy rules", map[string]interface{}{
+			"error": err.Error(),
+			"ptype": ptype,
+		})
+		return err
+	}
+	return nil
+}
+
+// RemovePolicy deletes a policy rule
+func (a *postgresAdapter) RemovePolicy(sec string, ptype string, rule []string) error {
+	return a.RemovePolicies(sec, ptype, [][]string{rule})
+}
+
+// RemovePolicies deletes policy rules
+func (a *postgresAdapter) RemovePolicies(sec string, ptype string, rules [][]string) error {
+	err := a.db.DB.Transaction(func(tx *gorm.DB) error {
+		for _, rule := range rules {
+			row, err := newCasbinRule(ptype, rule)
+			if err != nil {
+				return err
+			}
+			// Match every column so a rule does not remove longer rules it prefixes
+			if err := tx.Where(map[string]interface{}{
+				"ptype": row.Ptype,
+				"v0":    row.V0,
+				"v1":    row.V1,
+				"v2":    row.V2,
+				"v3":    row.V3,
+				"v4":    row.V4,
+				"v5":    row.V5,
+			}).Delete(&casbinRule{}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		a.logger.Error("Failed to remove policy rules", map[string]interface{}{
+			"error": err.Error(),
+			"ptype": ptype,
+		})
+	}
+	return err
+}
+
+// RemoveFilteredPolicy deletes the rules whose fields, starting at fieldIndex,
+// match fieldValues. Empty values match any field value.
+func (a *postgresAdapter) RemoveFilteredPolicy(sec string, ptype string, fieldIndex int, fieldValues ...string) error {
+	if fieldIndex < 0 || fieldIndex+len(fieldValues) > maxRuleFields {
+		return errors.New("policy filter is out of range")
+	}
+
+	query := a.db.DB.Where("ptype = ?", ptype)
+	for i, value := range fieldValues {
+		if value != "" {
+			query = query.Where(fmt.Sprintf("v%d = ?", fieldIndex+i), value)
+		}
+	}
+
+	if err := query.Delete(&casbinRule{}).Error; err != nil {
+		a.logger.Error("Failed to remove policy rules", map[string]interface{}{
+			"error": err.Error(),
+			"ptype": ptype,
+		})
+		return err
+	}
+	return nil
+}
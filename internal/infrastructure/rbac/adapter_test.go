@@ -0,0 +1,33 @@
+package rbac
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCasbinRule_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		ptype string
+		rule  []string
+	}{
+		{name: "policy", ptype: "p", rule: []string{"admin", "/api/v1/todos", "GET"}},
+		{name: "grouping", ptype: "g", rule: []string{"alice@example.com", "admin"}},
+		{name: "all fields", ptype: "p", rule: []string{"a", "b", "c", "d", "e", "f"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			row, err := newCasbinRule(tt.ptype, tt.rule)
+			require.NoError(t, err)
+			assert.Equal(t, append([]string{tt.ptype}, tt.rule...), row.values())
+		})
+	}
+
+	t.Run("too many fields", func(t *testing.T) {
+		_, err := newCasbinRule("p", []string{"a", "b", "c", "d", "e", "f", "g"})
+		assert.Error(t, err)
+	})
+}
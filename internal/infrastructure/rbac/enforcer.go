@@ -0,0 +1,104 @@
+package rbac
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/config"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/db"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/model"
+	"github.com/casbin/casbin/v2/persist"
+	fileadapter "github.com/casbin/casbin/v2/persist/file-adapter"
+)
+
+// loadEnforcer creates a Casbin enforcer for the configured model and policy store.
+// With the postgres store the policy is kept in the casbin_rule table, which is
+// seeded from the policy file when it is empty. The enforcer is safe to modify
+// while requests are being authorized.
+func loadEnforcer(cfg *config.RBACConfig, database *db.Database, logger *logger.Logger) (*casbin.SyncedEnforcer, error) {
+	if cfg.ModelPath == "" {
+		return nil, errors.New("rbac.model_path is required")
+	}
+	m, err := model.NewModelFromFile(cfg.ModelPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load RBAC model: %w", err)
+	}
+
+	var enforcer *casbin.SyncedEnforcer
+	switch cfg.PolicyStore {
+	case config.PolicyStoreFile:
+		if cfg.PolicyPath == "" {
+			return nil, errors.New("rbac.policy_path is required with the file policy store")
+		}
+		enforcer, err = casbin.NewSyncedEnforcer(m, fileadapter.NewAdapter(cfg.PolicyPath))
+		if err != nil {
+			return nil, err
+		}
+	case config.PolicyStorePostgres, "":
+		adapter := NewPostgresAdapter(database, logger)
+		enforcer, err = casbin.NewSyncedEnforcer(m, adapter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load RBAC policy: %w", err)
+		}
+		if err := seedPolicy(enforcer, adapter, cfg, logger); err != nil {
+			return nil, fmt.Errorf("failed to seed RBAC policy: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown rbac.policy_store %q", cfg.PolicyStore)
+	}
+
+	addMatcherFunctions(enforcer)
+	return enforcer, nil
+}
+
+// seedPolicy copies the rules in the policy file into an empty policy store
+// and reloads the enforcer. Replicas booting together may both seed; rules
+// already stored are skipped.
+func seedPolicy(enforcer casbin.IEnforcer, adapter persist.BatchAdapter, cfg *config.RBACConfig, logger *logger.Logger) error {
+	if empty, err := isEmpty(enforcer); err != nil || !empty {
+		return err
+	}
+	if cfg.PolicyPath == "" {
+		logger.Warn("RBAC policy store is empty and no policy file is configured", nil)
+		return nil
+	}
+
+	seed, err := model.NewModelFromFile(cfg.ModelPath)
+	if err != nil {
+		return err
+	}
+	if err := fileadapter.NewAdapter(cfg.PolicyPath).LoadPolicy(seed); err != nil {
+		return err
+	}
+
+	count := 0
+	for _, sec := range []string{"p", "g"} {
+		for ptype, assertion := range seed[sec] {
+			if err := adapter.AddPolicies(sec, ptype, assertion.Policy); err != nil {
+				return err
+			}
+			count += len(assertion.Policy)
+		}
+	}
+
+	logger.Info("Seeded RBAC policy store", map[string]interface{}{
+		"path":  cfg.PolicyPath,
+		"rules": count,
+	})
+	return enforcer.LoadPolicy()
+}
+
+// isEmpty reports whether the enforcer has no policy or grouping rules
+func isEmpty(enforcer casbin.IEnforcer) (bool, error) {
+	policies, err := enforcer.GetPolicy()
+	if err != nil {
+		return false, err
+	}
+	groupings, err := enforcer.GetGroupingPolicy()
+	if err != nil {
+		return false, err
+	}
+	return len(policies) == 0 && len(groupings) == 0, nil
+}
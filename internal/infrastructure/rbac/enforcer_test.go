@@ -0,0 +1,45 @@
+package rbac
+
+import (
+	"testing"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewEnforcer_FileStore(t *testing.T) {
+	enforcer, err := NewEnforcer(&config.RBACConfig{
+		ModelPath:   "model.conf",
+		PolicyPath:  "policy.csv",
+		PolicyStore: config.PolicyStoreFile,
+	}, nil, nil)
+	require.NoError(t, err)
+
+	allowed, err := enforcer.Current().Enforce("alice@example.com", "default", "/api/v1/todos", "POST")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	allowed, err = enforcer.Current().Enforce("bob@example.com", "default", "/api/v1/todos", "POST")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+}
+
+func TestNewEnforcer_InvalidConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config config.RBACConfig
+	}{
+		{name: "missing model", config: config.RBACConfig{PolicyPath: "policy.csv", PolicyStore: config.PolicyStoreFile}},
+		{name: "unreadable model", config: config.RBACConfig{ModelPath: "missing.conf", PolicyPath: "policy.csv", PolicyStore: config.PolicyStoreFile}},
+		{name: "file store without policy", config: config.RBACConfig{ModelPath: "model.conf", PolicyStore: config.PolicyStoreFile}},
+		{name: "unknown store", config: config.RBACConfig{ModelPath: "model.conf", PolicyStore: "redis"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewEnforcer(&tt.config, nil, nil)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package rbac
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+	"github.com/casbin/casbin/v2"
+)
+
+// PolicyExplainer explains the decisions of the permission rules (p) of the
+// active enforcer, and evaluates proposed policies against expected decisions
+type PolicyExplainer struct {
+	enforcer  *Enforcer
+	resources map[string]string
+}
+
+// NewPolicyExplainer creates a new policy explainer for the enforcer.
+// resources maps route group prefixes to the resource names used in Casbin
+// objects, so queries can name route templates as the RBAC middleware does.
+func NewPolicyExplainer(enforcer *Enforcer, resources map[string]string) *PolicyExplainer {
+	return &PolicyExplainer{
+		enforcer:  enforcer,
+		resources: resources,
+	}
+}
+
+// Explain evaluates the query with the active policy and explains the decision
+func (x *PolicyExplainer) Explain(ctx context.Context, query model.PolicyQuery) (*model.PolicyDecision, error) {
+	query.Object = routeObject(x.resources, query.Object)
+	return explain(x.enforcer.Current(), query)
+}
+
+// DryRun evaluates the cases with a proposed policy, given in the format of
+// policy.csv, under the active model. The active policy is not changed.
+func (x *PolicyExplainer) DryRun(ctx context.Context, policy string, cases []model.PolicyCase) (*model.PolicyDryRun, error) {
+	scratch, err := newScratchEnforcer(x.enforcer.Current().GetModel())
+	if err != nil {
+		return nil, err
+	}
+	if err := loadPolicyText(scratch, policy); err != nil {
+		return nil, err
+	}
+
+	run := &model.PolicyDryRun{Results: make([]model.PolicyCaseResult, 0, len(cases))}
+	for _, c := range cases {
+		c.Object = routeObject(x.resources, c.Object)
+		decision, err := explain(scratch, c.PolicyQuery)
+		if err != nil {
+			return nil, err
+		}
+
+		passed := decision.Allowed == c.Allow
+		if !passed {
+			run.Failures++
+		}
+		run.Results = append(run.Results, model.PolicyCaseResult{
+			PolicyDecision: *decision,
+			Expected:       c.Allow,
+			Passed:         passed,
+		})
+	}
+	run.Passed = run.Failures == 0
+	return run, nil
+}
+
+// explain evaluates the query with the enforcer. Allowed decisions name the
+// rule that matched and the role assignments leading to its subject.
+func explain(enforcer casbin.IEnforcer, query model.PolicyQuery) (*model.PolicyDecision, error) {
+	allowed, rule, err := enforcer.EnforceEx(query.Subject, query.Tenant, query.Object, query.Action)
+	if err != nil {
+		return nil, err
+	}
+	roles, err := enforcer.GetImplicitRolesForUser(query.Subject, query.Tenant)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(roles)
+
+	decision := &model.PolicyDecision{
+		PolicyQuery: query,
+		Allowed:     allowed,
+		Roles:       append([]string{}, roles...),
+	}
+	if allowed && len(rule) == 4 {
+		decision.Rule = &model.Policy{Subject: rule[0], Tenant: rule[1], Object: rule[2], Action: rule[3]}
+		decision.RoleChain, err = roleChain(enforcer, query.Subject, rule[0], query.Tenant)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return decision, nil
+}
+
+// roleChain returns the shortest chain of role assignments in the tenant from
+// subject to role, both included, or nil if the subject does not hold the role
+func roleChain(enforcer casbin.IEnforcer, subject, role, tenant string) ([]string, error) {
+	previous := map[string]string{subject: ""}
+	queue := []string{subject}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if current == role {
+			chain := []string{current}
+			for current != subject {
+				current = previous[current]
+				chain = append([]string{current}, chain...)
+			}
+			return chain, nil
+		}
+
+		roles, err := enforcer.GetRolesForUser(current, tenant)
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(roles)
+		for _, next := range roles {
+			if _, seen := previous[next]; !seen {
+				previous[next] = current
+				queue = append(queue, next)
+			}
+		}
+	}
+	return nil, nil
+}
+
+// loadPolicyText adds the rules of a policy in the format of policy.csv to
+// the enforcer. Rules are checked against the model; the first invalid rule
+// is reported with its line number.
+func loadPolicyText(enforcer *casbin.Enforcer, policy string) error {
+	m := enforcer.GetModel()
+	scanner := bufio.NewScanner(strings.NewReader(policy))
+	for line := 1; scanner.Scan(); line++ {
+		rule, err := parseCSVLine(scanner.Text())
+		if err != nil {
+			return model.NewValidationError("policy", fmt.Sprintf("line %d: %v", line, err))
+		}
+		if rule == nil {
+			continue
+		}
+
+		ptype, values := rule[0], rule[1:]
+		sec := ""
+		if ptype != "" {
+			sec = ptype[:1]
+		}
+		assertion, ok := m[sec][ptype]
+		if !ok || (sec != "p" && sec != "g") {
+			return model.NewValidationError("policy", fmt.Sprintf("line %d: %s is not defined by the RBAC model", line, ptype))
+		}
+		if len(assertion.Tokens) != len(values) {
+			return model.NewValidationError("policy", fmt.Sprintf("line %d: the RBAC model expects %d fields for %s, got %d", line, len(assertion.Tokens), ptype, len(values)))
+		}
+
+		if sec == "g" {
+			_, err = enforcer.AddNamedGroupingPolicy(ptype, values)
+		} else {
+			_, err = enforcer.AddNamedPolicy(ptype, values)
+		}
+		if err != nil {
+			return model.NewValidationError("policy", fmt.Sprintf("line %d: %v", line, err))
+		}
+	}
+	return scanner.Err()
+}
+
+// ParsePolicyCases reads policy cases, one per line, in the format
+//
+//	subject, tenant, object, action, allow|deny
+//
+// Empty lines and lines starting with # are skipped.
+func ParsePolicyCases(r io.Reader) ([]model.PolicyCase, error) {
+	var cases []model.PolicyCase
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		fields, err := parseCSVLine(scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if fields == nil {
+			continue
+		}
+		if len(fields) != 5 {
+			return nil, fmt.Errorf("line %d: expected subject, tenant, object, action and allow or deny, got %d fields", line, len(fields))
+		}
+
+		var allow bool
+		switch strings.ToLower(fields[4]) {
+		case "allow":
+			allow = true
+		case "deny":
+			allow = false
+		default:
+			return nil, fmt.Errorf("line %d: expected allow or deny, got %q", line, fields[4])
+		}
+
+		cases = append(cases, model.PolicyCase{
+			PolicyQuery: model.PolicyQuery{
+				Subject: fields[0],
+				Tenant:  fields[1],
+				Object:  fields[2],
+				Action:  fields[3],
+			},
+			Allow: allow,
+		})
+	}
+	return cases, scanner.Err()
+}
+
+// parseCSVLine splits a line of comma separated fields, trimming each one.
+// It returns nil for empty lines and comments.
+func parseCSVLine(line string) ([]string, error) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil, nil
+	}
+
+	reader := csv.NewReader(strings.NewReader(line))
+	reader.TrimLeadingSpace = true
+	fields, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+	return fields, nil
+}
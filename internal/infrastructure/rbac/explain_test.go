@@ -0,0 +1,137 @@
+package rbac
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicyExplainer_Explain(t *testing.T) {
+	enforcer, _ := newFileEnforcer(t)
+	_, err := enforcer.Current().AddGroupingPolicy("carol@example.com", "manager", "default")
+	require.NoError(t, err)
+	_, err = enforcer.Current().AddGroupingPolicy("manager", "admin", "default")
+	require.NoError(t, err)
+	explainer := NewPolicyExplainer(enforcer, map[string]string{"/api/v1/notes": "notes"})
+	ctx := context.Background()
+
+	t.Run("Allowed through a role", func(t *testing.T) {
+		decision, err := explainer.Explain(ctx, model.PolicyQuery{Subject: "alice@example.com", Tenant: "default", Object: "/api/v1/todos", Action: "POST"})
+		require.NoError(t, err)
+		assert.True(t, decision.Allowed)
+		assert.Equal(t, &model.Policy{Subject: "admin", Tenant: "*", Object: "/api/v1/todos", Action: "POST"}, decision.Rule)
+		assert.Equal(t, []string{"alice@example.com", "admin"}, decision.RoleChain)
+		assert.Equal(t, []string{"admin"}, decision.Roles)
+	})
+
+	t.Run("Allowed through inherited roles", func(t *testing.T) {
+		decision, err := explainer.Explain(ctx, model.PolicyQuery{Subject: "carol@example.com", Tenant: "default", Object: "/api/v1/todos/:id", Action: "DELETE"})
+		require.NoError(t, err)
+		assert.True(t, decision.Allowed)
+		assert.Equal(t, []string{"carol@example.com", "manager", "admin"}, decision.RoleChain)
+		assert.Equal(t, []string{"admin", "manager"}, decision.Roles)
+	})
+
+	t.Run("Denied", func(t *testing.T) {
+		decision, err := explainer.Explain(ctx, model.PolicyQuery{Subject: "bob@example.com", Tenant: "default", Object: "/api/v1/todos", Action: "POST"})
+		require.NoError(t, err)
+		assert.False(t, decision.Allowed)
+		assert.Nil(t, decision.Rule)
+		assert.Empty(t, decision.RoleChain)
+		assert.Equal(t, []string{"user"}, decision.Roles)
+	})
+
+	t.Run("Roles of another tenant do not apply", func(t *testing.T) {
+		decision, err := explainer.Explain(ctx, model.PolicyQuery{Subject: "alice@example.com", Tenant: "acme", Object: "/api/v1/todos", Action: "POST"})
+		require.NoError(t, err)
+		assert.False(t, decision.Allowed)
+		assert.Empty(t, decision.Roles)
+	})
+
+	t.Run("Route templates are mapped to resource objects", func(t *testing.T) {
+		decision, err := explainer.Explain(ctx, model.PolicyQuery{Subject: "alice@example.com", Tenant: "default", Object: "/api/v1/notes", Action: "GET"})
+		require.NoError(t, err)
+		assert.Equal(t, "notes:/api/v1/notes", decision.Object)
+	})
+}
+
+func TestPolicyExplainer_DryRun(t *testing.T) {
+	enforcer, _ := newFileEnforcer(t)
+	explainer := NewPolicyExplainer(enforcer, nil)
+	ctx := context.Background()
+
+	policy, err := os.ReadFile("policy.csv")
+	require.NoError(t, err)
+	casesFile, err := os.Open("policy_cases.csv")
+	require.NoError(t, err)
+	defer casesFile.Close()
+	cases, err := ParsePolicyCases(casesFile)
+	require.NoError(t, err)
+	require.NotEmpty(t, cases)
+
+	t.Run("Seed policy passes its cases", func(t *testing.T) {
+		run, err := explainer.DryRun(ctx, string(policy), cases)
+		require.NoError(t, err)
+		for _, result := range run.Results {
+			assert.True(t, result.Passed, "%s %s %s in %s", result.Subject, result.Action, result.Object, result.Tenant)
+		}
+		assert.True(t, run.Passed)
+		assert.Zero(t, run.Failures)
+	})
+
+	t.Run("Proposed policy failing cases", func(t *testing.T) {
+		proposed := strings.Replace(string(policy), "p, user, *, /api/v1/todos, GET\n", "", 1)
+		run, err := explainer.DryRun(ctx, proposed, cases)
+		require.NoError(t, err)
+		assert.False(t, run.Passed)
+		assert.Equal(t, 1, run.Failures)
+	})
+
+	t.Run("Active policy is unchanged", func(t *testing.T) {
+		decision, err := explainer.Explain(ctx, model.PolicyQuery{Subject: "bob@example.com", Tenant: "default", Object: "/api/v1/todos", Action: "GET"})
+		require.NoError(t, err)
+		assert.True(t, decision.Allowed)
+	})
+
+	t.Run("Invalid policy", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			policy string
+		}{
+			{name: "unknown rule type", policy: "p, user, *, /api/v1/todos, GET\nx, user, *"},
+			{name: "wrong number of fields", policy: "# comment\n\np, user, /api/v1/todos, GET"},
+			{name: "unparsable line", policy: `p, "user, *, /api/v1/todos, GET`},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := explainer.DryRun(ctx, tt.policy, cases)
+
+				var validationErr *model.ValidationError
+				require.ErrorAs(t, err, &validationErr)
+				assert.Equal(t, "policy", validationErr.Field)
+				assert.Contains(t, validationErr.Message, "line ")
+			})
+		}
+	})
+}
+
+func TestParsePolicyCases(t *testing.T) {
+	cases, err := ParsePolicyCases(strings.NewReader("# comment\n\nuser, default, /api/v1/todos, GET, allow\nuser, default, /api/v1/todos, POST, DENY\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []model.PolicyCase{
+		{PolicyQuery: model.PolicyQuery{Subject: "user", Tenant: "default", Object: "/api/v1/todos", Action: "GET"}, Allow: true},
+		{PolicyQuery: model.PolicyQuery{Subject: "user", Tenant: "default", Object: "/api/v1/todos", Action: "POST"}, Allow: false},
+	}, cases)
+
+	_, err = ParsePolicyCases(strings.NewReader("user, default, /api/v1/todos, GET"))
+	assert.ErrorContains(t, err, "line 1")
+
+	_, err = ParsePolicyCases(strings.NewReader("user, default, /api/v1/todos, GET, maybe"))
+	assert.ErrorContains(t, err, "allow or deny")
+}
@@ -0,0 +1,212 @@
+package rbac
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/repository"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+	"github.com/casbin/casbin/v2"
+	casbinmodel "github.com/casbin/casbin/v2/model"
+)
+
+var (
+	// policyFields names the fields of a permission rule in validation errors
+	policyFields = []string{"subject", "tenant", "object", "action"}
+
+	// roleAssignmentFields names the fields of a role assignment in validation errors
+	roleAssignmentFields = []string{"subject", "role", "tenant"}
+)
+
+// policyRepository implements the PolicyRepository interface on the active
+// Casbin enforcer. Changes are applied to the enforcer in place, so they take
+// effect immediately, and saved through its adapter.
+type policyRepository struct {
+	enforcer *Enforcer
+	logger   *logger.Logger
+}
+
+// NewPolicyRepository creates a new policy repository for the enforcer
+func NewPolicyRepository(enforcer *Enforcer, logger *logger.Logger) repository.PolicyRepository {
+	return &policyRepository{
+		enforcer: enforcer,
+		logger:   logger,
+	}
+}
+
+// ListPolicies returns every permission rule
+func (r *policyRepository) ListPolicies(ctx context.Context) ([]model.Policy, error) {
+	rules, err := r.enforcer.Current().GetPolicy()
+	if err != nil {
+		return nil, err
+	}
+
+	policies := make([]model.Policy, 0, len(rules))
+	for _, rule := range rules {
+		if len(rule) < 4 {
+			continue
+		}
+		policies = append(policies, model.Policy{
+			Subject: rule[0],
+			Tenant:  rule[1],
+			Object:  rule[2],
+			Action:  rule[3],
+		})
+	}
+	return policies, nil
+}
+
+// AddPolicy adds a permission rule
+func (r *policyRepository) AddPolicy(ctx context.Context, policy model.Policy) error {
+	rule := []string{policy.Subject, policy.Tenant, policy.Object, policy.Action}
+	if err := r.validate("p", rule, policyFields); err != nil {
+		return err
+	}
+
+	added, err := r.enforcer.Current().AddPolicy(rule)
+	if err != nil {
+		r.logger.Error("Failed to add policy", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return err
+	}
+	if !added {
+		return model.ErrConflict
+	}
+	return nil
+}
+
+// RemovePolicy removes a permission rule
+func (r *policyRepository) RemovePolicy(ctx context.Context, policy model.Policy) error {
+	removed, err := r.enforcer.Current().RemovePolicy([]string{policy.Subject, policy.Tenant, policy.Object, policy.Action})
+	if err != nil {
+		r.logger.Error("Failed to remove policy", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return err
+	}
+	if !removed {
+		return model.ErrNotFound
+	}
+	return nil
+}
+
+// ListRoleAssignments returns every role assignment
+func (r *policyRepository) ListRoleAssignments(ctx context.Context) ([]model.RoleAssignment, error) {
+	rules, err := r.enforcer.Current().GetGroupingPolicy()
+	if err != nil {
+		return nil, err
+	}
+
+	assignments := make([]model.RoleAssignment, 0, len(rules))
+	for _, rule := range rules {
+		if len(rule) < 3 {
+			continue
+		}
+		assignments = append(assignments, model.RoleAssignment{
+			Subject: rule[0],
+			Role:    rule[1],
+			Tenant:  rule[2],
+		})
+	}
+	return assignments, nil
+}
+
+// AddRoleAssignment adds a role assignment
+func (r *policyRepository) AddRoleAssignment(ctx context.Context, assignment model.RoleAssignment) error {
+	rule := []string{assignment.Subject, assignment.Role, assignment.Tenant}
+	if err := r.validate("g", rule, roleAssignmentFields); err != nil {
+		return err
+	}
+
+	added, err := r.enforcer.Current().AddGroupingPolicy(rule)
+	if err != nil {
+		r.logger.Error("Failed to add role assignment", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return err
+	}
+	if !added {
+		return model.ErrConflict
+	}
+	return nil
+}
+
+// RemoveRoleAssignment removes a role assignment
+func (r *policyRepository) RemoveRoleAssignment(ctx context.Context, assignment model.RoleAssignment) error {
+	removed, err := r.enforcer.Current().RemoveGroupingPolicy([]string{assignment.Subject, assignment.Role, assignment.Tenant})
+	if err != nil {
+		r.logger.Error("Failed to remove role assignment", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return err
+	}
+	if !removed {
+		return model.ErrNotFound
+	}
+	return nil
+}
+
+// validate checks a rule against the RBAC model. The rule must have as many
+// fields as its definition, and the matcher must evaluate with it in place,
+// which is checked on a scratch enforcer holding only this rule.
+func (r *policyRepository) validate(ptype string, rule []string, fields []string) error {
+	m := r.enforcer.Current().GetModel()
+	assertion, ok := m[ptype][ptype]
+	if !ok {
+		return model.NewValidationError(ptype, "is not defined by the RBAC model")
+	}
+	if len(assertion.Tokens) != len(rule) {
+		return model.NewValidationError(ptype, fmt.Sprintf("the RBAC model expects %d fields, got %d", len(assertion.Tokens), len(rule)))
+	}
+	for i, value := range rule {
+		if strings.TrimSpace(value) == "" || strings.ContainsAny(value, ",\n") {
+			return model.NewValidationError(fields[i], "must be non-empty and must not contain commas or newlines")
+		}
+	}
+
+	scratch, err := newScratchEnforcer(m)
+	if err != nil {
+		return err
+	}
+	if ptype == "g" {
+		_, err = scratch.AddNamedGroupingPolicy(ptype, rule)
+	} else {
+		_, err = scratch.AddNamedPolicy(ptype, rule)
+	}
+	if err != nil {
+		return model.NewValidationError(ptype, err.Error())
+	}
+
+	// Evaluate a request shaped like the rule so matcher functions see its values
+	request := make([]interface{}, len(m["r"]["r"].Tokens))
+	for i := range request {
+		request[i] = ""
+		if ptype == "p" && len(request) == len(rule) {
+			request[i] = rule[i]
+		}
+	}
+	if _, err := scratch.Enforce(request...); err != nil {
+		return model.NewValidationError(ptype, fmt.Sprintf("rejected by the RBAC model: %v", err))
+	}
+	return nil
+}
+
+// newScratchEnforcer creates an enforcer with a copy of the model and no rules
+func newScratchEnforcer(m casbinmodel.Model) (*casbin.Enforcer, error) {
+	scratchModel := m.Copy()
+	for _, sec := range []string{"p", "g"} {
+		for _, ast := range scratchModel[sec] {
+			ast.Policy = nil
+			ast.PolicyMap = map[string]int{}
+		}
+	}
+	scratch, err := casbin.NewEnforcer(scratchModel)
+	if err != nil {
+		return nil, err
+	}
+	addMatcherFunctions(scratch)
+	return scratch, nil
+}
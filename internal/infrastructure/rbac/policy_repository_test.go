@@ -0,0 +1,82 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/config"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicyRepository(t *testing.T) {
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+	// The file adapter does not save changes, so policy.csv is left untouched
+	enforcer, err := NewEnforcer(&config.RBACConfig{
+		ModelPath:   "model.conf",
+		PolicyPath:  "policy.csv",
+		PolicyStore: config.PolicyStoreFile,
+	}, nil, log)
+	require.NoError(t, err)
+	repo := NewPolicyRepository(enforcer, log)
+	ctx := context.Background()
+
+	t.Run("Added policies are enforced immediately", func(t *testing.T) {
+		allowed, _ := enforcer.Current().Enforce("bob@example.com", "default", "/api/v1/todos", "POST")
+		assert.False(t, allowed)
+
+		require.NoError(t, repo.AddPolicy(ctx, model.Policy{Subject: "user", Tenant: "*", Object: "/api/v1/todos", Action: "POST"}))
+		allowed, _ = enforcer.Current().Enforce("bob@example.com", "default", "/api/v1/todos", "POST")
+		assert.True(t, allowed)
+
+		policies, err := repo.ListPolicies(ctx)
+		require.NoError(t, err)
+		assert.Contains(t, policies, model.Policy{Subject: "user", Tenant: "*", Object: "/api/v1/todos", Action: "POST"})
+
+		require.NoError(t, repo.RemovePolicy(ctx, model.Policy{Subject: "user", Tenant: "*", Object: "/api/v1/todos", Action: "POST"}))
+		allowed, _ = enforcer.Current().Enforce("bob@example.com", "default", "/api/v1/todos", "POST")
+		assert.False(t, allowed)
+	})
+
+	t.Run("Role assignments are enforced immediately", func(t *testing.T) {
+		require.NoError(t, repo.AddRoleAssignment(ctx, model.RoleAssignment{Subject: "carol@example.com", Role: "admin", Tenant: "default"}))
+		allowed, _ := enforcer.Current().Enforce("carol@example.com", "default", "/api/v1/todos/:id", "DELETE")
+		assert.True(t, allowed)
+
+		assignments, err := repo.ListRoleAssignments(ctx)
+		require.NoError(t, err)
+		assert.Contains(t, assignments, model.RoleAssignment{Subject: "carol@example.com", Role: "admin", Tenant: "default"})
+
+		require.NoError(t, repo.RemoveRoleAssignment(ctx, model.RoleAssignment{Subject: "carol@example.com", Role: "admin", Tenant: "default"}))
+		allowed, _ = enforcer.Current().Enforce("carol@example.com", "default", "/api/v1/todos/:id", "DELETE")
+		assert.False(t, allowed)
+	})
+
+	t.Run("Duplicate and missing rules", func(t *testing.T) {
+		err := repo.AddPolicy(ctx, model.Policy{Subject: "admin", Tenant: "*", Object: "/api/v1/todos", Action: "GET"})
+		assert.ErrorIs(t, err, model.ErrConflict)
+
+		err = repo.RemovePolicy(ctx, model.Policy{Subject: "nobody", Tenant: "*", Object: "/api/v1/todos", Action: "GET"})
+		assert.ErrorIs(t, err, model.ErrNotFound)
+
+		err = repo.AddRoleAssignment(ctx, model.RoleAssignment{Subject: "alice@example.com", Role: "admin", Tenant: "default"})
+		assert.ErrorIs(t, err, model.ErrConflict)
+
+		err = repo.RemoveRoleAssignment(ctx, model.RoleAssignment{Subject: "nobody@example.com", Role: "admin", Tenant: "default"})
+		assert.ErrorIs(t, err, model.ErrNotFound)
+	})
+
+	t.Run("Rules are validated against the model", func(t *testing.T) {
+		err := repo.AddPolicy(ctx, model.Policy{Subject: "user", Tenant: "*", Object: "/api/v1/a,b", Action: "GET"})
+		var validationErr *model.ValidationError
+		require.True(t, errors.As(err, &validationErr))
+		assert.Equal(t, "object", validationErr.Field)
+
+		err = repo.AddRoleAssignment(ctx, model.RoleAssignment{Subject: "carol@example.com", Role: "", Tenant: "default"})
+		require.True(t, errors.As(err, &validationErr))
+		assert.Equal(t, "role", validationErr.Field)
+	})
+}
@@ -0,0 +1,125 @@
+package rbac
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/config"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFileEnforcer copies the model and policy into a temporary directory and
+// loads an enforcer from the copies with the file policy store
+func newFileEnforcer(t *testing.T) (*Enforcer, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	for _, name := range []string{"model.conf", "policy.csv"} {
+		data, err := os.ReadFile(name)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), data, 0o600))
+	}
+
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+	enforcer, err := NewEnforcer(&config.RBACConfig{
+		ModelPath:   filepath.Join(dir, "model.conf"),
+		PolicyPath:  filepath.Join(dir, "policy.csv"),
+		PolicyStore: config.PolicyStoreFile,
+	}, nil, log)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = enforcer.Close() })
+
+	return enforcer, filepath.Join(dir, "policy.csv")
+}
+
+// appendRule appends a line to the policy file
+func appendRule(t *testing.T, path, line string) {
+	t.Helper()
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	require.NoError(t, err)
+	_, err = f.WriteString("\n" + line + "\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+}
+
+func TestEnforcer_Reload(t *testing.T) {
+	enforcer, policyPath := newFileEnforcer(t)
+	assert.Equal(t, model.PolicyReloadNone, enforcer.Status().LastStatus)
+
+	allowed, _ := enforcer.Current().Enforce("carol@example.com", "default", "/api/v1/todos", "GET")
+	assert.False(t, allowed)
+
+	appendRule(t, policyPath, "g, carol@example.com, user, default")
+	require.NoError(t, enforcer.Reload())
+
+	allowed, _ = enforcer.Current().Enforce("carol@example.com", "default", "/api/v1/todos", "GET")
+	assert.True(t, allowed)
+
+	status := enforcer.Status()
+	assert.Equal(t, int64(1), status.ReloadCount)
+	assert.Equal(t, model.PolicyReloadOK, status.LastStatus)
+	assert.NotNil(t, status.LastReloadAt)
+
+	// A broken policy keeps the active enforcer
+	appendRule(t, policyPath, "p, admin")
+	assert.Error(t, enforcer.Reload())
+
+	allowed, _ = enforcer.Current().Enforce("carol@example.com", "default", "/api/v1/todos", "GET")
+	assert.True(t, allowed)
+
+	status = enforcer.Status()
+	assert.Equal(t, int64(1), status.ReloadCount)
+	assert.Equal(t, int64(1), status.FailureCount)
+	assert.Equal(t, model.PolicyReloadFailed, status.LastStatus)
+	assert.NotEmpty(t, status.LastError)
+}
+
+func TestEnforcer_Watch(t *testing.T) {
+	enforcer, policyPath := newFileEnforcer(t)
+	require.NoError(t, enforcer.Watch())
+	assert.True(t, enforcer.Status().Watching)
+
+	appendRule(t, policyPath, "g, carol@example.com, admin, default")
+
+	assert.Eventually(t, func() bool {
+		allowed, _ := enforcer.Current().Enforce("carol@example.com", "default", "/api/v1/todos", "POST")
+		return allowed
+	}, 5*time.Second, 50*time.Millisecond)
+	assert.Equal(t, model.PolicyReloadOK, enforcer.Status().LastStatus)
+
+	require.NoError(t, enforcer.Close())
+	assert.False(t, enforcer.Status().Watching)
+}
+
+func TestEnforcer_Poll(t *testing.T) {
+	enforcer, policyPath := newFileEnforcer(t)
+	require.NoError(t, enforcer.Poll(20*time.Millisecond))
+
+	// Rules changed elsewhere are picked up without a file event
+	appendRule(t, policyPath, "g, carol@example.com, admin, default")
+
+	assert.Eventually(t, func() bool {
+		allowed, _ := enforcer.Current().Enforce("carol@example.com", "default", "/api/v1/todos", "POST")
+		return allowed
+	}, 5*time.Second, 20*time.Millisecond)
+
+	require.NoError(t, enforcer.Close())
+	reloads := enforcer.Status().ReloadCount
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, reloads, enforcer.Status().ReloadCount)
+}
+
+func TestNewStaticEnforcer(t *testing.T) {
+	enforcer, _ := newFileEnforcer(t)
+	static := NewStaticEnforcer(enforcer.Current())
+
+	assert.Error(t, static.Reload())
+	assert.Error(t, static.Watch())
+	assert.Error(t, static.Poll(time.Second))
+}
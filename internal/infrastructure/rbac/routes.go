@@ -0,0 +1,153 @@
+package rbac
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/auth"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+	"github.com/casbin/casbin/v2"
+)
+
+// RouteAuthorizer authorizes calls to API routes with the route rules (p) of
+// the active enforcer
+type RouteAuthorizer struct {
+	enforcer  *Enforcer
+	resources map[string]string
+	logger    *logger.Logger
+}
+
+// NewRouteAuthorizer creates a new route authorizer for the enforcer.
+// resources maps route group prefixes to the resource names used in Casbin objects.
+func NewRouteAuthorizer(enforcer *Enforcer, resources map[string]string, logger *logger.Logger) *RouteAuthorizer {
+	return &RouteAuthorizer{
+		enforcer:  enforcer,
+		resources: resources,
+		logger:    logger,
+	}
+}
+
+// Object returns the Casbin object for a route template
+func (a *RouteAuthorizer) Object(route string) string {
+	return routeObject(a.resources, route)
+}
+
+// AuthorizeRoute reports whether a rule in the principal's tenant allows the
+// principal's email, or one of the roles asserted by its token issuer, to call
+// the route template with the HTTP method. Principals with scopes are only
+// authorized as the roles their scopes name. Superadmins may call every route.
+func (a *RouteAuthorizer) AuthorizeRoute(ctx context.Context, principal *auth.Principal, method, route string) (bool, error) {
+	if principal.IsSuperAdmin {
+		return true, nil
+	}
+
+	enforcer := a.enforcer.Current()
+	obj := a.Object(route)
+
+	subjects, err := subjects(enforcer, principal)
+	if err != nil {
+		return false, err
+	}
+	for _, subject := range subjects {
+		allowed, err := enforcer.Enforce(subject, principal.Tenant, obj, method)
+		if err != nil {
+			a.logger.Error("Casbin enforcement error", map[string]interface{}{
+				"error":  err.Error(),
+				"email":  principal.Email,
+				"tenant": principal.Tenant,
+				"object": obj,
+				"method": method,
+			})
+			return false, err
+		}
+		if allowed {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ImplicitRoles returns the roles the principal holds in its tenant: the roles
+// assigned to its email and the roles asserted by its token issuer, with the
+// roles those inherit, sorted by name. Principals with scopes only hold the
+// roles their scopes name.
+func (a *RouteAuthorizer) ImplicitRoles(ctx context.Context, principal *auth.Principal) ([]string, error) {
+	held, err := heldRoles(a.enforcer.Current(), principal)
+	if err != nil {
+		return nil, err
+	}
+
+	roles := make([]string, 0, len(held))
+	for role := range held {
+		if len(principal.Scopes) == 0 || slices.Contains(principal.Scopes, role) {
+			roles = append(roles, role)
+		}
+	}
+	sort.Strings(roles)
+	return roles, nil
+}
+
+// subjects returns the Casbin subjects the principal is authorized as: its
+// email and the roles asserted by its token issuer, or, for principals with
+// scopes, the roles named by its scopes that it holds
+func subjects(enforcer casbin.IEnforcer, principal *auth.Principal) ([]string, error) {
+	if len(principal.Scopes) == 0 {
+		return append([]string{principal.Email}, principal.Roles...), nil
+	}
+
+	held, err := heldRoles(enforcer, principal)
+	if err != nil {
+		return nil, err
+	}
+	var scoped []string
+	for _, scope := range principal.Scopes {
+		if held[scope] {
+			scoped = append(scoped, scope)
+		}
+	}
+	return scoped, nil
+}
+
+// heldRoles returns the roles the principal holds in its tenant: the roles
+// asserted by its token issuer and the roles those and its email inherit
+func heldRoles(enforcer casbin.IEnforcer, principal *auth.Principal) (map[string]bool, error) {
+	held := make(map[string]bool)
+	for _, role := range principal.Roles {
+		held[role] = true
+	}
+
+	subjects := append([]string{principal.Email}, principal.Roles...)
+	for _, subject := range subjects {
+		roles, err := enforcer.GetImplicitRolesForUser(subject, principal.Tenant)
+		if err != nil {
+			return nil, err
+		}
+		for _, role := range roles {
+			held[role] = true
+		}
+	}
+	return held, nil
+}
+
+// routeObject returns the Casbin object for a route template: the template
+// itself, prefixed with "<resource>:" when it belongs to a named route group.
+// The longest matching group prefix wins.
+func routeObject(resources map[string]string, route string) string {
+	resource, longest := "", -1
+	for prefix, name := range resources {
+		prefix = strings.TrimSuffix(prefix, "/")
+		if route != prefix && !strings.HasPrefix(route, prefix+"/") {
+			continue
+		}
+		if len(prefix) > longest {
+			resource, longest = name, len(prefix)
+		}
+	}
+
+	if resource == "" {
+		return route
+	}
+	return resource + ":" + route
+}
@@ -0,0 +1,99 @@
+package rbac
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/config"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/auth"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouteAuthorizer(t *testing.T) {
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+	enforcer, err := NewEnforcer(&config.RBACConfig{
+		ModelPath:   "model.conf",
+		PolicyPath:  "policy.csv",
+		PolicyStore: config.PolicyStoreFile,
+	}, nil, log)
+	require.NoError(t, err)
+	_, err = enforcer.Current().AddGroupingPolicy("admin", "user", "default")
+	require.NoError(t, err)
+	_, err = enforcer.Current().AddGroupingPolicy("alice@example.com", "user", "acme")
+	require.NoError(t, err)
+	_, err = enforcer.Current().AddGroupingPolicy(auth.ServiceSubject("batch"), "user", "default")
+	require.NoError(t, err)
+	authorizer := NewRouteAuthorizer(enforcer, nil, log)
+
+	alice := &auth.Principal{Email: "alice@example.com", Tenant: "default"}
+	bob := &auth.Principal{Email: "bob@example.com", Tenant: "default"}
+	carol := &auth.Principal{Email: "carol@example.com", Tenant: "default", Roles: []string{"admin"}}
+	scopedAlice := &auth.Principal{Email: "alice@example.com", Tenant: "default", Scopes: []string{"user"}}
+	batch := &auth.Principal{Email: auth.ServiceSubject("batch"), Tenant: "default", Service: "batch"}
+
+	t.Run("AuthorizeRoute", func(t *testing.T) {
+		tests := []struct {
+			name      string
+			principal *auth.Principal
+			method    string
+			route     string
+			allowed   bool
+		}{
+			{name: "user lists todos", principal: bob, method: "GET", route: "/api/v1/todos", allowed: true},
+			{name: "user cannot create todos", principal: bob, method: "POST", route: "/api/v1/todos", allowed: false},
+			{name: "admin creates todos", principal: alice, method: "POST", route: "/api/v1/todos", allowed: true},
+			{name: "asserted role grants access", principal: carol, method: "POST", route: "/api/v1/todos", allowed: true},
+			{name: "unknown route", principal: alice, method: "GET", route: "/api/v1/unknown", allowed: false},
+			{name: "scopes limit the roles used", principal: scopedAlice, method: "POST", route: "/api/v1/todos", allowed: false},
+			{name: "scoped role grants access", principal: scopedAlice, method: "GET", route: "/api/v1/todos", allowed: true},
+			{name: "scopes grant no roles that are not held", principal: &auth.Principal{Email: "bob@example.com", Tenant: "default", Scopes: []string{"admin"}}, method: "GET", route: "/api/v1/todos", allowed: false},
+			{name: "service with a role", principal: batch, method: "GET", route: "/api/v1/todos", allowed: true},
+			{name: "superadmin calls any route", principal: &auth.Principal{Email: "admin@example.com", Tenant: "default", IsSuperAdmin: true}, method: "DELETE", route: "/api/v1/unknown", allowed: true},
+			{name: "superadmin email without superadmin privileges", principal: &auth.Principal{Email: "admin@example.com", Tenant: "default"}, method: "POST", route: "/api/v1/todos", allowed: false},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				allowed, err := authorizer.AuthorizeRoute(context.Background(), tt.principal, tt.method, tt.route)
+				require.NoError(t, err)
+				assert.Equal(t, tt.allowed, allowed)
+			})
+		}
+	})
+
+	t.Run("ImplicitRoles", func(t *testing.T) {
+		tests := []struct {
+			name      string
+			principal *auth.Principal
+			roles     []string
+		}{
+			{name: "inherited roles are resolved", principal: alice, roles: []string{"admin", "user"}},
+			{name: "roles are scoped to the tenant", principal: &auth.Principal{Email: "alice@example.com", Tenant: "acme"}, roles: []string{"user"}},
+			{name: "asserted roles are included", principal: carol, roles: []string{"admin", "user"}},
+			{name: "caller without roles", principal: &auth.Principal{Email: "dave@example.com", Tenant: "default"}, roles: []string{}},
+			{name: "scopes limit the roles held", principal: scopedAlice, roles: []string{"user"}},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				roles, err := authorizer.ImplicitRoles(context.Background(), tt.principal)
+				require.NoError(t, err)
+				assert.Equal(t, tt.roles, roles)
+			})
+		}
+	})
+}
+
+func TestRouteAuthorizer_Object(t *testing.T) {
+	authorizer := NewRouteAuthorizer(nil, map[string]string{
+		"/api/v1/todos/": "todos",
+		"/api/v1":        "api",
+	}, nil)
+
+	assert.Equal(t, "todos:/api/v1/todos/:id", authorizer.Object("/api/v1/todos/:id"))
+	assert.Equal(t, "todos:/api/v1/todos", authorizer.Object("/api/v1/todos"))
+	assert.Equal(t, "api:/api/v1/users", authorizer.Object("/api/v1/users"))
+	assert.Equal(t, "/healthz", authorizer.Object("/healthz"))
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/repository"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/db"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+	"gorm.io/gorm"
+)
+
+// apiKeyRepository implements the APIKeyRepository interface
+type apiKeyRepository struct {
+	db     *db.Database
+	logger *logger.Logger
+}
+
+// NewAPIKeyRepository creates a new API key repository
+func NewAPIKeyRepository(db *db.Database, logger *logger.Logger) repository.APIKeyRepository {
+	return &apiKeyRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Create adds a new API key to the repository
+func (r *apiKeyRepository) Create(ctx context.Context, key *model.APIKey) error {
+	result := r.db.DB.WithContext(ctx).Create(key)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+			return model.ErrConflict
+		}
+		r.logger.Error("Failed to create API key", map[string]interface{}{
+			"error": result.Error.Error(),
+		})
+		return result.Error
+	}
+	return nil
+}
+
+// GetByID retrieves an API key by its ID
+func (r *apiKeyRepository) GetByID(ctx context.Context, id uint) (*model.APIKey, error) {
+	return r.get(ctx, "id = ?", id)
+}
+
+// GetByPrefix retrieves an API key by its public prefix
+func (r *apiKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*model.APIKey, error) {
+	return r.get(ctx, "prefix = ?", prefix)
+}
+
+// List returns every API key, ordered by ID
+func (r *apiKeyRepository) List(ctx context.Context) ([]model.APIKey, error) {
+	var keys []model.APIKey
+	result := r.db.DB.WithContext(ctx).Order("id").Find(&keys)
+	if result.Error != nil {
+		r.logger.Error("Failed to list API keys", map[string]interface{}{
+			"error": result.Error.Error(),
+		})
+		return nil, result.Error
+	}
+	return keys, nil
+}
+
+// Update saves the name, scopes and expiry of an API key
+func (r *apiKeyRepository) Update(ctx context.Context, key *model.APIKey) error {
+	result := r.db.DB.WithContext(ctx).
+		Model(key).
+		Select("name", "scopes", "expires_at").
+		Updates(key)
+	if result.Error != nil {
+		r.logger.Error("Failed to update API key", map[string]interface{}{
+			"error": result.Error.Error(),
+			"id":    key.ID,
+		})
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return model.ErrNotFound
+	}
+
+	// Reload to pick up columns maintained by the database
+	return r.db.DB.WithContext(ctx).First(key, key.ID).Error
+}
+
+// Delete removes an API key by its ID
+func (r *apiKeyRepository) Delete(ctx context.Context, id uint) error {
+	result := r.db.DB.WithContext(ctx).Delete(&model.APIKey{}, id)
+	if result.Error != nil {
+		r.logger.Error("Failed to delete API key", map[string]interface{}{
+			"error": result.Error.Error(),
+			"id":    id,
+		})
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return model.ErrNotFound
+	}
+	return nil
+}
+
+// TouchLastUsed records when an API key was last used
+func (r *apiKeyRepository) TouchLastUsed(ctx context.Context, id uint, usedAt time.Time) error {
+	result := r.db.DB.WithContext(ctx).
+		Model(&model.APIKey{}).
+		Where("id = ?", id).
+		UpdateColumn("last_used_at", usedAt)
+	if result.Error != nil {
+		r.logger.Error("Failed to record API key use", map[string]interface{}{
+			"error": result.Error.Error(),
+			"id":    id,
+		})
+		return result.Error
+	}
+	return nil
+}
+
+// get retrieves the API key matching the condition
+func (r *apiKeyRepository) get(ctx context.Context, query string, arg interface{}) (*model.APIKey, error) {
+	var key model.APIKey
+	result := r.db.DB.WithContext(ctx).Where(query, arg).First(&key)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, model.ErrNotFound
+		}
+		r.logger.Error("Failed to get API key", map[string]interface{}{
+			"error": result.Error.Error(),
+		})
+		return nil, result.Error
+	}
+	return &key, nil
+}
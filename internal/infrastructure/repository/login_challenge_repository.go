@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/repository"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/db"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+	"gorm.io/gorm"
+)
+
+// loginChallengeRepository implements the LoginChallengeRepository interface
+type loginChallengeRepository struct {
+	db     *db.Database
+	logger *logger.Logger
+}
+
+// NewLoginChallengeRepository creates a new login challenge repository
+func NewLoginChallengeRepository(db *db.Database, logger *logger.Logger) repository.LoginChallengeRepository {
+	return &loginChallengeRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Create adds a new login challenge to the repository
+func (r *loginChallengeRepository) Create(ctx context.Context, challenge *model.LoginChallenge) error {
+	result := r.db.DB.WithContext(ctx).Create(challenge)
+	if result.Error != nil {
+		r.logger.Error("Failed to create login challenge", map[string]interface{}{
+			"error": result.Error.Error(),
+		})
+		return result.Error
+	}
+	return nil
+}
+
+// GetByID retrieves a login challenge by its ID
+func (r *loginChallengeRepository) GetByID(ctx context.Context, id uint) (*model.LoginChallenge, error) {
+	var challenge model.LoginChallenge
+	result := r.db.DB.WithContext(ctx).First(&challenge, id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, model.ErrNotFound
+		}
+		r.logger.Error("Failed to get login challenge", map[string]interface{}{
+			"error": result.Error.Error(),
+			"id":    id,
+		})
+		return nil, result.Error
+	}
+	return &challenge, nil
+}
+
+// GetLatestActive retrieves the most recent unused, unexpired login challenge for the email
+func (r *loginChallengeRepository) GetLatestActive(ctx context.Context, email string) (*model.LoginChallenge, error) {
+	var challenge model.LoginChallenge
+	result := r.db.DB.WithContext(ctx).
+		Where("email = ? AND used_at IS NULL AND expires_at > ?", email, time.Now()).
+		Order("id DESC").
+		First(&challenge)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, model.ErrNotFound
+		}
+		r.logger.Error("Failed to get login challenge", map[string]interface{}{
+			"error": result.Error.Error(),
+		})
+		return nil, result.Error
+	}
+	return &challenge, nil
+}
+
+// RecordAttempt counts an attempt to enter the code of the challenge
+func (r *loginChallengeRepository) RecordAttempt(ctx context.Context, id uint, maxAttempts int) (bool, error) {
+	// The conditional update lets concurrent guesses share the attempt budget
+	// rather than each reading the same count
+	result := r.db.DB.WithContext(ctx).
+		Model(&model.LoginChallenge{}).
+		Where("id = ? AND attempts < ?", id, maxAttempts).
+		Update("attempts", gorm.Expr("attempts + 1"))
+	if result.Error != nil {
+		r.logger.Error("Failed to record login code attempt", map[string]interface{}{
+			"error": result.Error.Error(),
+			"id":    id,
+		})
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Consume marks the challenge as used
+func (r *loginChallengeRepository) Consume(ctx context.Context, id uint) error {
+	result := r.db.DB.WithContext(ctx).
+		Model(&model.LoginChallenge{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		r.logger.Error("Failed to consume login challenge", map[string]interface{}{
+			"error": result.Error.Error(),
+			"id":    id,
+		})
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return model.ErrConflict
+	}
+	return nil
+}
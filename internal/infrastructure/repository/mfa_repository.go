@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/repository"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/db"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// mfaRepository implements the MFARepository interface
+type mfaRepository struct {
+	db     *db.Database
+	logger *logger.Logger
+}
+
+// NewMFARepository creates a new multi-factor authentication repository
+func NewMFARepository(db *db.Database, logger *logger.Logger) repository.MFARepository {
+	return &mfaRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// GetTOTP retrieves the TOTP factor of the user
+func (r *mfaRepository) GetTOTP(ctx context.Context, userID uint) (*model.TOTPFactor, error) {
+	var factor model.TOTPFactor
+	result := r.db.DB.WithContext(ctx).Where("user_id = ?", userID).First(&factor)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, model.ErrNotFound
+		}
+		r.logger.Error("Failed to get TOTP factor", map[string]interface{}{
+			"error":   result.Error.Error(),
+			"user_id": userID,
+		})
+		return nil, result.Error
+	}
+	return &factor, nil
+}
+
+// SaveTOTP stores a pending TOTP factor, replacing any factor the user has
+func (r *mfaRepository) SaveTOTP(ctx context.Context, factor *model.TOTPFactor) error {
+	factor.ConfirmedAt = nil
+	factor.LastStep = 0
+	result := r.db.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"secret", "confirmed_at", "last_step", "created_at", "updated_at"}),
+	}).Create(factor)
+	if result.Error != nil {
+		r.logger.Error("Failed to save TOTP factor", map[string]interface{}{
+			"error":   result.Error.Error(),
+			"user_id": factor.UserID,
+		})
+		return result.Error
+	}
+	return nil
+}
+
+// ConfirmTOTP activates the pending TOTP factor of the user and replaces their recovery codes
+func (r *mfaRepository) ConfirmTOTP(ctx context.Context, userID uint, step int64, recoveryCodeHashes []string) error {
+	err := r.db.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.TOTPFactor{}).
+			Where("user_id = ? AND confirmed_at IS NULL", userID).
+			Updates(map[string]interface{}{"confirmed_at": time.Now(), "last_step": step})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return model.ErrConflict
+		}
+		return replaceRecoveryCodes(tx, userID, recoveryCodeHashes)
+	})
+	if err != nil && !errors.Is(err, model.ErrConflict) {
+		r.logger.Error("Failed to confirm TOTP factor", map[string]interface{}{
+			"error":   err.Error(),
+			"user_id": userID,
+		})
+	}
+	return err
+}
+
+// UseTOTPStep records that a code for the time step was accepted
+func (r *mfaRepository) UseTOTPStep(ctx context.Context, userID uint, step int64) (bool, error) {
+	// Only one request can advance the step, so a code observed in transit
+	// cannot be replayed while it is still valid
+	result := r.db.DB.WithContext(ctx).
+		Model(&model.TOTPFactor{}).
+		Where("user_id = ? AND last_step < ?", userID, step).
+		Update("last_step", step)
+	if result.Error != nil {
+		r.logger.Error("Failed to record TOTP step", map[string]interface{}{
+			"error":   result.Error.Error(),
+			"user_id": userID,
+		})
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// DeleteTOTP removes the TOTP factor and recovery codes of the user
+func (r *mfaRepository) DeleteTOTP(ctx context.Context, userID uint) error {
+	err := r.db.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&model.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&model.TOTPFactor{}).Error
+	})
+	if err != nil {
+		r.logger.Error("Failed to delete TOTP factor", map[string]interface{}{
+			"error":   err.Error(),
+			"user_id": userID,
+		})
+	}
+	return err
+}
+
+// ReplaceRecoveryCodes replaces the recovery codes of the user with the given hashes
+func (r *mfaRepository) ReplaceRecoveryCodes(ctx context.Context, userID uint, codeHashes []string) error {
+	err := r.db.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return replaceRecoveryCodes(tx, userID, codeHashes)
+	})
+	if err != nil {
+		r.logger.Error("Failed to replace recovery codes", map[string]interface{}{
+			"error":   err.Error(),
+			"user_id": userID,
+		})
+	}
+	return err
+}
+
+// UseRecoveryCode marks the unused recovery code of the user with the hash as used
+func (r *mfaRepository) UseRecoveryCode(ctx context.Context, userID uint, codeHash string) error {
+	result := r.db.DB.WithContext(ctx).
+		Model(&model.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		r.logger.Error("Failed to use recovery code", map[string]interface{}{
+			"error":   result.Error.Error(),
+			"user_id": userID,
+		})
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return model.ErrNotFound
+	}
+	return nil
+}
+
+// CountRecoveryCodes returns how many unused recovery codes the user has
+func (r *mfaRepository) CountRecoveryCodes(ctx context.Context, userID uint) (int, error) {
+	var count int64
+	result := r.db.DB.WithContext(ctx).
+		Model(&model.RecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count)
+	if result.Error != nil {
+		r.logger.Error("Failed to count recovery codes", map[string]interface{}{
+			"error":   result.Error.Error(),
+			"user_id": userID,
+		})
+		return 0, result.Error
+	}
+	return int(count), nil
+}
+
+// replaceRecoveryCodes deletes the recovery codes of the user and stores the
+// given hashes in their place within the transaction
+func replaceRecoveryCodes(tx *gorm.DB, userID uint, codeHashes []string) error {
+	if err := tx.Where("user_id = ?", userID).Delete(&model.RecoveryCode{}).Error; err != nil {
+		return err
+	}
+	if len(codeHashes) == 0 {
+		return nil
+	}
+	codes := make([]model.RecoveryCode, len(codeHashes))
+	for i, hash := range codeHashes {
+		codes[i] = model.RecoveryCode{UserID: userID, CodeHash: hash}
+	}
+	return tx.Create(&codes).Error
+}
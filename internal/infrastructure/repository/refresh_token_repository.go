@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/repository"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/db"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+	"gorm.io/gorm"
+)
+
+// refreshTokenRepository implements the RefreshTokenRepository interface
+type refreshTokenRepository struct {
+	db     *db.Database
+	logger *logger.Logger
+}
+
+// NewRefreshTokenRepository creates a new refresh token repository
+func NewRefreshTokenRepository(db *db.Database, logger *logger.Logger) repository.RefreshTokenRepository {
+	return &refreshTokenRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Create adds a new refresh token to the repository
+func (r *refreshTokenRepository) Create(ctx context.Context, token *model.RefreshToken) error {
+	result := r.db.DB.WithContext(ctx).Create(token)
+	if result.Error != nil {
+		r.logger.Error("Failed to create refresh token", map[string]interface{}{
+			"error": result.Error.Error(),
+		})
+		return result.Error
+	}
+	return nil
+}
+
+// GetByHash retrieves a refresh token by the hash of its opaque value
+func (r *refreshTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*model.RefreshToken, error) {
+	var token model.RefreshToken
+	result := r.db.DB.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&token)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, model.ErrNotFound
+		}
+		r.logger.Error("Failed to get refresh token", map[string]interface{}{
+			"error": result.Error.Error(),
+		})
+		return nil, result.Error
+	}
+	return &token, nil
+}
+
+// Rotate marks the current token as rotated and stores its replacement atomically
+func (r *refreshTokenRepository) Rotate(ctx context.Context, current *model.RefreshToken, next *model.RefreshToken) error {
+	err := r.db.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Only one caller can win the conditional update, so concurrent use of
+		// the same token is detected as reuse rather than forking the family
+		result := tx.Model(&model.RefreshToken{}).
+			Where("id = ? AND rotated_at IS NULL AND revoked_at IS NULL", current.ID).
+			Update("rotated_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return model.ErrConflict
+		}
+
+		return tx.Create(next).Error
+	})
+	if err != nil && !errors.Is(err, model.ErrConflict) {
+		r.logger.Error("Failed to rotate refresh token", map[string]interface{}{
+			"error": err.Error(),
+			"id":    current.ID,
+		})
+	}
+	return err
+}
+
+// RevokeFamily revokes every unrevoked token in the family
+func (r *refreshTokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	result := r.db.DB.WithContext(ctx).
+		Model(&model.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		r.logger.Error("Failed to revoke refresh token family", map[string]interface{}{
+			"error":     result.Error.Error(),
+			"family_id": familyID,
+		})
+		return result.Error
+	}
+	return nil
+}
+
+// RevokeUser revokes every unrevoked token of the user
+func (r *refreshTokenRepository) RevokeUser(ctx context.Context, userID uint) error {
+	result := r.db.DB.WithContext(ctx).
+		Model(&model.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		r.logger.Error("Failed to revoke refresh tokens of user", map[string]interface{}{
+			"error":   result.Error.Error(),
+			"user_id": userID,
+		})
+		return result.Error
+	}
+	return nil
+}
@@ -2,14 +2,35 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/auth"
 	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
 	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/repository"
 	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/db"
 	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+	"gorm.io/gorm"
 )
 
-// todoRepository implements the TodoRepository interface
+// todoSortColumns maps sortable fields to their columns
+var todoSortColumns = map[string]string{
+	repository.TodoSortID:        "id",
+	repository.TodoSortTitle:     "title",
+	repository.TodoSortCompleted: "completed",
+	repository.TodoSortCreatedAt: "created_at",
+	repository.TodoSortUpdatedAt: "updated_at",
+}
+
+// likeEscaper escapes LIKE wildcards so title filters match literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// errNoTenant is returned when a todo query runs without a tenant in its context
+var errNoTenant = errors.New("todo repository called without a tenant in the context")
+
+// todoRepository implements the TodoRepository interface.
+// Every query is scoped to the tenant of the principal in the context.
 type todoRepository struct {
 	db     *db.Database
 	logger *logger.Logger
@@ -23,22 +44,93 @@ func NewTodoRepository(db *db.Database, logger *logger.Logger) repository.TodoRe
 	}
 }
 
-// GetAll retrieves all todos from the repository
-func (r *todoRepository) GetAll(ctx context.Context) ([]model.Todo, error) {
+// List retrieves a page of the owner's todos matching the options
+func (r *todoRepository) List(ctx context.Context, opts repository.TodoListOptions) ([]model.Todo, int64, error) {
+	column, ok := todoSortColumns[opts.Sort.Field]
+	if !ok {
+		return nil, 0, fmt.Errorf("unsupported sort field %q", opts.Sort.Field)
+	}
+
+	query := r.filtered(ctx, opts)
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Model(&model.Todo{}).Count(&total).Error; err != nil {
+		r.logger.Error("Failed to count todos", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return nil, 0, err
+	}
+
+	direction, comparison := "ASC", ">"
+	if opts.Sort.Desc {
+		direction, comparison = "DESC", "<"
+	}
+
+	if opts.After != nil {
+		query = query.Where(fmt.Sprintf("(%s, id) %s (?, ?)", column, comparison), opts.After.Value, opts.After.ID)
+	} else if opts.Offset > 0 {
+		query = query.Offset(opts.Offset)
+	}
+
+	order := fmt.Sprintf("%s %s", column, direction)
+	if column != "id" {
+		order = fmt.Sprintf("%s, id %s", order, direction)
+	}
+
 	var todos []model.Todo
-	result := r.db.DB.Find(&todos)
+	result := query.Order(order).Limit(opts.Limit).Find(&todos)
 	if result.Error != nil {
 		r.logger.Error("Failed to get todos", map[string]interface{}{
 			"error": result.Error.Error(),
 		})
+		return nil, 0, result.Error
+	}
+	return todos, total, nil
+}
+
+// GetByID retrieves a single todo belonging to the owner by its ID
+func (r *todoRepository) GetByID(ctx context.Context, ownerID string, id uint) (*model.Todo, error) {
+	var todo model.Todo
+	result := r.owned(ctx, ownerID).First(&todo, id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, model.ErrNotFound
+		}
+		r.logger.Error("Failed to get todo", map[string]interface{}{
+			"error": result.Error.Error(),
+			"id":    id,
+		})
+		return nil, result.Error
+	}
+	return &todo, nil
+}
+
+// FindByID retrieves a single todo by its ID whatever its owner
+func (r *todoRepository) FindByID(ctx context.Context, id uint) (*model.Todo, error) {
+	var todo model.Todo
+	result := r.tenant(ctx).First(&todo, id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, model.ErrNotFound
+		}
+		r.logger.Error("Failed to find todo", map[string]interface{}{
+			"error": result.Error.Error(),
+			"id":    id,
+		})
 		return nil, result.Error
 	}
-	return todos, nil
+	return &todo, nil
 }
 
-// Create adds a new todo to the repository
+// Create adds a new todo to the repository in the tenant of the context
 func (r *todoRepository) Create(ctx context.Context, todo *model.Todo) error {
-	result := r.db.DB.Create(todo)
+	tenant, ok := auth.TenantFromContext(ctx)
+	if !ok {
+		return errNoTenant
+	}
+	todo.Tenant = tenant
+
+	result := r.db.DB.WithContext(ctx).Create(todo)
 	if result.Error != nil {
 		r.logger.Error("Failed to create todo", map[string]interface{}{
 			"error": result.Error.Error(),
@@ -47,3 +139,84 @@ func (r *todoRepository) Create(ctx context.Context, todo *model.Todo) error {
 	}
 	return nil
 }
+
+// Update persists all fields of an existing todo, matching on its ID and owner
+func (r *todoRepository) Update(ctx context.Context, todo *model.Todo) error {
+	result := r.owned(ctx, todo.OwnerID).
+		Model(&model.Todo{}).
+		Where("id = ?", todo.ID).
+		Updates(map[string]interface{}{
+			"title":     todo.Title,
+			"completed": todo.Completed,
+		})
+	if result.Error != nil {
+		r.logger.Error("Failed to update todo", map[string]interface{}{
+			"error": result.Error.Error(),
+			"id":    todo.ID,
+		})
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return model.ErrNotFound
+	}
+
+	// Reload to pick up columns maintained by the database
+	return r.owned(ctx, todo.OwnerID).First(todo, todo.ID).Error
+}
+
+// Delete removes a todo belonging to the owner by its ID
+func (r *todoRepository) Delete(ctx context.Context, ownerID string, id uint) error {
+	result := r.owned(ctx, ownerID).Delete(&model.Todo{}, id)
+	if result.Error != nil {
+		r.logger.Error("Failed to delete todo", map[string]interface{}{
+			"error": result.Error.Error(),
+			"id":    id,
+		})
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return model.ErrNotFound
+	}
+	return nil
+}
+
+// filtered returns a query scoped to the owner with the listing filters applied
+func (r *todoRepository) filtered(ctx context.Context, opts repository.TodoListOptions) *gorm.DB {
+	query := r.owned(ctx, opts.OwnerID)
+	if opts.Completed != nil {
+		query = query.Where("completed = ?", *opts.Completed)
+	}
+	if opts.Title != "" {
+		query = query.Where("title ILIKE ? ESCAPE '\\'", "%"+likeEscaper.Replace(opts.Title)+"%")
+	}
+	if opts.CreatedAfter != nil {
+		query = query.Where("created_at >= ?", *opts.CreatedAfter)
+	}
+	if opts.CreatedBefore != nil {
+		query = query.Where("created_at < ?", *opts.CreatedBefore)
+	}
+	if opts.UpdatedAfter != nil {
+		query = query.Where("updated_at >= ?", *opts.UpdatedAfter)
+	}
+	if opts.UpdatedBefore != nil {
+		query = query.Where("updated_at < ?", *opts.UpdatedBefore)
+	}
+	return query
+}
+
+// owned returns a query scoped to the todos of the given owner
+func (r *todoRepository) owned(ctx context.Context, ownerID string) *gorm.DB {
+	return r.tenant(ctx).Where("owner_id = ?", ownerID)
+}
+
+// tenant returns a query scoped to the todos of the tenant in the context.
+// Without a tenant the query fails instead of reading across tenants.
+func (r *todoRepository) tenant(ctx context.Context) *gorm.DB {
+	query := r.db.DB.WithContext(ctx)
+	tenant, ok := auth.TenantFromContext(ctx)
+	if !ok {
+		_ = query.AddError(errNoTenant)
+		return query
+	}
+	return query.Where("tenant = ?", tenant)
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/repository"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/db"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+	"gorm.io/gorm"
+)
+
+// userRepository implements the UserRepository interface
+type userRepository struct {
+	db     *db.Database
+	logger *logger.Logger
+}
+
+// NewUserRepository creates a new user repository
+func NewUserRepository(db *db.Database, logger *logger.Logger) repository.UserRepository {
+	return &userRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Create adds a new user to the repository
+func (r *userRepository) Create(ctx context.Context, user *model.User) error {
+	result := r.db.DB.WithContext(ctx).Create(user)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+			return model.ErrConflict
+		}
+		r.logger.Error("Failed to create user", map[string]interface{}{
+			"error": result.Error.Error(),
+		})
+		return result.Error
+	}
+	return nil
+}
+
+// GetByEmail retrieves a user by their normalized email
+func (r *userRepository) GetByEmail(ctx context.Context, email string) (*model.User, error) {
+	var user model.User
+	result := r.db.DB.WithContext(ctx).Where("email = ?", email).First(&user)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, model.ErrNotFound
+		}
+		r.logger.Error("Failed to get user", map[string]interface{}{
+			"error": result.Error.Error(),
+		})
+		return nil, result.Error
+	}
+	return &user, nil
+}
+
+// GetByID retrieves a user by their ID
+func (r *userRepository) GetByID(ctx context.Context, id uint) (*model.User, error) {
+	var user model.User
+	result := r.db.DB.WithContext(ctx).First(&user, id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, model.ErrNotFound
+		}
+		r.logger.Error("Failed to get user", map[string]interface{}{
+			"error": result.Error.Error(),
+			"id":    id,
+		})
+		return nil, result.Error
+	}
+	return &user, nil
+}
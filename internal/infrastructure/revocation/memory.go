@@ -0,0 +1,70 @@
+package revocation
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/repository"
+)
+
+// memoryStore implements the RevocationStore interface in process memory.
+// Revocations are lost on restart and not shared between replicas, so it is
+// intended for development, tests and single-instance deployments.
+type memoryStore struct {
+	mu       sync.RWMutex
+	tokens   map[string]time.Time
+	subjects map[string]time.Time
+}
+
+// NewMemoryStore creates a new in-memory revocation store
+func NewMemoryStore() repository.RevocationStore {
+	return &memoryStore{
+		tokens:   make(map[string]time.Time),
+		subjects: make(map[string]time.Time),
+	}
+}
+
+// RevokeToken revokes the token with the given ID until it expires
+func (s *memoryStore) RevokeToken(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Drop entries for tokens that have expired on their own
+	now := time.Now()
+	for id, exp := range s.tokens {
+		if exp.Before(now) {
+			delete(s.tokens, id)
+		}
+	}
+
+	s.tokens[tokenID] = expiresAt
+	return nil
+}
+
+// RevokeSubject revokes every token of the subject issued at or before the given time
+func (s *memoryStore) RevokeSubject(ctx context.Context, subject string, before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if before.After(s.subjects[subject]) {
+		s.subjects[subject] = before
+	}
+	return nil
+}
+
+// IsRevoked reports whether the token has been revoked
+func (s *memoryStore) IsRevoked(ctx context.Context, tokenID, subject string, issuedAt time.Time) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if tokenID != "" {
+		if _, ok := s.tokens[tokenID]; ok {
+			return true, nil
+		}
+	}
+	if before, ok := s.subjects[subject]; ok && !issuedAt.After(before) {
+		return true, nil
+	}
+	return false, nil
+}
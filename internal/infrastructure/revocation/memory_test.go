@@ -0,0 +1,59 @@
+package revocation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	t.Run("Revoked token IDs are rejected", func(t *testing.T) {
+		store := NewMemoryStore()
+		assert.NoError(t, store.RevokeToken(ctx, "token-1", now.Add(time.Hour)))
+
+		revoked, err := store.IsRevoked(ctx, "token-1", "user@example.com", now)
+		assert.NoError(t, err)
+		assert.True(t, revoked)
+
+		revoked, err = store.IsRevoked(ctx, "token-2", "user@example.com", now)
+		assert.NoError(t, err)
+		assert.False(t, revoked)
+	})
+
+	t.Run("Expired entries are pruned", func(t *testing.T) {
+		store := NewMemoryStore().(*memoryStore)
+		assert.NoError(t, store.RevokeToken(ctx, "expired", now.Add(-time.Minute)))
+		assert.NoError(t, store.RevokeToken(ctx, "active", now.Add(time.Hour)))
+
+		assert.NotContains(t, store.tokens, "expired")
+		assert.Contains(t, store.tokens, "active")
+	})
+
+	t.Run("Subject revocation covers tokens issued up to the cutoff", func(t *testing.T) {
+		store := NewMemoryStore()
+		assert.NoError(t, store.RevokeSubject(ctx, "user@example.com", now))
+
+		revoked, _ := store.IsRevoked(ctx, "", "user@example.com", now.Add(-time.Minute))
+		assert.True(t, revoked)
+		revoked, _ = store.IsRevoked(ctx, "", "user@example.com", now)
+		assert.True(t, revoked)
+		revoked, _ = store.IsRevoked(ctx, "", "user@example.com", now.Add(time.Second))
+		assert.False(t, revoked)
+		revoked, _ = store.IsRevoked(ctx, "", "other@example.com", now.Add(-time.Minute))
+		assert.False(t, revoked)
+	})
+
+	t.Run("An older subject revocation does not shorten a newer one", func(t *testing.T) {
+		store := NewMemoryStore()
+		assert.NoError(t, store.RevokeSubject(ctx, "user@example.com", now))
+		assert.NoError(t, store.RevokeSubject(ctx, "user@example.com", now.Add(-time.Hour)))
+
+		revoked, _ := store.IsRevoked(ctx, "", "user@example.com", now.Add(-time.Minute))
+		assert.True(t, revoked)
+	})
+}
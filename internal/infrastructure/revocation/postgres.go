@@ -0,0 +1,113 @@
+package revocation
+
+import (
+	"context"
+	"time"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/repository"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/db"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+	"gorm.io/gorm/clause"
+)
+
+// revokedToken is a row of the revoked_tokens table
+type revokedToken struct {
+	TokenID   string    `gorm:"primaryKey;size:64"`
+	ExpiresAt time.Time `gorm:"not null"`
+	RevokedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// TableName returns the table name for revoked tokens
+func (revokedToken) TableName() string {
+	return "revoked_tokens"
+}
+
+// subjectRevocation is a row of the subject_revocations table
+type subjectRevocation struct {
+	Subject       string    `gorm:"primaryKey;size:255"`
+	RevokedBefore time.Time `gorm:"not null"`
+}
+
+// TableName returns the table name for subject revocations
+func (subjectRevocation) TableName() string {
+	return "subject_revocations"
+}
+
+// postgresStore implements the RevocationStore interface on top of Postgres,
+// so revocations survive restarts and are shared between replicas
+type postgresStore struct {
+	db     *db.Database
+	logger *logger.Logger
+}
+
+// NewPostgresStore creates a new Postgres-backed revocation store
+func NewPostgresStore(db *db.Database, logger *logger.Logger) repository.RevocationStore {
+	return &postgresStore{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// RevokeToken revokes the token with the given ID until it expires
+func (s *postgresStore) RevokeToken(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	// Drop entries for tokens that have expired on their own
+	if err := s.db.DB.WithContext(ctx).Where("expires_at < ?", time.Now()).Delete(&revokedToken{}).Error; err != nil {
+		s.logger.Warn("Failed to prune revoked tokens", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
+	result := s.db.DB.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&revokedToken{TokenID: tokenID, ExpiresAt: expiresAt})
+	if result.Error != nil {
+		s.logger.Error("Failed to revoke token", map[string]interface{}{
+			"error": result.Error.Error(),
+		})
+		return result.Error
+	}
+	return nil
+}
+
+// RevokeSubject revokes every token of the subject issued at or before the given time
+func (s *postgresStore) RevokeSubject(ctx context.Context, subject string, before time.Time) error {
+	result := s.db.DB.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "subject"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"revoked_before": latestCutoff(before),
+			}),
+		}).
+		Create(&subjectRevocation{Subject: subject, RevokedBefore: before})
+	if result.Error != nil {
+		s.logger.Error("Failed to revoke subject tokens", map[string]interface{}{
+			"error":   result.Error.Error(),
+			"subject": subject,
+		})
+		return result.Error
+	}
+	return nil
+}
+
+// IsRevoked reports whether the token has been revoked
+func (s *postgresStore) IsRevoked(ctx context.Context, tokenID, subject string, issuedAt time.Time) (bool, error) {
+	var revoked bool
+	err := s.db.DB.WithContext(ctx).Raw(
+		`SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE token_id = ?)
+			OR EXISTS (SELECT 1 FROM subject_revocations WHERE subject = ? AND revoked_before >= ?)`,
+		tokenID, subject, issuedAt,
+	).Scan(&revoked).Error
+	if err != nil {
+		s.logger.Error("Failed to check token revocation", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return false, err
+	}
+	return revoked, nil
+}
+
+// latestCutoff keeps the later of the stored and the new cutoff so an older
+// revocation never shortens a newer one
+func latestCutoff(before time.Time) clause.Expr {
+	return clause.Expr{SQL: "GREATEST(subject_revocations.revoked_before, ?)", Vars: []interface{}{before}}
+}
@@ -0,0 +1,73 @@
+package totp
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfcSecret is the SHA-1 key of the RFC 6238 test vectors, base32 encoded
+var rfcSecret = encoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestCode_RFC6238Vectors(t *testing.T) {
+	a := NewAuthenticator("Example")
+
+	// The RFC lists 8-digit codes; 6-digit codes are their last six digits
+	vectors := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	}
+	for unix, want := range vectors {
+		got, err := a.Code(rfcSecret, time.Unix(unix, 0))
+		require.NoError(t, err)
+		assert.Equal(t, want, got, unix)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	a := NewAuthenticator("Example")
+	secret, err := a.GenerateSecret()
+	require.NoError(t, err)
+	now := time.Unix(1700000000, 0)
+
+	current, _ := a.Code(secret, now)
+	step, ok := a.Verify(secret, current, now)
+	assert.True(t, ok)
+	assert.Equal(t, now.Unix()/30, step)
+
+	// Codes from the neighbouring steps are accepted for clock drift
+	previous, _ := a.Code(secret, now.Add(-30*time.Second))
+	step, ok = a.Verify(secret, previous, now)
+	assert.True(t, ok)
+	assert.Equal(t, now.Unix()/30-1, step)
+
+	stale, _ := a.Code(secret, now.Add(-2*time.Minute))
+	_, ok = a.Verify(secret, stale, now)
+	assert.False(t, ok)
+
+	_, ok = a.Verify(secret, "12345", now)
+	assert.False(t, ok)
+	_, ok = a.Verify("not base32!", current, now)
+	assert.False(t, ok)
+}
+
+func TestProvisioningURI(t *testing.T) {
+	a := NewAuthenticator("Example Co")
+
+	uri, err := url.Parse(a.ProvisioningURI("user@example.com", "JBSWY3DPEHPK3PXP"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "otpauth", uri.Scheme)
+	assert.Equal(t, "totp", uri.Host)
+	assert.Equal(t, "/Example Co:user@example.com", uri.Path)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", uri.Query().Get("secret"))
+	assert.Equal(t, "Example Co", uri.Query().Get("issuer"))
+	assert.Equal(t, "6", uri.Query().Get("digits"))
+}
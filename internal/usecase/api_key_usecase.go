@@ -0,0 +1,324 @@
+package usecase
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/config"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/repository"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+)
+
+const (
+	// apiKeyPrefix starts every API key, so leaked keys are easy to recognize
+	apiKeyPrefix = "gmk_"
+
+	// apiKeyIDBytes is the amount of randomness in the public part of a key,
+	// which follows apiKeyPrefix and identifies the key
+	apiKeyIDBytes = 6
+
+	// apiKeySecretBytes is the amount of randomness in the secret part of a key
+	apiKeySecretBytes = 32
+
+	// apiKeyTouchInterval bounds how often the last use of a key is recorded
+	apiKeyTouchInterval = time.Minute
+
+	// maxAPIKeyNameLength mirrors the size of the api_keys.name column
+	maxAPIKeyNameLength = 255
+)
+
+// serviceNamePattern matches the names of services authenticated by API key
+var serviceNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,63}$`)
+
+// APIKeyUsecase defines the interface for managing API keys and
+// authenticating the services holding them. Managing keys requires a
+// superadmin caller.
+type APIKeyUsecase interface {
+	// Create generates an API key for a service. The key itself is only
+	// returned here; afterwards it is known by its prefix.
+	Create(ctx context.Context, input APIKeyInput) (*CreatedAPIKey, error)
+
+	// List returns every API key
+	List(ctx context.Context) ([]model.APIKey, error)
+
+	// Get returns the API key with the given ID
+	Get(ctx context.Context, id uint) (*model.APIKey, error)
+
+	// Update applies the non-nil fields of the patch to the API key with the given ID
+	Update(ctx context.Context, id uint, patch APIKeyPatch) (*model.APIKey, error)
+
+	// Delete removes the API key with the given ID; it stops authenticating immediately
+	Delete(ctx context.Context, id uint) error
+
+	// Authenticate returns the unexpired API key matching key.
+	// It returns model.ErrInvalidToken for unknown and expired keys.
+	Authenticate(ctx context.Context, key string) (*model.APIKey, error)
+}
+
+// APIKeyInput represents the attributes of a new API key
+type APIKeyInput struct {
+	Name    string
+	Service string
+	// Tenant defaults to the default tenant
+	Tenant string
+	// Scopes restrict the key to the named roles; when empty the key acts with
+	// every role of its service
+	Scopes []string
+	// ExpiresAt is optional; keys without it do not expire
+	ExpiresAt *time.Time
+}
+
+// APIKeyPatch represents a partial update of an API key
+type APIKeyPatch struct {
+	Name      *string
+	Scopes    *[]string
+	ExpiresAt *time.Time
+}
+
+// CreatedAPIKey pairs a new API key with its secret value
+type CreatedAPIKey struct {
+	APIKey *model.APIKey
+	// Key is sent by the service in the X-API-Key header
+	Key string
+}
+
+// apiKeyUsecase implements the APIKeyUsecase interface
+type apiKeyUsecase struct {
+	keys   repository.APIKeyRepository
+	config *config.AuthConfig
+	logger *logger.Logger
+}
+
+// NewAPIKeyUsecase creates a new API key usecase
+func NewAPIKeyUsecase(keys repository.APIKeyRepository, config *config.AuthConfig, logger *logger.Logger) APIKeyUsecase {
+	return &apiKeyUsecase{
+		keys:   keys,
+		config: config,
+		logger: logger,
+	}
+}
+
+// Create generates an API key for a service
+func (u *apiKeyUsecase) Create(ctx context.Context, input APIKeyInput) (*CreatedAPIKey, error) {
+	principal, err := requireSuperAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := normalizeAPIKeyName(input.Name)
+	if err != nil {
+		return nil, err
+	}
+	service := strings.ToLower(strings.TrimSpace(input.Service))
+	if !serviceNamePattern.MatchString(service) {
+		return nil, model.NewValidationError("service", "must be 1 to 64 lowercase letters, digits, '.', '_' or '-', starting with a letter or digit")
+	}
+	scopes, err := normalizeScopes(input.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateAPIKeyExpiry(input.ExpiresAt); err != nil {
+		return nil, err
+	}
+
+	id, err := randomToken(apiKeyIDBytes, hex.EncodeToString)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := randomToken(apiKeySecretBytes, base64.RawURLEncoding.EncodeToString)
+	if err != nil {
+		return nil, err
+	}
+	prefix := apiKeyPrefix + id
+	raw := prefix + "_" + secret
+
+	key := &model.APIKey{
+		Name:      name,
+		Service:   service,
+		Tenant:    u.config.TenantOrDefault(strings.TrimSpace(input.Tenant)),
+		Prefix:    prefix,
+		KeyHash:   hashSecret(raw),
+		Scopes:    scopes,
+		ExpiresAt: input.ExpiresAt,
+		CreatedBy: principal.Email,
+	}
+	if err := u.keys.Create(ctx, key); err != nil {
+		return nil, err
+	}
+
+	u.logger.Warn("API key created", map[string]interface{}{
+		"prefix":     key.Prefix,
+		"service":    key.Service,
+		"tenant":     key.Tenant,
+		"scopes":     key.Scopes,
+		"changed_by": principal.Email,
+	})
+	return &CreatedAPIKey{APIKey: key, Key: raw}, nil
+}
+
+// List returns every API key
+func (u *apiKeyUsecase) List(ctx context.Context) ([]model.APIKey, error) {
+	if _, err := requireSuperAdmin(ctx); err != nil {
+		return nil, err
+	}
+	return u.keys.List(ctx)
+}
+
+// Get returns the API key with the given ID
+func (u *apiKeyUsecase) Get(ctx context.Context, id uint) (*model.APIKey, error) {
+	if _, err := requireSuperAdmin(ctx); err != nil {
+		return nil, err
+	}
+	return u.keys.GetByID(ctx, id)
+}
+
+// Update applies the non-nil fields of the patch to the API key with the given ID
+func (u *apiKeyUsecase) Update(ctx context.Context, id uint, patch APIKeyPatch) (*model.APIKey, error) {
+	principal, err := requireSuperAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := u.keys.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if patch.Name != nil {
+		if key.Name, err = normalizeAPIKeyName(*patch.Name); err != nil {
+			return nil, err
+		}
+	}
+	if patch.Scopes != nil {
+		if key.Scopes, err = normalizeScopes(*patch.Scopes); err != nil {
+			return nil, err
+		}
+	}
+	if patch.ExpiresAt != nil {
+		if err := validateAPIKeyExpiry(patch.ExpiresAt); err != nil {
+			return nil, err
+		}
+		key.ExpiresAt = patch.ExpiresAt
+	}
+	if err := u.keys.Update(ctx, key); err != nil {
+		return nil, err
+	}
+
+	u.logger.Warn("API key updated", map[string]interface{}{
+		"prefix":     key.Prefix,
+		"service":    key.Service,
+		"scopes":     key.Scopes,
+		"changed_by": principal.Email,
+	})
+	return key, nil
+}
+
+// Delete removes the API key with the given ID
+func (u *apiKeyUsecase) Delete(ctx context.Context, id uint) error {
+	principal, err := requireSuperAdmin(ctx)
+	if err != nil {
+		return err
+	}
+	if err := u.keys.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	u.logger.Warn("API key deleted", map[string]interface{}{
+		"id":         id,
+		"changed_by": principal.Email,
+	})
+	return nil
+}
+
+// Authenticate returns the unexpired API key matching key
+func (u *apiKeyUsecase) Authenticate(ctx context.Context, key string) (*model.APIKey, error) {
+	prefix, ok := apiKeyPrefixOf(key)
+	if !ok {
+		return nil, model.ErrInvalidToken
+	}
+
+	stored, err := u.keys.GetByPrefix(ctx, prefix)
+	if errors.Is(err, model.ErrNotFound) {
+		return nil, model.ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(stored.KeyHash), []byte(hashSecret(key))) != 1 {
+		return nil, model.ErrInvalidToken
+	}
+
+	now := time.Now()
+	if stored.Expired(now) {
+		return nil, model.ErrInvalidToken
+	}
+
+	// Recording every request would write on every call, so uses within
+	// apiKeyTouchInterval of the last recorded one are skipped
+	if stored.LastUsedAt == nil || now.Sub(*stored.LastUsedAt) >= apiKeyTouchInterval {
+		if err := u.keys.TouchLastUsed(ctx, stored.ID, now); err == nil {
+			stored.LastUsedAt = &now
+		}
+	}
+	return stored, nil
+}
+
+// apiKeyPrefixOf returns the public prefix of an API key of the form
+// gmk_<id>_<secret>
+func apiKeyPrefixOf(key string) (string, bool) {
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return "", false
+	}
+	id, secret, ok := strings.Cut(key[len(apiKeyPrefix):], "_")
+	if !ok || len(id) != 2*apiKeyIDBytes || secret == "" {
+		return "", false
+	}
+	return apiKeyPrefix + id, true
+}
+
+// normalizeAPIKeyName trims the name of an API key and checks its length
+func normalizeAPIKeyName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", model.NewValidationError("name", "must not be empty")
+	}
+	if utf8.RuneCountInString(name) > maxAPIKeyNameLength {
+		return "", model.NewValidationError("name", fmt.Sprintf("must be at most %d characters", maxAPIKeyNameLength))
+	}
+	return name, nil
+}
+
+// normalizeScopes trims, deduplicates and sorts scopes
+func normalizeScopes(scopes []string) ([]string, error) {
+	seen := make(map[string]bool, len(scopes))
+	normalized := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if scope == "" || strings.ContainsAny(scope, " \t\r\n,") {
+			return nil, model.NewValidationError("scopes", "must be role names without whitespace or commas")
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			normalized = append(normalized, scope)
+		}
+	}
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+// validateAPIKeyExpiry checks that an expiry, if set, is in the future
+func validateAPIKeyExpiry(expiresAt *time.Time) error {
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return model.NewValidationError("expires_at", "must be in the future")
+	}
+	return nil
+}
@@ -0,0 +1,241 @@
+package usecase_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/config"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/usecase"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockAPIKeyRepository is a mock implementation of the APIKeyRepository interface
+type MockAPIKeyRepository struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyRepository) Create(ctx context.Context, key *model.APIKey) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) GetByID(ctx context.Context, id uint) (*model.APIKey, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*model.APIKey, error) {
+	args := m.Called(ctx, prefix)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) List(ctx context.Context) ([]model.APIKey, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) Update(ctx context.Context, key *model.APIKey) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) Delete(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) TouchLastUsed(ctx context.Context, id uint, usedAt time.Time) error {
+	args := m.Called(ctx, id, usedAt)
+	return args.Error(0)
+}
+
+// newAPIKey creates an API key through the usecase and returns it with the
+// record the repository was asked to store
+func newAPIKey(t *testing.T, apiKeyUsecase usecase.APIKeyUsecase, mockRepo *MockAPIKeyRepository, input usecase.APIKeyInput) (string, *model.APIKey) {
+	t.Helper()
+	var stored *model.APIKey
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*model.APIKey")).Run(func(args mock.Arguments) {
+		stored = args.Get(1).(*model.APIKey)
+		stored.ID = 7
+	}).Return(nil).Once()
+
+	created, err := apiKeyUsecase.Create(superAdminContext(), input)
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+	return created.Key, stored
+}
+
+func TestAPIKeyUsecase_Create(t *testing.T) {
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+	authConfig := &config.AuthConfig{DefaultTenant: "default"}
+
+	t.Run("Stores a hash of the key", func(t *testing.T) {
+		mockRepo := new(MockAPIKeyRepository)
+		apiKeyUsecase := usecase.NewAPIKeyUsecase(mockRepo, authConfig, log)
+
+		raw, stored := newAPIKey(t, apiKeyUsecase, mockRepo, usecase.APIKeyInput{
+			Name:    " Nightly export ",
+			Service: "Batch",
+			Scopes:  []string{"user", " user", "admin"},
+		})
+
+		assert.True(t, strings.HasPrefix(raw, stored.Prefix+"_"))
+		assert.True(t, strings.HasPrefix(stored.Prefix, "gmk_"))
+		assert.NotContains(t, stored.KeyHash, raw)
+		assert.Len(t, stored.KeyHash, 64)
+		assert.Equal(t, "Nightly export", stored.Name)
+		assert.Equal(t, "batch", stored.Service)
+		assert.Equal(t, "default", stored.Tenant)
+		assert.Equal(t, []string{"admin", "user"}, stored.Scopes)
+		assert.Equal(t, "admin@example.com", stored.CreatedBy)
+	})
+
+	t.Run("Invalid input", func(t *testing.T) {
+		past := time.Now().Add(-time.Hour)
+		tests := []struct {
+			name  string
+			input usecase.APIKeyInput
+			field string
+		}{
+			{name: "empty name", input: usecase.APIKeyInput{Name: " ", Service: "batch"}, field: "name"},
+			{name: "invalid service", input: usecase.APIKeyInput{Name: "Export", Service: "batch jobs"}, field: "service"},
+			{name: "empty scope", input: usecase.APIKeyInput{Name: "Export", Service: "batch", Scopes: []string{""}}, field: "scopes"},
+			{name: "expired", input: usecase.APIKeyInput{Name: "Export", Service: "batch", ExpiresAt: &past}, field: "expires_at"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				mockRepo := new(MockAPIKeyRepository)
+				apiKeyUsecase := usecase.NewAPIKeyUsecase(mockRepo, authConfig, log)
+
+				_, err := apiKeyUsecase.Create(superAdminContext(), tt.input)
+
+				var validationErr *model.ValidationError
+				require.ErrorAs(t, err, &validationErr)
+				assert.Equal(t, tt.field, validationErr.Field)
+				mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+			})
+		}
+	})
+
+	t.Run("Requires a superadmin", func(t *testing.T) {
+		mockRepo := new(MockAPIKeyRepository)
+		apiKeyUsecase := usecase.NewAPIKeyUsecase(mockRepo, authConfig, log)
+
+		_, err := apiKeyUsecase.Create(userContext(), usecase.APIKeyInput{Name: "Export", Service: "batch"})
+		assert.ErrorIs(t, err, model.ErrForbidden)
+
+		_, err = apiKeyUsecase.List(userContext())
+		assert.ErrorIs(t, err, model.ErrForbidden)
+
+		err = apiKeyUsecase.Delete(context.Background(), 7)
+		assert.ErrorIs(t, err, model.ErrUnauthenticated)
+
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		mockRepo.AssertNotCalled(t, "List", mock.Anything)
+		mockRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+}
+
+func TestAPIKeyUsecase_Authenticate(t *testing.T) {
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+	authConfig := &config.AuthConfig{DefaultTenant: "default"}
+	ctx := context.Background()
+
+	t.Run("Valid key records its use", func(t *testing.T) {
+		mockRepo := new(MockAPIKeyRepository)
+		apiKeyUsecase := usecase.NewAPIKeyUsecase(mockRepo, authConfig, log)
+		raw, stored := newAPIKey(t, apiKeyUsecase, mockRepo, usecase.APIKeyInput{Name: "Export", Service: "batch"})
+		mockRepo.On("GetByPrefix", ctx, stored.Prefix).Return(stored, nil).Once()
+		mockRepo.On("TouchLastUsed", ctx, uint(7), mock.AnythingOfType("time.Time")).Return(nil).Once()
+
+		key, err := apiKeyUsecase.Authenticate(ctx, raw)
+		require.NoError(t, err)
+		assert.Equal(t, "batch", key.Service)
+		assert.NotNil(t, key.LastUsedAt)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Recent use is not recorded again", func(t *testing.T) {
+		mockRepo := new(MockAPIKeyRepository)
+		apiKeyUsecase := usecase.NewAPIKeyUsecase(mockRepo, authConfig, log)
+		raw, stored := newAPIKey(t, apiKeyUsecase, mockRepo, usecase.APIKeyInput{Name: "Export", Service: "batch"})
+		lastUsed := time.Now().Add(-10 * time.Second)
+		stored.LastUsedAt = &lastUsed
+		mockRepo.On("GetByPrefix", ctx, stored.Prefix).Return(stored, nil).Once()
+
+		_, err := apiKeyUsecase.Authenticate(ctx, raw)
+		require.NoError(t, err)
+		mockRepo.AssertNotCalled(t, "TouchLastUsed", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Wrong secret", func(t *testing.T) {
+		mockRepo := new(MockAPIKeyRepository)
+		apiKeyUsecase := usecase.NewAPIKeyUsecase(mockRepo, authConfig, log)
+		_, stored := newAPIKey(t, apiKeyUsecase, mockRepo, usecase.APIKeyInput{Name: "Export", Service: "batch"})
+		mockRepo.On("GetByPrefix", ctx, stored.Prefix).Return(stored, nil).Once()
+
+		_, err := apiKeyUsecase.Authenticate(ctx, stored.Prefix+"_guessed")
+		assert.ErrorIs(t, err, model.ErrInvalidToken)
+	})
+
+	t.Run("Expired key", func(t *testing.T) {
+		mockRepo := new(MockAPIKeyRepository)
+		apiKeyUsecase := usecase.NewAPIKeyUsecase(mockRepo, authConfig, log)
+		raw, stored := newAPIKey(t, apiKeyUsecase, mockRepo, usecase.APIKeyInput{Name: "Export", Service: "batch"})
+		expired := time.Now().Add(-time.Minute)
+		stored.ExpiresAt = &expired
+		mockRepo.On("GetByPrefix", ctx, stored.Prefix).Return(stored, nil).Once()
+
+		_, err := apiKeyUsecase.Authenticate(ctx, raw)
+		assert.ErrorIs(t, err, model.ErrInvalidToken)
+	})
+
+	t.Run("Unknown and malformed keys", func(t *testing.T) {
+		mockRepo := new(MockAPIKeyRepository)
+		apiKeyUsecase := usecase.NewAPIKeyUsecase(mockRepo, authConfig, log)
+		mockRepo.On("GetByPrefix", ctx, "gmk_000000000000").Return(nil, model.ErrNotFound).Once()
+
+		_, err := apiKeyUsecase.Authenticate(ctx, "gmk_000000000000_secret")
+		assert.ErrorIs(t, err, model.ErrInvalidToken)
+
+		for _, key := range []string{"", "secret", "gmk_short_secret", "gmk_000000000000"} {
+			_, err := apiKeyUsecase.Authenticate(ctx, key)
+			assert.ErrorIs(t, err, model.ErrInvalidToken, key)
+		}
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestAPIKeyUsecase_Update(t *testing.T) {
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+	mockRepo := new(MockAPIKeyRepository)
+	apiKeyUsecase := usecase.NewAPIKeyUsecase(mockRepo, &config.AuthConfig{}, log)
+
+	existing := &model.APIKey{ID: 7, Name: "Export", Service: "batch", Scopes: []string{"admin"}}
+	mockRepo.On("GetByID", mock.Anything, uint(7)).Return(existing, nil).Once()
+	mockRepo.On("Update", mock.Anything, mock.MatchedBy(func(key *model.APIKey) bool {
+		return key.Name == "Export" && assert.ObjectsAreEqual([]string{"user"}, key.Scopes)
+	})).Return(nil).Once()
+
+	scopes := []string{"user"}
+	key, err := apiKeyUsecase.Update(superAdminContext(), 7, usecase.APIKeyPatch{Scopes: &scopes})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user"}, key.Scopes)
+	mockRepo.AssertExpectations(t)
+}
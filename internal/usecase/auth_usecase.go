@@ -0,0 +1,290 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/config"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/auth"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/repository"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+)
+
+const (
+	// minPasswordLength is the shortest password accepted at registration
+	minPasswordLength = 8
+
+	// maxPasswordLength is the longest password accepted; bcrypt ignores bytes past 72
+	maxPasswordLength = 72
+)
+
+// emailPattern is a pragmatic check for well-formed email addresses
+var emailPattern = regexp.MustCompile(`^[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}$`)
+
+// PasswordHasher hashes and verifies user passwords
+type PasswordHasher interface {
+	// Hash returns a salted hash of the password
+	Hash(password string) (string, error)
+
+	// Verify reports whether the password matches the hash
+	Verify(hash, password string) (bool, error)
+}
+
+// TokenIssuer issues signed access tokens, and the tokens of the steps of a
+// login that precede them
+type TokenIssuer interface {
+	// IssueToken returns an access token carrying the grant
+	IssueToken(grant auth.TokenGrant) (string, error)
+
+	// GenerateLoginToken returns a token for a login link sent to the email,
+	// naming the login challenge it belongs to
+	GenerateLoginToken(email, challengeID string, ttl time.Duration) (string, error)
+
+	// ValidateLoginToken returns the email and login challenge ID of a login
+	// link token. Access tokens are not accepted.
+	ValidateLoginToken(token string) (email, challengeID string, err error)
+
+	// GenerateMFAToken returns a token for a login whose first factor, recorded
+	// in amr, was verified and which still needs a second factor
+	GenerateMFAToken(email string, amr []string, ttl time.Duration) (string, error)
+
+	// ValidateMFAToken returns the email and first-factor methods of an MFA
+	// token. Access tokens are not accepted.
+	ValidateMFAToken(token string) (email string, amr []string, err error)
+}
+
+// TokenPair is the result of a successful login or refresh
+type TokenPair struct {
+	// AccessToken is a short-lived JWT sent as a bearer token
+	AccessToken string
+	// ExpiresIn is how long the access token is valid
+	ExpiresIn time.Duration
+	// RefreshToken is an opaque single-use token exchanged for a new pair
+	RefreshToken string
+	// RecoveryCodes are set when the login enabled MFA; they are only shown once
+	RecoveryCodes []string
+	// Scopes are the roles the tokens are restricted to; empty when unrestricted
+	Scopes []string
+}
+
+// AuthUsecase defines the interface for account registration, credential checks and token issuance
+type AuthUsecase interface {
+	// Register creates a new account with the given email and password.
+	// The configured superadmin email cannot be registered this way; use Provision.
+	Register(ctx context.Context, email, password string) (*model.User, error)
+
+	// Provision creates an account without the self-registration restrictions.
+	// It is intended for operators bootstrapping privileged accounts.
+	Provision(ctx context.Context, email, password string) (*model.User, error)
+
+	// Login issues a token pair for the account matching the email and password.
+	// It returns model.ErrInvalidCredentials if they do not match, and an
+	// *MFARequiredError if the account must also pass MFA.
+	Login(ctx context.Context, email, password string) (*TokenPair, error)
+
+	// Refresh exchanges a refresh token for a new token pair. The refresh token
+	// is rotated: it cannot be used again, and presenting it again revokes every
+	// token descended from the same login. It returns model.ErrInvalidToken if
+	// the token is unknown, expired, revoked or reused.
+	Refresh(ctx context.Context, refreshToken string) (*TokenPair, error)
+
+	// Logout revokes the caller's access token. If a refresh token belonging to
+	// the caller is given, every token descended from the same login is revoked too.
+	Logout(ctx context.Context, refreshToken string) error
+
+	// RevokeUserTokens revokes every access and refresh token issued to the
+	// account with the given email so far. Only superadmins may call it.
+	RevokeUserTokens(ctx context.Context, email string) error
+
+	// RequestLoginLink emails a single-use login link and 6-digit code to the
+	// account with the given email. It succeeds without sending anything if no
+	// account has the email, so callers cannot probe for accounts.
+	RequestLoginLink(ctx context.Context, email string) error
+
+	// VerifyLoginLink exchanges the token of a login link for a token pair.
+	// It returns model.ErrInvalidToken if the token is invalid, expired or used.
+	VerifyLoginLink(ctx context.Context, token string) (*TokenPair, error)
+
+	// VerifyLoginCode exchanges the code of the latest login email sent to the
+	// email for a token pair. It returns model.ErrInvalidToken if the code is
+	// wrong, expired or used, or too many wrong codes were entered.
+	VerifyLoginCode(ctx context.Context, email, code string) (*TokenPair, error)
+
+	// EnrollMFA starts TOTP enrollment for the login of an MFA token, for
+	// accounts that must use MFA but have not enabled it. It returns
+	// model.ErrInvalidToken if the token is invalid or expired, and
+	// model.ErrConflict if MFA is already enabled.
+	EnrollMFA(ctx context.Context, mfaToken string) (*TOTPEnrollment, error)
+
+	// VerifyMFA completes the login of an MFA token with a TOTP code or
+	// recovery code. If the code confirms a new enrollment the token pair
+	// carries the new recovery codes. It returns model.ErrInvalidToken if the
+	// token or code is invalid.
+	VerifyMFA(ctx context.Context, mfaToken, code string) (*TokenPair, error)
+
+	// IssueScopedTokens issues the caller a token pair restricted to the named
+	// roles, starting a new token family whose refreshed tokens keep the
+	// restriction. Callers with scopes may only narrow them further. It
+	// returns model.ErrForbidden for callers without an account, such as
+	// services, and for superadmins impersonating a user.
+	IssueScopedTokens(ctx context.Context, scopes []string) (*TokenPair, error)
+}
+
+// authUsecase implements the AuthUsecase interface
+type authUsecase struct {
+	users         repository.UserRepository
+	refreshTokens repository.RefreshTokenRepository
+	revocations   repository.RevocationStore
+	challenges    repository.LoginChallengeRepository
+	mailer        Mailer
+	mfa           MFAUsecase
+	throttle      LoginThrottle
+	hasher        PasswordHasher
+	tokens        TokenIssuer
+	config        *config.AuthConfig
+	logger        *logger.Logger
+
+	// dummyHash is verified against when an email is unknown so that failed
+	// logins take the same time whether or not the account exists
+	dummyHashOnce sync.Once
+	dummyHash     string
+}
+
+// NewAuthUsecase creates a new auth usecase
+// Passwordless login is unavailable when challenges or mailer is nil,
+// logins skip MFA when mfa is nil, and failed attempts are not throttled
+// when throttle is nil.
+func NewAuthUsecase(users repository.UserRepository, refreshTokens repository.RefreshTokenRepository, revocations repository.RevocationStore, challenges repository.LoginChallengeRepository, mailer Mailer, mfa MFAUsecase, throttle LoginThrottle, hasher PasswordHasher, tokens TokenIssuer, config *config.AuthConfig, logger *logger.Logger) AuthUsecase {
+	return &authUsecase{
+		users:         users,
+		refreshTokens: refreshTokens,
+		revocations:   revocations,
+		challenges:    challenges,
+		mailer:        mailer,
+		mfa:           mfa,
+		throttle:      throttle,
+		hasher:        hasher,
+		tokens:        tokens,
+		config:        config,
+		logger:        logger,
+	}
+}
+
+// Register creates a new account with the given email and password
+func (u *authUsecase) Register(ctx context.Context, email, password string) (*model.User, error) {
+	email = NormalizeEmail(email)
+	if u.config.SuperAdminEmail != "" && email == NormalizeEmail(u.config.SuperAdminEmail) {
+		u.logger.Warn("Refused self-registration of superadmin email", map[string]interface{}{
+			"email": email,
+		})
+		return nil, model.ErrForbidden
+	}
+
+	return u.Provision(ctx, email, password)
+}
+
+// Provision creates an account without the self-registration restrictions
+func (u *authUsecase) Provision(ctx context.Context, email, password string) (*model.User, error) {
+	email = NormalizeEmail(email)
+	if err := ValidateEmail(email); err != nil {
+		return nil, err
+	}
+	if err := validatePassword(password); err != nil {
+		return nil, err
+	}
+
+	hash, err := u.hasher.Hash(password)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &model.User{
+		Email:        email,
+		PasswordHash: hash,
+		Tenant:       u.config.TenantOrDefault(""),
+	}
+	if err := u.users.Create(ctx, user); err != nil {
+		return nil, err
+	}
+
+	u.logger.Info("User registered", map[string]interface{}{
+		"email": email,
+	})
+
+	return user, nil
+}
+
+// Login issues a token pair for the account matching the email and password
+func (u *authUsecase) Login(ctx context.Context, email, password string) (*TokenPair, error) {
+	email = NormalizeEmail(email)
+
+	var user *model.User
+	err := u.throttled(ctx, email, auth.AMRPassword, func() (err error) {
+		user, err = u.checkCredentials(ctx, email, password)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return u.startSession(ctx, user, []string{auth.AMRPassword})
+}
+
+// checkCredentials returns the account matching the email and password
+func (u *authUsecase) checkCredentials(ctx context.Context, email, password string) (*model.User, error) {
+	email = NormalizeEmail(email)
+
+	user, err := u.users.GetByEmail(ctx, email)
+	if errors.Is(err, model.ErrNotFound) {
+		u.dummyHashOnce.Do(func() {
+			u.dummyHash, _ = u.hasher.Hash("timing-equalization-password")
+		})
+		_, _ = u.hasher.Verify(u.dummyHash, password)
+		return nil, model.ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := u.hasher.Verify(user.PasswordHash, password)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, model.ErrInvalidCredentials
+	}
+
+	return user, nil
+}
+
+// NormalizeEmail trims and lowercases an email so it can be compared and stored
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// ValidateEmail checks that the email is well formed
+func ValidateEmail(email string) error {
+	if email == "" {
+		return model.NewValidationError("email", "is required")
+	}
+	if !emailPattern.MatchString(email) {
+		return model.NewValidationError("email", "must be a valid email address")
+	}
+	return nil
+}
+
+// validatePassword checks the password length policy
+func validatePassword(password string) error {
+	if len(password) < minPasswordLength {
+		return model.NewValidationError("password", "must be at least 8 characters")
+	}
+	if len(password) > maxPasswordLength {
+		return model.NewValidationError("password", "must be at most 72 bytes")
+	}
+	return nil
+}
@@ -0,0 +1,446 @@
+package usecase_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/config"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/auth"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/repository"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/jwt"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/password"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/revocation"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/usecase"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// MockUserRepository is a mock implementation of the UserRepository interface
+type MockUserRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserRepository) Create(ctx context.Context, user *model.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) GetByEmail(ctx context.Context, email string) (*model.User, error) {
+	args := m.Called(ctx, email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+func (m *MockUserRepository) GetByID(ctx context.Context, id uint) (*model.User, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+// MockRefreshTokenRepository is a mock implementation of the RefreshTokenRepository interface
+type MockRefreshTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockRefreshTokenRepository) Create(ctx context.Context, token *model.RefreshToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*model.RefreshToken, error) {
+	args := m.Called(ctx, tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.RefreshToken), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) Rotate(ctx context.Context, current *model.RefreshToken, next *model.RefreshToken) error {
+	args := m.Called(ctx, current, next)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	args := m.Called(ctx, familyID)
+	return args.Error(0)
+}
+
+// testAuthConfig is the auth configuration shared by the auth usecase tests
+var testAuthConfig = &config.AuthConfig{
+	JWTSecret:             "test-secret",
+	AccessTokenTTLMinutes: 15,
+	RefreshTokenTTLHours:  24,
+	SuperAdminEmail:       "admin@example.com",
+}
+
+// testTokenService signs and verifies access tokens with testAuthConfig
+var testTokenService, _ = jwt.NewTokenService(testAuthConfig)
+
+func (m *MockRefreshTokenRepository) RevokeUser(ctx context.Context, userID uint) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func newAuthUsecase(repo *MockUserRepository, refreshRepo *MockRefreshTokenRepository, revocations repository.RevocationStore) usecase.AuthUsecase {
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+	hasher := password.NewBcryptHasher(bcrypt.MinCost)
+	return usecase.NewAuthUsecase(repo, refreshRepo, revocations, nil, nil, nil, nil, hasher, testTokenService, testAuthConfig, log)
+}
+
+// sha256Hex returns the hex SHA-256 of a string, matching how refresh tokens are stored
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestAuthUsecase_Register(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	authUsecase := newAuthUsecase(mockRepo, new(MockRefreshTokenRepository), revocation.NewMemoryStore())
+	ctx := context.Background()
+
+	t.Run("Success", func(t *testing.T) {
+		mockRepo.On("Create", ctx, mock.MatchedBy(func(user *model.User) bool {
+			return user.Email == "new@example.com" && user.PasswordHash != "" && user.PasswordHash != "long-enough"
+		})).Return(nil).Once()
+
+		user, err := authUsecase.Register(ctx, "  New@Example.com ", "long-enough")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "new@example.com", user.Email)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Duplicate email", func(t *testing.T) {
+		mockRepo.On("Create", ctx, mock.Anything).Return(model.ErrConflict).Once()
+
+		user, err := authUsecase.Register(ctx, "taken@example.com", "long-enough")
+
+		assert.ErrorIs(t, err, model.ErrConflict)
+		assert.Nil(t, user)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Short password", func(t *testing.T) {
+		user, err := authUsecase.Register(ctx, "new@example.com", "short")
+
+		var validationErr *model.ValidationError
+		assert.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, "password", validationErr.Field)
+		assert.Nil(t, user)
+	})
+
+	t.Run("Superadmin email cannot self-register", func(t *testing.T) {
+		user, err := authUsecase.Register(ctx, "Admin@Example.com", "long-enough")
+
+		assert.ErrorIs(t, err, model.ErrForbidden)
+		assert.Nil(t, user)
+	})
+
+	t.Run("Superadmin email can be provisioned", func(t *testing.T) {
+		mockRepo.On("Create", ctx, mock.Anything).Return(nil).Once()
+
+		user, err := authUsecase.Provision(ctx, "admin@example.com", "long-enough")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "admin@example.com", user.Email)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestAuthUsecase_Login(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockRefreshRepo := new(MockRefreshTokenRepository)
+	authUsecase := newAuthUsecase(mockRepo, mockRefreshRepo, revocation.NewMemoryStore())
+	ctx := context.Background()
+
+	hash, _ := password.NewBcryptHasher(bcrypt.MinCost).Hash("correct-password")
+	existing := &model.User{ID: 1, Email: "user@example.com", PasswordHash: hash}
+
+	t.Run("Success", func(t *testing.T) {
+		mockRepo.On("GetByEmail", ctx, "user@example.com").Return(existing, nil).Once()
+
+		var stored *model.RefreshToken
+		mockRefreshRepo.On("Create", ctx, mock.AnythingOfType("*model.RefreshToken")).
+			Run(func(args mock.Arguments) { stored = args.Get(1).(*model.RefreshToken) }).
+			Return(nil).Once()
+
+		tokens, err := authUsecase.Login(ctx, "User@example.com", "correct-password")
+
+		assert.NoError(t, err)
+		assert.Equal(t, 15*time.Minute, tokens.ExpiresIn)
+
+		claims, err := testTokenService.ValidateToken(tokens.AccessToken)
+		assert.NoError(t, err)
+		assert.Equal(t, "user@example.com", claims.Email)
+
+		// Only the hash of the refresh token is persisted
+		assert.NotEmpty(t, tokens.RefreshToken)
+		assert.Equal(t, sha256Hex(tokens.RefreshToken), stored.TokenHash)
+		assert.Equal(t, uint(1), stored.UserID)
+		assert.NotEmpty(t, stored.FamilyID)
+		assert.WithinDuration(t, time.Now().Add(24*time.Hour), stored.ExpiresAt, time.Minute)
+		mockRepo.AssertExpectations(t)
+		mockRefreshRepo.AssertExpectations(t)
+	})
+
+	t.Run("Wrong password", func(t *testing.T) {
+		mockRepo.On("GetByEmail", ctx, "user@example.com").Return(existing, nil).Once()
+
+		tokens, err := authUsecase.Login(ctx, "user@example.com", "wrong-password")
+
+		assert.ErrorIs(t, err, model.ErrInvalidCredentials)
+		assert.Nil(t, tokens)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Unknown email", func(t *testing.T) {
+		mockRepo.On("GetByEmail", ctx, "nobody@example.com").Return(nil, model.ErrNotFound).Once()
+
+		tokens, err := authUsecase.Login(ctx, "nobody@example.com", "correct-password")
+
+		assert.ErrorIs(t, err, model.ErrInvalidCredentials)
+		assert.Nil(t, tokens)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestAuthUsecase_Refresh(t *testing.T) {
+	ctx := context.Background()
+	user := &model.User{ID: 1, Email: "user@example.com"}
+	activeToken := func() *model.RefreshToken {
+		return &model.RefreshToken{
+			ID:        10,
+			UserID:    1,
+			FamilyID:  "family-1",
+			TokenHash: sha256Hex("old-refresh-token"),
+			ExpiresAt: time.Now().Add(time.Hour),
+		}
+	}
+
+	t.Run("Success rotates the token", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockRefreshRepo := new(MockRefreshTokenRepository)
+		authUsecase := newAuthUsecase(mockRepo, mockRefreshRepo, revocation.NewMemoryStore())
+
+		current := activeToken()
+		mockRefreshRepo.On("GetByHash", ctx, sha256Hex("old-refresh-token")).Return(current, nil).Once()
+		mockRepo.On("GetByID", ctx, uint(1)).Return(user, nil).Once()
+
+		var next *model.RefreshToken
+		mockRefreshRepo.On("Rotate", ctx, current, mock.AnythingOfType("*model.RefreshToken")).
+			Run(func(args mock.Arguments) { next = args.Get(2).(*model.RefreshToken) }).
+			Return(nil).Once()
+
+		tokens, err := authUsecase.Refresh(ctx, "old-refresh-token")
+
+		assert.NoError(t, err)
+		assert.NotEqual(t, "old-refresh-token", tokens.RefreshToken)
+		assert.Equal(t, sha256Hex(tokens.RefreshToken), next.TokenHash)
+		assert.Equal(t, "family-1", next.FamilyID)
+		assert.Equal(t, uint(1), next.UserID)
+
+		claims, err := testTokenService.ValidateToken(tokens.AccessToken)
+		assert.NoError(t, err)
+		assert.Equal(t, "user@example.com", claims.Email)
+		mockRepo.AssertExpectations(t)
+		mockRefreshRepo.AssertExpectations(t)
+	})
+
+	t.Run("Reusing a rotated token revokes the family", func(t *testing.T) {
+		mockRefreshRepo := new(MockRefreshTokenRepository)
+		authUsecase := newAuthUsecase(new(MockUserRepository), mockRefreshRepo, revocation.NewMemoryStore())
+
+		rotated := activeToken()
+		rotatedAt := time.Now().Add(-time.Minute)
+		rotated.RotatedAt = &rotatedAt
+		mockRefreshRepo.On("GetByHash", ctx, sha256Hex("old-refresh-token")).Return(rotated, nil).Once()
+		mockRefreshRepo.On("RevokeFamily", ctx, "family-1").Return(nil).Once()
+
+		tokens, err := authUsecase.Refresh(ctx, "old-refresh-token")
+
+		assert.ErrorIs(t, err, model.ErrInvalidToken)
+		assert.Nil(t, tokens)
+		mockRefreshRepo.AssertExpectations(t)
+	})
+
+	t.Run("Concurrent rotation is treated as reuse", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockRefreshRepo := new(MockRefreshTokenRepository)
+		authUsecase := newAuthUsecase(mockRepo, mockRefreshRepo, revocation.NewMemoryStore())
+
+		current := activeToken()
+		mockRefreshRepo.On("GetByHash", ctx, sha256Hex("old-refresh-token")).Return(current, nil).Once()
+		mockRepo.On("GetByID", ctx, uint(1)).Return(user, nil).Once()
+		mockRefreshRepo.On("Rotate", ctx, current, mock.Anything).Return(model.ErrConflict).Once()
+		mockRefreshRepo.On("RevokeFamily", ctx, "family-1").Return(nil).Once()
+
+		tokens, err := authUsecase.Refresh(ctx, "old-refresh-token")
+
+		assert.ErrorIs(t, err, model.ErrInvalidToken)
+		assert.Nil(t, tokens)
+		mockRefreshRepo.AssertExpectations(t)
+	})
+
+	t.Run("Revoked token", func(t *testing.T) {
+		mockRefreshRepo := new(MockRefreshTokenRepository)
+		authUsecase := newAuthUsecase(new(MockUserRepository), mockRefreshRepo, revocation.NewMemoryStore())
+
+		revoked := activeToken()
+		revokedAt := time.Now().Add(-time.Minute)
+		revoked.RevokedAt = &revokedAt
+		mockRefreshRepo.On("GetByHash", ctx, sha256Hex("old-refresh-token")).Return(revoked, nil).Once()
+
+		_, err := authUsecase.Refresh(ctx, "old-refresh-token")
+
+		assert.ErrorIs(t, err, model.ErrInvalidToken)
+		mockRefreshRepo.AssertExpectations(t)
+	})
+
+	t.Run("Expired token", func(t *testing.T) {
+		mockRefreshRepo := new(MockRefreshTokenRepository)
+		authUsecase := newAuthUsecase(new(MockUserRepository), mockRefreshRepo, revocation.NewMemoryStore())
+
+		expired := activeToken()
+		expired.ExpiresAt = time.Now().Add(-time.Minute)
+		mockRefreshRepo.On("GetByHash", ctx, sha256Hex("old-refresh-token")).Return(expired, nil).Once()
+
+		_, err := authUsecase.Refresh(ctx, "old-refresh-token")
+
+		assert.ErrorIs(t, err, model.ErrInvalidToken)
+		mockRefreshRepo.AssertExpectations(t)
+	})
+
+	t.Run("Unknown token", func(t *testing.T) {
+		mockRefreshRepo := new(MockRefreshTokenRepository)
+		authUsecase := newAuthUsecase(new(MockUserRepository), mockRefreshRepo, revocation.NewMemoryStore())
+
+		mockRefreshRepo.On("GetByHash", ctx, sha256Hex("unknown")).Return(nil, model.ErrNotFound).Once()
+
+		_, err := authUsecase.Refresh(ctx, "unknown")
+
+		assert.ErrorIs(t, err, model.ErrInvalidToken)
+		mockRefreshRepo.AssertExpectations(t)
+	})
+}
+
+func TestAuthUsecase_Logout(t *testing.T) {
+	expiresAt := time.Now().Add(time.Hour)
+	issuedAt := time.Now().Add(-time.Minute)
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{
+		ID:             "user@example.com",
+		Email:          "user@example.com",
+		TokenID:        "token-1",
+		TokenExpiresAt: expiresAt,
+	})
+
+	t.Run("Revokes the access token", func(t *testing.T) {
+		revocations := revocation.NewMemoryStore()
+		authUsecase := newAuthUsecase(new(MockUserRepository), new(MockRefreshTokenRepository), revocations)
+
+		err := authUsecase.Logout(ctx, "")
+
+		assert.NoError(t, err)
+		revoked, _ := revocations.IsRevoked(ctx, "token-1", "user@example.com", issuedAt)
+		assert.True(t, revoked)
+		revoked, _ = revocations.IsRevoked(ctx, "token-2", "user@example.com", issuedAt)
+		assert.False(t, revoked)
+	})
+
+	t.Run("Revokes the caller's refresh token family", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockRefreshRepo := new(MockRefreshTokenRepository)
+		authUsecase := newAuthUsecase(mockRepo, mockRefreshRepo, revocation.NewMemoryStore())
+
+		mockRefreshRepo.On("GetByHash", ctx, sha256Hex("refresh-token")).
+			Return(&model.RefreshToken{ID: 3, UserID: 1, FamilyID: "family-1"}, nil).Once()
+		mockRepo.On("GetByID", ctx, uint(1)).Return(&model.User{ID: 1, Email: "user@example.com"}, nil).Once()
+		mockRefreshRepo.On("RevokeFamily", ctx, "family-1").Return(nil).Once()
+
+		err := authUsecase.Logout(ctx, "refresh-token")
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+		mockRefreshRepo.AssertExpectations(t)
+	})
+
+	t.Run("Ignores refresh tokens of other users", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockRefreshRepo := new(MockRefreshTokenRepository)
+		authUsecase := newAuthUsecase(mockRepo, mockRefreshRepo, revocation.NewMemoryStore())
+
+		mockRefreshRepo.On("GetByHash", ctx, sha256Hex("other-token")).
+			Return(&model.RefreshToken{ID: 4, UserID: 2, FamilyID: "family-2"}, nil).Once()
+		mockRepo.On("GetByID", ctx, uint(2)).Return(&model.User{ID: 2, Email: "other@example.com"}, nil).Once()
+
+		err := authUsecase.Logout(ctx, "other-token")
+
+		assert.NoError(t, err)
+		mockRefreshRepo.AssertNotCalled(t, "RevokeFamily", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Requires a principal", func(t *testing.T) {
+		authUsecase := newAuthUsecase(new(MockUserRepository), new(MockRefreshTokenRepository), revocation.NewMemoryStore())
+
+		err := authUsecase.Logout(context.Background(), "")
+
+		assert.ErrorIs(t, err, model.ErrUnauthenticated)
+	})
+}
+
+func TestAuthUsecase_RevokeUserTokens(t *testing.T) {
+	issuedAt := time.Now().Add(-time.Minute)
+
+	t.Run("Superadmin revokes access and refresh tokens", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockRefreshRepo := new(MockRefreshTokenRepository)
+		revocations := revocation.NewMemoryStore()
+		authUsecase := newAuthUsecase(mockRepo, mockRefreshRepo, revocations)
+		ctx := superAdminContext()
+
+		mockRepo.On("GetByEmail", ctx, "user@example.com").Return(&model.User{ID: 1, Email: "user@example.com"}, nil).Once()
+		mockRefreshRepo.On("RevokeUser", ctx, uint(1)).Return(nil).Once()
+
+		err := authUsecase.RevokeUserTokens(ctx, "User@example.com")
+
+		assert.NoError(t, err)
+		revoked, _ := revocations.IsRevoked(ctx, "any-token", "user@example.com", issuedAt)
+		assert.True(t, revoked)
+		revoked, _ = revocations.IsRevoked(ctx, "later-token", "user@example.com", time.Now().Add(time.Minute))
+		assert.False(t, revoked)
+		mockRepo.AssertExpectations(t)
+		mockRefreshRepo.AssertExpectations(t)
+	})
+
+	t.Run("Unknown accounts only revoke access tokens", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		revocations := revocation.NewMemoryStore()
+		authUsecase := newAuthUsecase(mockRepo, new(MockRefreshTokenRepository), revocations)
+		ctx := superAdminContext()
+
+		mockRepo.On("GetByEmail", ctx, "external@example.com").Return(nil, model.ErrNotFound).Once()
+
+		err := authUsecase.RevokeUserTokens(ctx, "external@example.com")
+
+		assert.NoError(t, err)
+		revoked, _ := revocations.IsRevoked(ctx, "any-token", "external@example.com", issuedAt)
+		assert.True(t, revoked)
+	})
+
+	t.Run("Regular users are forbidden", func(t *testing.T) {
+		authUsecase := newAuthUsecase(new(MockUserRepository), new(MockRefreshTokenRepository), revocation.NewMemoryStore())
+
+		err := authUsecase.RevokeUserTokens(userContext(), "other@example.com")
+
+		assert.ErrorIs(t, err, model.ErrForbidden)
+	})
+}
@@ -0,0 +1,89 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/auth"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/usecase"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newImpersonationUsecase(users *MockUserRepository, auditLog usecase.AuditLog) usecase.ImpersonationUsecase {
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+	return usecase.NewImpersonationUsecase(users, testTokenService, auditLog, testAuthConfig, log)
+}
+
+func TestImpersonationUsecase_Impersonate(t *testing.T) {
+	user := &model.User{ID: 2, Email: "user@example.com", Tenant: "acme"}
+
+	t.Run("Superadmin gets a short-lived token acting as the user", func(t *testing.T) {
+		ctx := superAdminContext()
+		users := new(MockUserRepository)
+		users.On("GetByEmail", ctx, "user@example.com").Return(user, nil).Once()
+		auditLog := new(MockAuditLog)
+		auditLog.On("Record", ctx, mock.MatchedBy(func(event model.AuditEvent) bool {
+			return event.Action == usecase.AuditImpersonationStarted && event.Actor == "admin@example.com" &&
+				event.Details["subject"] == "user@example.com" && event.Details["reason"] == "ticket 42"
+		})).Once()
+
+		token, err := newImpersonationUsecase(users, auditLog).Impersonate(ctx, " User@example.com ", "ticket 42")
+
+		require.NoError(t, err)
+		assert.Equal(t, "user@example.com", token.Subject)
+		assert.Equal(t, "admin@example.com", token.Actor)
+		assert.Equal(t, 15*time.Minute, token.ExpiresIn)
+
+		claims, err := testTokenService.ValidateToken(token.AccessToken)
+		require.NoError(t, err)
+		assert.Equal(t, "user@example.com", claims.Subject())
+		assert.Equal(t, "acme", claims.Tenant)
+		require.NotNil(t, claims.Act)
+		assert.Equal(t, "admin@example.com", claims.Act.Subject)
+		users.AssertExpectations(t)
+		auditLog.AssertExpectations(t)
+	})
+
+	t.Run("Only superadmins acting as themselves may impersonate", func(t *testing.T) {
+		impersonating := auth.WithPrincipal(context.Background(), &auth.Principal{
+			Email:        "admin@example.com",
+			IsSuperAdmin: true,
+			Actor:        "admin@example.com",
+		})
+		for _, ctx := range []context.Context{userContext(), impersonating} {
+			_, err := newImpersonationUsecase(new(MockUserRepository), nil).Impersonate(ctx, "other@example.com", "ticket 42")
+			assert.ErrorIs(t, err, model.ErrForbidden)
+		}
+
+		_, err := newImpersonationUsecase(new(MockUserRepository), nil).Impersonate(context.Background(), "other@example.com", "ticket 42")
+		assert.ErrorIs(t, err, model.ErrUnauthenticated)
+	})
+
+	t.Run("The superadmin cannot be impersonated", func(t *testing.T) {
+		_, err := newImpersonationUsecase(new(MockUserRepository), nil).Impersonate(superAdminContext(), "Admin@example.com", "ticket 42")
+		assert.ErrorIs(t, err, model.ErrForbidden)
+	})
+
+	t.Run("Reason is required", func(t *testing.T) {
+		_, err := newImpersonationUsecase(new(MockUserRepository), nil).Impersonate(superAdminContext(), "user@example.com", "  ")
+
+		var validationErr *model.ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, "reason", validationErr.Field)
+	})
+
+	t.Run("Unknown user", func(t *testing.T) {
+		ctx := superAdminContext()
+		users := new(MockUserRepository)
+		users.On("GetByEmail", ctx, "missing@example.com").Return(nil, model.ErrNotFound).Once()
+
+		_, err := newImpersonationUsecase(users, nil).Impersonate(ctx, "missing@example.com", "ticket 42")
+
+		assert.ErrorIs(t, err, model.ErrNotFound)
+	})
+}
@@ -0,0 +1,263 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/auth"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+)
+
+const (
+	// loginCodeDigits is the length of the one-time code in login emails
+	loginCodeDigits = 6
+
+	// defaultLoginLinkTTL applies when no TTL is configured
+	defaultLoginLinkTTL = 15 * time.Minute
+
+	// defaultMaxLoginCodeAttempts applies when no attempt limit is configured
+	defaultMaxLoginCodeAttempts = 5
+)
+
+// errLoginLinksUnavailable is returned when passwordless login is used
+// without a login challenge repository or mailer
+var errLoginLinksUnavailable = errors.New("passwordless login is not configured")
+
+// Mailer delivers emails
+type Mailer interface {
+	// Send delivers the email or returns an error
+	Send(ctx context.Context, email model.Email) error
+}
+
+// RequestLoginLink emails a single-use login link and code to the account with the given email
+func (u *authUsecase) RequestLoginLink(ctx context.Context, email string) error {
+	if u.challenges == nil || u.mailer == nil {
+		return errLoginLinksUnavailable
+	}
+
+	email = NormalizeEmail(email)
+	if err := ValidateEmail(email); err != nil {
+		return err
+	}
+
+	user, err := u.users.GetByEmail(ctx, email)
+	if errors.Is(err, model.ErrNotFound) {
+		u.logger.Info("Login link requested for unknown email", map[string]interface{}{
+			"email": email,
+		})
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	code, err := randomLoginCode()
+	if err != nil {
+		return err
+	}
+	ttl := u.loginLinkTTL()
+	challenge := &model.LoginChallenge{
+		Email:     user.Email,
+		CodeHash:  hashLoginCode(user.Email, code),
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if err := u.challenges.Create(ctx, challenge); err != nil {
+		return err
+	}
+
+	token, err := u.tokens.GenerateLoginToken(user.Email, strconv.FormatUint(uint64(challenge.ID), 10), ttl)
+	if err != nil {
+		return err
+	}
+	link, err := loginLinkURL(u.config.MagicLink.URL, token)
+	if err != nil {
+		return err
+	}
+
+	if err := u.mailer.Send(ctx, loginEmail(user.Email, link, code, ttl)); err != nil {
+		return fmt.Errorf("failed to send login email: %w", err)
+	}
+
+	u.logger.Info("Login link sent", map[string]interface{}{
+		"email":        user.Email,
+		"challenge_id": challenge.ID,
+	})
+	return nil
+}
+
+// VerifyLoginLink exchanges the token of a login link for a token pair
+func (u *authUsecase) VerifyLoginLink(ctx context.Context, token string) (*TokenPair, error) {
+	if u.challenges == nil {
+		return nil, errLoginLinksUnavailable
+	}
+
+	email, challengeID, err := u.tokens.ValidateLoginToken(token)
+	if err != nil {
+		return nil, model.ErrInvalidToken
+	}
+	id, err := strconv.ParseUint(challengeID, 10, 0)
+	if err != nil {
+		return nil, model.ErrInvalidToken
+	}
+
+	challenge, err := u.challenges.GetByID(ctx, uint(id))
+	if errors.Is(err, model.ErrNotFound) {
+		return nil, model.ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if challenge.Email != email {
+		return nil, model.ErrInvalidToken
+	}
+
+	return u.completeLogin(ctx, challenge)
+}
+
+// VerifyLoginCode exchanges the code of the latest login email sent to the email for a token pair
+func (u *authUsecase) VerifyLoginCode(ctx context.Context, email, code string) (*TokenPair, error) {
+	if u.challenges == nil {
+		return nil, errLoginLinksUnavailable
+	}
+
+	email = NormalizeEmail(email)
+	var challenge *model.LoginChallenge
+	err := u.throttled(ctx, email, auth.AMREmail, func() (err error) {
+		challenge, err = u.checkLoginCode(ctx, email, code)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return u.completeLogin(ctx, challenge)
+}
+
+// checkLoginCode returns the latest login challenge of the email if the code is its code
+func (u *authUsecase) checkLoginCode(ctx context.Context, email, code string) (*model.LoginChallenge, error) {
+	challenge, err := u.challenges.GetLatestActive(ctx, email)
+	if errors.Is(err, model.ErrNotFound) {
+		return nil, model.ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Every attempt is counted before the code is compared, so a 6-digit code
+	// cannot be guessed by trying many codes in parallel
+	allowed, err := u.challenges.RecordAttempt(ctx, challenge.ID, u.maxLoginCodeAttempts())
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		u.logger.Warn("Login code attempts exhausted", map[string]interface{}{
+			"email":        email,
+			"challenge_id": challenge.ID,
+		})
+		return nil, model.ErrInvalidToken
+	}
+
+	expected := hashLoginCode(email, strings.TrimSpace(code))
+	if subtle.ConstantTimeCompare([]byte(challenge.CodeHash), []byte(expected)) != 1 {
+		return nil, model.ErrInvalidToken
+	}
+	return challenge, nil
+}
+
+// completeLogin consumes the challenge and issues a token pair for its account
+func (u *authUsecase) completeLogin(ctx context.Context, challenge *model.LoginChallenge) (*TokenPair, error) {
+	if challenge.UsedAt != nil || challenge.Expired(time.Now()) {
+		return nil, model.ErrInvalidToken
+	}
+
+	// Consuming the challenge invalidates both its link and its code; only
+	// one concurrent verification can win
+	if err := u.challenges.Consume(ctx, challenge.ID); err != nil {
+		if errors.Is(err, model.ErrConflict) {
+			return nil, model.ErrInvalidToken
+		}
+		return nil, err
+	}
+
+	user, err := u.users.GetByEmail(ctx, challenge.Email)
+	if errors.Is(err, model.ErrNotFound) {
+		return nil, model.ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return u.startSession(ctx, user, []string{auth.AMREmail})
+}
+
+// loginLinkTTL returns how long login links and codes are valid
+func (u *authUsecase) loginLinkTTL() time.Duration {
+	if ttl := u.config.MagicLink.TTL(); ttl > 0 {
+		return ttl
+	}
+	return defaultLoginLinkTTL
+}
+
+// maxLoginCodeAttempts returns how many codes may be entered for one login email
+func (u *authUsecase) maxLoginCodeAttempts() int {
+	if u.config.MagicLink.MaxCodeAttempts > 0 {
+		return u.config.MagicLink.MaxCodeAttempts
+	}
+	return defaultMaxLoginCodeAttempts
+}
+
+// loginLinkURL appends the login token to the configured login page URL
+func loginLinkURL(base, token string) (string, error) {
+	u, err := url.Parse(base)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("invalid magic link URL %q", base)
+	}
+	query := u.Query()
+	query.Set("token", token)
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// loginEmail builds the email carrying a login link and code
+func loginEmail(to, link, code string, ttl time.Duration) model.Email {
+	return model.Email{
+		To:      to,
+		Subject: "Your sign-in link",
+		Body: fmt.Sprintf("Open this link to sign in:\n\n%s\n\n"+
+			"Or enter this code: %s\n\n"+
+			"The link and code expire in %d minutes and can be used once. "+
+			"If you did not ask to sign in, you can ignore this email.\n",
+			link, code, int(ttl.Minutes())),
+	}
+}
+
+// randomLoginCode returns a uniformly random numeric code of loginCodeDigits digits
+func randomLoginCode() (string, error) {
+	limit := big.NewInt(1)
+	for i := 0; i < loginCodeDigits; i++ {
+		limit.Mul(limit, big.NewInt(10))
+	}
+	n, err := rand.Int(rand.Reader, limit)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", loginCodeDigits, n), nil
+}
+
+// hashLoginCode returns the hex SHA-256 of a login code, bound to the email
+// it was sent to. The attempt limit, not the hash, is what protects a code
+// this short.
+func hashLoginCode(email, code string) string {
+	sum := sha256.Sum256([]byte(email + ":" + code))
+	return hex.EncodeToString(sum[:])
+}
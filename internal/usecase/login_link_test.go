@@ -0,0 +1,266 @@
+package usecase_test
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/config"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/mailer"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/password"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/revocation"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/usecase"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// MockLoginChallengeRepository is a mock implementation of the LoginChallengeRepository interface
+type MockLoginChallengeRepository struct {
+	mock.Mock
+}
+
+func (m *MockLoginChallengeRepository) Create(ctx context.Context, challenge *model.LoginChallenge) error {
+	args := m.Called(ctx, challenge)
+	return args.Error(0)
+}
+
+func (m *MockLoginChallengeRepository) GetByID(ctx context.Context, id uint) (*model.LoginChallenge, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.LoginChallenge), args.Error(1)
+}
+
+func (m *MockLoginChallengeRepository) GetLatestActive(ctx context.Context, email string) (*model.LoginChallenge, error) {
+	args := m.Called(ctx, email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.LoginChallenge), args.Error(1)
+}
+
+func (m *MockLoginChallengeRepository) RecordAttempt(ctx context.Context, id uint, maxAttempts int) (bool, error) {
+	args := m.Called(ctx, id, maxAttempts)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockLoginChallengeRepository) Consume(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// loginEmailPattern extracts the link token and code from a login email
+var loginEmailPattern = regexp.MustCompile(`(?s)(https?://\S+).*code: (\d{6})`)
+
+// loginLinkFixture is an auth usecase with passwordless login and the mocks behind it
+type loginLinkFixture struct {
+	users       *MockUserRepository
+	refresh     *MockRefreshTokenRepository
+	challenges  *MockLoginChallengeRepository
+	outbox      *mailer.MemoryMailer
+	authUsecase usecase.AuthUsecase
+}
+
+func newLoginLinkFixture() *loginLinkFixture {
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+	authConfig := *testAuthConfig
+	authConfig.MagicLink = config.MagicLinkConfig{
+		Enabled:         true,
+		TTLMinutes:      15,
+		URL:             "https://app.example.com/login/verify",
+		MaxCodeAttempts: 5,
+	}
+
+	f := &loginLinkFixture{
+		users:      new(MockUserRepository),
+		refresh:    new(MockRefreshTokenRepository),
+		challenges: new(MockLoginChallengeRepository),
+		outbox:     mailer.NewMemoryMailer(),
+	}
+	f.authUsecase = usecase.NewAuthUsecase(f.users, f.refresh, revocation.NewMemoryStore(), f.challenges, f.outbox, nil, nil,
+		password.NewBcryptHasher(bcrypt.MinCost), testTokenService, &authConfig, log)
+	return f
+}
+
+// requestLink requests a login email for the user and returns the stored
+// challenge with the token and code that were sent
+func (f *loginLinkFixture) requestLink(t *testing.T, user *model.User) (*model.LoginChallenge, string, string) {
+	t.Helper()
+	var stored *model.LoginChallenge
+	f.users.On("GetByEmail", mock.Anything, user.Email).Return(user, nil)
+	f.challenges.On("Create", mock.Anything, mock.AnythingOfType("*model.LoginChallenge")).Run(func(args mock.Arguments) {
+		stored = args.Get(1).(*model.LoginChallenge)
+		stored.ID = 42
+	}).Return(nil).Once()
+
+	require.NoError(t, f.authUsecase.RequestLoginLink(context.Background(), user.Email))
+
+	messages := f.outbox.Messages()
+	require.NotEmpty(t, messages)
+	match := loginEmailPattern.FindStringSubmatch(messages[len(messages)-1].Body)
+	require.NotNil(t, match)
+	link, err := url.Parse(match[1])
+	require.NoError(t, err)
+	return stored, link.Query().Get("token"), match[2]
+}
+
+func TestAuthUsecase_RequestLoginLink(t *testing.T) {
+	user := &model.User{ID: 1, Email: "user@example.com", Tenant: "default"}
+
+	t.Run("Sends a link and code", func(t *testing.T) {
+		f := newLoginLinkFixture()
+
+		stored, token, code := f.requestLink(t, user)
+
+		message := f.outbox.Messages()[0]
+		assert.Equal(t, "user@example.com", message.To)
+		assert.Contains(t, message.Body, "https://app.example.com/login/verify?token=")
+		assert.NotEmpty(t, token)
+		assert.Len(t, stored.CodeHash, 64)
+		assert.NotContains(t, stored.CodeHash, code)
+		assert.WithinDuration(t, time.Now().Add(15*time.Minute), stored.ExpiresAt, time.Minute)
+	})
+
+	t.Run("Unknown email sends nothing", func(t *testing.T) {
+		f := newLoginLinkFixture()
+		f.users.On("GetByEmail", mock.Anything, "nobody@example.com").Return(nil, model.ErrNotFound).Once()
+
+		err := f.authUsecase.RequestLoginLink(context.Background(), " Nobody@Example.com ")
+
+		assert.NoError(t, err)
+		assert.Empty(t, f.outbox.Messages())
+		f.challenges.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Invalid email", func(t *testing.T) {
+		f := newLoginLinkFixture()
+
+		err := f.authUsecase.RequestLoginLink(context.Background(), "not-an-email")
+
+		var validationErr *model.ValidationError
+		assert.ErrorAs(t, err, &validationErr)
+	})
+
+	t.Run("Not configured", func(t *testing.T) {
+		authUsecase := newAuthUsecase(new(MockUserRepository), new(MockRefreshTokenRepository), revocation.NewMemoryStore())
+
+		err := authUsecase.RequestLoginLink(context.Background(), "user@example.com")
+		assert.Error(t, err)
+	})
+}
+
+func TestAuthUsecase_VerifyLoginLink(t *testing.T) {
+	user := &model.User{ID: 1, Email: "user@example.com", Tenant: "default"}
+	ctx := context.Background()
+
+	t.Run("Success consumes the challenge", func(t *testing.T) {
+		f := newLoginLinkFixture()
+		stored, token, _ := f.requestLink(t, user)
+		f.challenges.On("GetByID", ctx, uint(42)).Return(stored, nil).Once()
+		f.challenges.On("Consume", ctx, uint(42)).Return(nil).Once()
+		f.refresh.On("Create", ctx, mock.AnythingOfType("*model.RefreshToken")).Return(nil).Once()
+
+		tokens, err := f.authUsecase.VerifyLoginLink(ctx, token)
+
+		require.NoError(t, err)
+		claims, err := testTokenService.ValidateToken(tokens.AccessToken)
+		require.NoError(t, err)
+		assert.Equal(t, "user@example.com", claims.Email)
+		assert.Equal(t, []string{"email"}, claims.AMR)
+		assert.NotEmpty(t, tokens.RefreshToken)
+		f.challenges.AssertExpectations(t)
+	})
+
+	t.Run("Used link", func(t *testing.T) {
+		f := newLoginLinkFixture()
+		stored, token, _ := f.requestLink(t, user)
+		f.challenges.On("GetByID", ctx, uint(42)).Return(stored, nil).Once()
+		f.challenges.On("Consume", ctx, uint(42)).Return(model.ErrConflict).Once()
+
+		_, err := f.authUsecase.VerifyLoginLink(ctx, token)
+		assert.ErrorIs(t, err, model.ErrInvalidToken)
+	})
+
+	t.Run("Expired challenge", func(t *testing.T) {
+		f := newLoginLinkFixture()
+		stored, token, _ := f.requestLink(t, user)
+		stored.ExpiresAt = time.Now().Add(-time.Second)
+		f.challenges.On("GetByID", ctx, uint(42)).Return(stored, nil).Once()
+
+		_, err := f.authUsecase.VerifyLoginLink(ctx, token)
+		assert.ErrorIs(t, err, model.ErrInvalidToken)
+		f.challenges.AssertNotCalled(t, "Consume", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Access tokens are not login tokens", func(t *testing.T) {
+		f := newLoginLinkFixture()
+		accessToken, _ := testTokenService.GenerateToken("user@example.com", "")
+
+		_, err := f.authUsecase.VerifyLoginLink(ctx, accessToken)
+		assert.ErrorIs(t, err, model.ErrInvalidToken)
+		f.challenges.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+	})
+}
+
+func TestAuthUsecase_VerifyLoginCode(t *testing.T) {
+	user := &model.User{ID: 1, Email: "user@example.com", Tenant: "default"}
+	ctx := context.Background()
+
+	t.Run("Success", func(t *testing.T) {
+		f := newLoginLinkFixture()
+		stored, _, code := f.requestLink(t, user)
+		f.challenges.On("GetLatestActive", ctx, "user@example.com").Return(stored, nil).Once()
+		f.challenges.On("RecordAttempt", ctx, uint(42), 5).Return(true, nil).Once()
+		f.challenges.On("Consume", ctx, uint(42)).Return(nil).Once()
+		f.refresh.On("Create", ctx, mock.AnythingOfType("*model.RefreshToken")).Return(nil).Once()
+
+		tokens, err := f.authUsecase.VerifyLoginCode(ctx, "User@Example.com", code)
+
+		require.NoError(t, err)
+		assert.NotEmpty(t, tokens.AccessToken)
+		f.challenges.AssertExpectations(t)
+	})
+
+	t.Run("Wrong code", func(t *testing.T) {
+		f := newLoginLinkFixture()
+		stored, _, code := f.requestLink(t, user)
+		wrong := "000000"
+		if code == wrong {
+			wrong = "111111"
+		}
+		f.challenges.On("GetLatestActive", ctx, "user@example.com").Return(stored, nil).Once()
+		f.challenges.On("RecordAttempt", ctx, uint(42), 5).Return(true, nil).Once()
+
+		_, err := f.authUsecase.VerifyLoginCode(ctx, "user@example.com", wrong)
+
+		assert.ErrorIs(t, err, model.ErrInvalidToken)
+		f.challenges.AssertNotCalled(t, "Consume", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Attempts exhausted", func(t *testing.T) {
+		f := newLoginLinkFixture()
+		stored, _, code := f.requestLink(t, user)
+		f.challenges.On("GetLatestActive", ctx, "user@example.com").Return(stored, nil).Once()
+		f.challenges.On("RecordAttempt", ctx, uint(42), 5).Return(false, nil).Once()
+
+		_, err := f.authUsecase.VerifyLoginCode(ctx, "user@example.com", code)
+
+		assert.ErrorIs(t, err, model.ErrInvalidToken)
+		f.challenges.AssertNotCalled(t, "Consume", mock.Anything, mock.Anything)
+	})
+
+	t.Run("No pending login", func(t *testing.T) {
+		f := newLoginLinkFixture()
+		f.challenges.On("GetLatestActive", ctx, "user@example.com").Return(nil, model.ErrNotFound).Once()
+
+		_, err := f.authUsecase.VerifyLoginCode(ctx, "user@example.com", "123456")
+		assert.ErrorIs(t, err, model.ErrInvalidToken)
+	})
+}
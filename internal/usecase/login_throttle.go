@@ -0,0 +1,255 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/config"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/auth"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/repository"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+)
+
+const (
+	// defaultLockoutBaseDelay applies when no base delay is configured
+	defaultLockoutBaseDelay = time.Second
+
+	// defaultLockoutDuration applies when no lockout duration is configured
+	defaultLockoutDuration = 15 * time.Minute
+)
+
+// Audit actions recorded by the login throttle
+const (
+	AuditLoginFailed    = "login.failed"
+	AuditLoginLockedOut = "login.locked_out"
+)
+
+// AuditLog records security-relevant events
+type AuditLog interface {
+	// Record stores the event. Failing to store it is the log's to report;
+	// the action it describes is not undone.
+	Record(ctx context.Context, event model.AuditEvent)
+}
+
+// ThrottledError is returned by logins refused because of recent failed
+// attempts at the same account or from the same client IP
+type ThrottledError struct {
+	// RetryAfter is how long to wait before the next attempt
+	RetryAfter time.Duration
+	// Locked is true if the account or IP is locked out, rather than in backoff
+	Locked bool
+}
+
+// Error implements the error interface
+func (e *ThrottledError) Error() string {
+	return "too many failed login attempts"
+}
+
+// LoginThrottle slows down and locks out repeated failed logins. Failures
+// are counted per account and per client IP, as stored in the context by
+// auth.WithClientIP.
+type LoginThrottle interface {
+	// Check returns a *ThrottledError if an attempt at the account from the
+	// client IP must wait
+	Check(ctx context.Context, account string) error
+
+	// Failure records a failed attempt at the account from the client IP.
+	// method names the credential that was wrong, for the audit log.
+	Failure(ctx context.Context, account, method string) error
+
+	// Success forgets the failures of the account. Those of the client IP
+	// are kept, so one valid account does not unlock password spraying.
+	Success(ctx context.Context, account string)
+}
+
+// loginThrottle implements the LoginThrottle interface
+type loginThrottle struct {
+	store  repository.LoginAttemptStore
+	audit  AuditLog
+	config *config.LockoutConfig
+	logger *logger.Logger
+}
+
+// NewLoginThrottle creates a new login throttle. audit may be nil.
+func NewLoginThrottle(store repository.LoginAttemptStore, audit AuditLog, config *config.LockoutConfig, logger *logger.Logger) LoginThrottle {
+	return &loginThrottle{
+		store:  store,
+		audit:  audit,
+		config: config,
+		logger: logger,
+	}
+}
+
+// throttleKey is a store key, what kind of key it is and the limits that apply to it
+type throttleKey struct {
+	kind   string
+	key    string
+	limits config.LockoutLimits
+}
+
+// Check returns a *ThrottledError if an attempt must wait
+func (t *loginThrottle) Check(ctx context.Context, account string) error {
+	now := time.Now()
+	var throttled *ThrottledError
+	for _, k := range t.keys(ctx, account) {
+		attempts, err := t.store.Get(ctx, k.key, now.Add(-t.lockoutDuration()))
+		if err != nil {
+			return err
+		}
+		wait, locked := t.wait(attempts, k.limits, now)
+		if wait > 0 && (throttled == nil || wait > throttled.RetryAfter) {
+			throttled = &ThrottledError{RetryAfter: wait, Locked: locked}
+		}
+	}
+	if throttled != nil {
+		t.logger.Warn("Login attempt throttled", map[string]interface{}{
+			"email":       account,
+			"ip":          auth.ClientIPFromContext(ctx),
+			"retry_after": throttled.RetryAfter.String(),
+			"locked":      throttled.Locked,
+		})
+		return throttled
+	}
+	return nil
+}
+
+// Failure records a failed attempt and audits it
+func (t *loginThrottle) Failure(ctx context.Context, account, method string) error {
+	now := time.Now()
+	ip := auth.ClientIPFromContext(ctx)
+	details := map[string]interface{}{"method": method}
+	for _, k := range t.keys(ctx, account) {
+		attempts, err := t.store.RecordFailure(ctx, k.key, now, now.Add(-t.lockoutDuration()))
+		if err != nil {
+			return err
+		}
+		details[k.kind+"_failures"] = attempts.Failures
+		if k.limits.MaxAttempts > 0 && attempts.Failures == k.limits.MaxAttempts {
+			t.record(ctx, model.AuditEvent{
+				Action: AuditLoginLockedOut,
+				Actor:  account,
+				IP:     ip,
+				Details: map[string]interface{}{
+					"key":    k.key,
+					"until":  now.Add(t.lockoutDuration()).UTC().Format(time.RFC3339),
+					"method": method,
+				},
+			})
+		}
+	}
+	t.record(ctx, model.AuditEvent{
+		Action:  AuditLoginFailed,
+		Actor:   account,
+		IP:      ip,
+		Details: details,
+	})
+	return nil
+}
+
+// Success forgets the failures of the account
+func (t *loginThrottle) Success(ctx context.Context, account string) {
+	if account == "" {
+		return
+	}
+	if err := t.store.Reset(ctx, accountKey(account)); err != nil {
+		t.logger.Warn("Failed to reset login failures", map[string]interface{}{
+			"error": err.Error(),
+			"email": account,
+		})
+	}
+}
+
+// keys returns the store keys of the account and the client IP in ctx
+func (t *loginThrottle) keys(ctx context.Context, account string) []throttleKey {
+	var keys []throttleKey
+	if account != "" {
+		keys = append(keys, throttleKey{kind: "account", key: accountKey(account), limits: t.config.Account})
+	}
+	if ip := auth.ClientIPFromContext(ctx); ip != "" {
+		keys = append(keys, throttleKey{kind: "ip", key: "ip:" + ip, limits: t.config.IP})
+	}
+	return keys
+}
+
+// wait returns how long the next attempt of a key with the given failures
+// must wait, and whether the key is locked out
+func (t *loginThrottle) wait(attempts model.LoginAttempts, limits config.LockoutLimits, now time.Time) (time.Duration, bool) {
+	if attempts.Failures == 0 || attempts.Failures < limits.FreeAttempts {
+		return 0, false
+	}
+
+	lockout := t.lockoutDuration()
+	locked := limits.MaxAttempts > 0 && attempts.Failures >= limits.MaxAttempts
+	delay := lockout
+	if !locked {
+		// The delay doubles with every failure past the free attempts, up to
+		// the lockout duration
+		delay = t.baseDelay()
+		for n := attempts.Failures - limits.FreeAttempts; n > 0 && delay < lockout; n-- {
+			delay *= 2
+		}
+		delay = min(delay, lockout)
+	}
+
+	wait := attempts.LastFailureAt.Add(delay).Sub(now)
+	if wait <= 0 {
+		return 0, false
+	}
+	return wait, locked
+}
+
+// record writes an event to the audit log, if there is one
+func (t *loginThrottle) record(ctx context.Context, event model.AuditEvent) {
+	if t.audit != nil {
+		t.audit.Record(ctx, event)
+	}
+}
+
+// baseDelay returns the backoff after the first failure past the free attempts
+func (t *loginThrottle) baseDelay() time.Duration {
+	if delay := t.config.BaseDelay(); delay > 0 {
+		return delay
+	}
+	return defaultLockoutBaseDelay
+}
+
+// lockoutDuration returns how long keys are locked out, which is also how
+// long failures are remembered
+func (t *loginThrottle) lockoutDuration() time.Duration {
+	if duration := t.config.LockoutDuration(); duration > 0 {
+		return duration
+	}
+	return defaultLockoutDuration
+}
+
+// accountKey returns the store key of an account
+func accountKey(account string) string {
+	return "account:" + account
+}
+
+// throttled runs a check of a credential of the account under brute-force
+// protection. Errors of check that mean the credential was wrong count as
+// failed attempts; success forgets the account's failures.
+func (u *authUsecase) throttled(ctx context.Context, account, method string, check func() error) error {
+	if u.throttle == nil {
+		return check()
+	}
+
+	if err := u.throttle.Check(ctx, account); err != nil {
+		return err
+	}
+	err := check()
+	if errors.Is(err, model.ErrInvalidCredentials) || errors.Is(err, model.ErrInvalidToken) {
+		if failureErr := u.throttle.Failure(ctx, account, method); failureErr != nil {
+			return failureErr
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	u.throttle.Success(ctx, account)
+	return nil
+}
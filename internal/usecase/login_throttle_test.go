@@ -0,0 +1,165 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/config"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/auth"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/lockout"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/password"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/revocation"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/usecase"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// MockAuditLog is a mock implementation of the AuditLog interface
+type MockAuditLog struct {
+	mock.Mock
+}
+
+func (m *MockAuditLog) Record(ctx context.Context, event model.AuditEvent) {
+	m.Called(ctx, event)
+}
+
+// auditAction matches audit events with the given action
+func auditAction(action string) interface{} {
+	return mock.MatchedBy(func(event model.AuditEvent) bool {
+		return event.Action == action
+	})
+}
+
+// testLockoutConfig allows two free failures per account and locks it out at four
+var testLockoutConfig = &config.LockoutConfig{
+	Enabled:          true,
+	Account:          config.LockoutLimits{FreeAttempts: 2, MaxAttempts: 4},
+	IP:               config.LockoutLimits{FreeAttempts: 5, MaxAttempts: 10},
+	BaseDelaySeconds: 10,
+	LockoutMinutes:   15,
+}
+
+func newLoginThrottle(auditLog usecase.AuditLog) usecase.LoginThrottle {
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+	return usecase.NewLoginThrottle(lockout.NewMemoryStore(), auditLog, testLockoutConfig, log)
+}
+
+func TestLoginThrottle(t *testing.T) {
+	ctx := auth.WithClientIP(context.Background(), "192.0.2.1")
+
+	t.Run("Free attempts are not delayed", func(t *testing.T) {
+		auditLog := new(MockAuditLog)
+		auditLog.On("Record", ctx, auditAction(usecase.AuditLoginFailed)).Once()
+		throttle := newLoginThrottle(auditLog)
+
+		require.NoError(t, throttle.Failure(ctx, "user@example.com", auth.AMRPassword))
+
+		assert.NoError(t, throttle.Check(ctx, "user@example.com"))
+		auditLog.AssertExpectations(t)
+	})
+
+	t.Run("Backoff doubles past the free attempts", func(t *testing.T) {
+		auditLog := new(MockAuditLog)
+		auditLog.On("Record", ctx, auditAction(usecase.AuditLoginFailed))
+		throttle := newLoginThrottle(auditLog)
+
+		for i := 0; i < 2; i++ {
+			require.NoError(t, throttle.Failure(ctx, "user@example.com", auth.AMRPassword))
+		}
+		var throttled *usecase.ThrottledError
+		require.ErrorAs(t, throttle.Check(ctx, "user@example.com"), &throttled)
+		assert.False(t, throttled.Locked)
+		assert.InDelta(t, 10*time.Second, throttled.RetryAfter, float64(time.Second))
+
+		require.NoError(t, throttle.Failure(ctx, "user@example.com", auth.AMRPassword))
+		require.ErrorAs(t, throttle.Check(ctx, "user@example.com"), &throttled)
+		assert.InDelta(t, 20*time.Second, throttled.RetryAfter, float64(time.Second))
+
+		// Other accounts from another address are not affected
+		assert.NoError(t, throttle.Check(auth.WithClientIP(context.Background(), "192.0.2.2"), "other@example.com"))
+	})
+
+	t.Run("Max attempts lock out and are audited", func(t *testing.T) {
+		auditLog := new(MockAuditLog)
+		auditLog.On("Record", ctx, auditAction(usecase.AuditLoginFailed)).Times(4)
+		auditLog.On("Record", ctx, mock.MatchedBy(func(event model.AuditEvent) bool {
+			return event.Action == usecase.AuditLoginLockedOut && event.Actor == "user@example.com" &&
+				event.IP == "192.0.2.1" && event.Details["key"] == "account:user@example.com"
+		})).Once()
+		throttle := newLoginThrottle(auditLog)
+
+		for i := 0; i < 4; i++ {
+			require.NoError(t, throttle.Failure(ctx, "user@example.com", auth.AMRPassword))
+		}
+
+		var throttled *usecase.ThrottledError
+		require.ErrorAs(t, throttle.Check(ctx, "user@example.com"), &throttled)
+		assert.True(t, throttled.Locked)
+		assert.InDelta(t, 15*time.Minute, throttled.RetryAfter, float64(time.Second))
+		auditLog.AssertExpectations(t)
+	})
+
+	t.Run("Failures from one IP throttle every account", func(t *testing.T) {
+		auditLog := new(MockAuditLog)
+		auditLog.On("Record", ctx, mock.Anything)
+		throttle := newLoginThrottle(auditLog)
+
+		for i := 0; i < 5; i++ {
+			require.NoError(t, throttle.Failure(ctx, "user"+string(rune('a'+i))+"@example.com", auth.AMRPassword))
+		}
+
+		var throttled *usecase.ThrottledError
+		assert.ErrorAs(t, throttle.Check(ctx, "new@example.com"), &throttled)
+	})
+
+	t.Run("Success resets the account but not the IP", func(t *testing.T) {
+		auditLog := new(MockAuditLog)
+		auditLog.On("Record", ctx, mock.Anything)
+		throttle := newLoginThrottle(auditLog)
+
+		for i := 0; i < 2; i++ {
+			require.NoError(t, throttle.Failure(ctx, "user@example.com", auth.AMRPassword))
+		}
+		throttle.Success(ctx, "user@example.com")
+		assert.NoError(t, throttle.Check(ctx, "user@example.com"))
+
+		for i := 0; i < 3; i++ {
+			require.NoError(t, throttle.Failure(ctx, "other@example.com", auth.AMRPassword))
+		}
+		throttle.Success(ctx, "other@example.com")
+		var throttled *usecase.ThrottledError
+		assert.ErrorAs(t, throttle.Check(ctx, "other@example.com"), &throttled)
+	})
+}
+
+func TestAuthUsecase_LoginThrottled(t *testing.T) {
+	ctx := auth.WithClientIP(context.Background(), "192.0.2.1")
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+	hash, _ := password.NewBcryptHasher(bcrypt.MinCost).Hash("correct-password")
+	user := &model.User{ID: 1, Email: "user@example.com", PasswordHash: hash}
+
+	users := new(MockUserRepository)
+	auditLog := new(MockAuditLog)
+	auditLog.On("Record", ctx, mock.Anything)
+	authUsecase := usecase.NewAuthUsecase(users, new(MockRefreshTokenRepository), revocation.NewMemoryStore(), nil, nil, nil,
+		newLoginThrottle(auditLog), password.NewBcryptHasher(bcrypt.MinCost), testTokenService, testAuthConfig, log)
+
+	users.On("GetByEmail", ctx, "user@example.com").Return(user, nil).Twice()
+	for i := 0; i < 2; i++ {
+		_, err := authUsecase.Login(ctx, "User@example.com", "wrong-password")
+		require.ErrorIs(t, err, model.ErrInvalidCredentials)
+	}
+
+	// The password is not checked while the account waits, even if it is right
+	_, err := authUsecase.Login(ctx, "user@example.com", "correct-password")
+
+	var throttled *usecase.ThrottledError
+	assert.ErrorAs(t, err, &throttled)
+	users.AssertExpectations(t)
+	auditLog.AssertNumberOfCalls(t, "Record", 2)
+}
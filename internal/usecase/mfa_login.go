@@ -0,0 +1,117 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/auth"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+)
+
+// MFARequiredError is returned by logins whose first factor was verified but
+// which still need a second one. Token is exchanged, along with a code, at
+// VerifyMFA.
+type MFARequiredError struct {
+	Token string
+	// EnrollmentRequired is true if MFA is mandatory for the account but not
+	// enabled yet; EnrollMFA starts enrollment with Token
+	EnrollmentRequired bool
+}
+
+// Error implements the error interface
+func (e *MFARequiredError) Error() string {
+	return "multi-factor authentication required"
+}
+
+// startSession completes a login whose first factor, recorded in amr, was
+// verified. Accounts with MFA enabled, or for which it is mandatory, get an
+// *MFARequiredError instead of tokens.
+func (u *authUsecase) startSession(ctx context.Context, user *model.User, amr []string) (*TokenPair, error) {
+	if u.mfa != nil {
+		enabled, required, err := u.mfa.Requirement(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+		if enabled || required {
+			token, err := u.tokens.GenerateMFAToken(user.Email, amr, mfaChallengeTTL(u.config))
+			if err != nil {
+				return nil, err
+			}
+			u.logger.Info("User passed first factor, MFA required", map[string]interface{}{
+				"email":  user.Email,
+				"amr":    amr,
+				"enroll": !enabled,
+			})
+			return nil, &MFARequiredError{Token: token, EnrollmentRequired: !enabled}
+		}
+	}
+
+	u.logger.Info("User authenticated", map[string]interface{}{
+		"email": user.Email,
+		"amr":   amr,
+	})
+	return u.issueTokens(ctx, user, amr, nil)
+}
+
+// EnrollMFA starts TOTP enrollment for the login of an MFA token
+func (u *authUsecase) EnrollMFA(ctx context.Context, mfaToken string) (*TOTPEnrollment, error) {
+	user, _, err := u.mfaLogin(ctx, mfaToken)
+	if err != nil {
+		return nil, err
+	}
+	return u.mfa.EnrollUser(ctx, user)
+}
+
+// VerifyMFA completes the login of an MFA token with a TOTP code or recovery code
+func (u *authUsecase) VerifyMFA(ctx context.Context, mfaToken, code string) (*TokenPair, error) {
+	user, amr, err := u.mfaLogin(ctx, mfaToken)
+	if err != nil {
+		return nil, err
+	}
+
+	var recoveryCodes []string
+	err = u.throttled(ctx, user.Email, auth.AMROTP, func() (err error) {
+		recoveryCodes, err = u.mfa.VerifyUser(ctx, user, code)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, model.ErrInvalidToken) {
+			u.logger.Warn("MFA verification failed", map[string]interface{}{
+				"email": user.Email,
+			})
+		}
+		return nil, err
+	}
+
+	amr = append(append([]string(nil), amr...), auth.AMROTP, auth.AMRMFA)
+	u.logger.Info("User authenticated", map[string]interface{}{
+		"email": user.Email,
+		"amr":   amr,
+	})
+	tokens, err := u.issueTokens(ctx, user, amr, nil)
+	if err != nil {
+		return nil, err
+	}
+	tokens.RecoveryCodes = recoveryCodes
+	return tokens, nil
+}
+
+// mfaLogin returns the account and first-factor methods of the login of an MFA token
+func (u *authUsecase) mfaLogin(ctx context.Context, mfaToken string) (*model.User, []string, error) {
+	if u.mfa == nil {
+		return nil, nil, model.ErrInvalidToken
+	}
+
+	email, amr, err := u.tokens.ValidateMFAToken(mfaToken)
+	if err != nil {
+		return nil, nil, model.ErrInvalidToken
+	}
+	user, err := u.users.GetByEmail(ctx, email)
+	if errors.Is(err, model.ErrNotFound) {
+		return nil, nil, model.ErrInvalidToken
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, amr, nil
+}
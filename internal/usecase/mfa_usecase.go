@@ -0,0 +1,458 @@
+package usecase
+
+import (
+	"context"
+	"encoding/base32"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/config"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/auth"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/repository"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+)
+
+const (
+	// recoveryCodeCount is how many recovery codes are issued at a time
+	recoveryCodeCount = 10
+
+	// recoveryCodeBytes is the amount of randomness in a recovery code
+	recoveryCodeBytes = 10
+
+	// defaultMFAChallengeTTL applies when no challenge TTL is configured
+	defaultMFAChallengeTTL = 5 * time.Minute
+)
+
+// recoveryCodeEncoding encodes recovery codes in lowercase base32, which is
+// easy to read back and type
+var recoveryCodeEncoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+// TOTPAuthenticator generates TOTP secrets and verifies codes
+type TOTPAuthenticator interface {
+	// GenerateSecret returns a new random base32 secret
+	GenerateSecret() (string, error)
+
+	// ProvisioningURI returns the otpauth:// URI that authenticator apps scan
+	ProvisioningURI(account, secret string) string
+
+	// Verify checks a code against the secret and returns its time step
+	Verify(secret, code string, now time.Time) (int64, bool)
+}
+
+// RoleResolver resolves the roles a principal holds
+type RoleResolver interface {
+	// ImplicitRoles returns the roles the principal holds, directly or through other roles
+	ImplicitRoles(ctx context.Context, principal *auth.Principal) ([]string, error)
+}
+
+// TOTPEnrollment is a pending TOTP factor that the user adds to an
+// authenticator app, by scanning URI as a QR code or entering Secret
+type TOTPEnrollment struct {
+	Secret string
+	URI    string
+}
+
+// MFAStatus describes the multi-factor authentication of a user
+type MFAStatus struct {
+	// Enabled is true once a TOTP factor was confirmed
+	Enabled bool `json:"enabled"`
+	// Pending is true while a TOTP factor awaits its first code
+	Pending bool `json:"pending"`
+	// Required is true if the user may not log in without MFA
+	Required bool `json:"required"`
+	// RecoveryCodesRemaining counts the unused recovery codes
+	RecoveryCodesRemaining int `json:"recovery_codes_remaining"`
+}
+
+// MFAUsecase defines the interface for enrolling TOTP authenticator apps and
+// verifying their codes. The methods taking a context act for the user
+// principal stored in it; the methods taking a user serve the login flow.
+type MFAUsecase interface {
+	// Status describes the caller's multi-factor authentication
+	Status(ctx context.Context) (*MFAStatus, error)
+
+	// Enroll starts TOTP enrollment for the caller, replacing a pending one.
+	// It returns model.ErrConflict if MFA is already enabled.
+	Enroll(ctx context.Context) (*TOTPEnrollment, error)
+
+	// Activate confirms the caller's pending enrollment with a code from the
+	// authenticator app and returns new recovery codes.
+	// It returns model.ErrInvalidToken if the code is wrong.
+	Activate(ctx context.Context, code string) ([]string, error)
+
+	// Disable removes the caller's TOTP factor after checking a code or
+	// recovery code. It returns model.ErrForbidden if MFA is mandatory for
+	// the caller, and model.ErrInvalidToken if the code is wrong.
+	Disable(ctx context.Context, code string) error
+
+	// RegenerateRecoveryCodes replaces the caller's recovery codes after
+	// checking a code. It returns model.ErrInvalidToken if the code is wrong.
+	RegenerateRecoveryCodes(ctx context.Context, code string) ([]string, error)
+
+	// Requirement reports whether the user has MFA enabled, and whether MFA
+	// is mandatory for them
+	Requirement(ctx context.Context, user *model.User) (enabled, required bool, err error)
+
+	// EnrollUser starts TOTP enrollment for the user, replacing a pending one.
+	// It returns model.ErrConflict if MFA is already enabled.
+	EnrollUser(ctx context.Context, user *model.User) (*TOTPEnrollment, error)
+
+	// VerifyUser checks a code from the user's authenticator app, or one of
+	// their recovery codes. If the factor was pending it is activated, and
+	// the new recovery codes are returned. It returns model.ErrInvalidToken
+	// if the code is wrong or was already used.
+	VerifyUser(ctx context.Context, user *model.User, code string) ([]string, error)
+}
+
+// mfaUsecase implements the MFAUsecase interface
+type mfaUsecase struct {
+	users   repository.UserRepository
+	factors repository.MFARepository
+	totp    TOTPAuthenticator
+	roles   RoleResolver
+	config  *config.AuthConfig
+	logger  *logger.Logger
+}
+
+// NewMFAUsecase creates a new MFA usecase. roles may be nil when RBAC is
+// unavailable; users then count as holding every role in
+// auth.mfa.required_roles.
+func NewMFAUsecase(users repository.UserRepository, factors repository.MFARepository, totp TOTPAuthenticator, roles RoleResolver, config *config.AuthConfig, logger *logger.Logger) MFAUsecase {
+	return &mfaUsecase{
+		users:   users,
+		factors: factors,
+		totp:    totp,
+		roles:   roles,
+		config:  config,
+		logger:  logger,
+	}
+}
+
+// Status describes the caller's multi-factor authentication
+func (u *mfaUsecase) Status(ctx context.Context) (*MFAStatus, error) {
+	user, err := u.caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &MFAStatus{}
+	if status.Required, err = u.required(ctx, user); err != nil {
+		return nil, err
+	}
+	factor, err := u.factors.GetTOTP(ctx, user.ID)
+	if errors.Is(err, model.ErrNotFound) {
+		return status, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	status.Enabled = factor.Active()
+	status.Pending = !factor.Active()
+	if status.Enabled {
+		if status.RecoveryCodesRemaining, err = u.factors.CountRecoveryCodes(ctx, user.ID); err != nil {
+			return nil, err
+		}
+	}
+	return status, nil
+}
+
+// Enroll starts TOTP enrollment for the caller
+func (u *mfaUsecase) Enroll(ctx context.Context) (*TOTPEnrollment, error) {
+	user, err := u.caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return u.EnrollUser(ctx, user)
+}
+
+// Activate confirms the caller's pending enrollment
+func (u *mfaUsecase) Activate(ctx context.Context, code string) ([]string, error) {
+	user, err := u.caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	factor, err := u.factors.GetTOTP(ctx, user.ID)
+	if errors.Is(err, model.ErrNotFound) {
+		return nil, model.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if factor.Active() {
+		return nil, model.ErrConflict
+	}
+	return u.VerifyUser(ctx, user, code)
+}
+
+// Disable removes the caller's TOTP factor
+func (u *mfaUsecase) Disable(ctx context.Context, code string) error {
+	user, err := u.caller(ctx)
+	if err != nil {
+		return err
+	}
+
+	required, err := u.required(ctx, user)
+	if err != nil {
+		return err
+	}
+	if required {
+		return model.ErrForbidden
+	}
+
+	factor, err := u.activeFactor(ctx, user)
+	if err != nil {
+		return err
+	}
+	if err := u.checkCode(ctx, user, factor, code); err != nil {
+		return err
+	}
+	if err := u.factors.DeleteTOTP(ctx, user.ID); err != nil {
+		return err
+	}
+
+	u.logger.Warn("MFA disabled", map[string]interface{}{
+		"email": user.Email,
+	})
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces the caller's recovery codes
+func (u *mfaUsecase) RegenerateRecoveryCodes(ctx context.Context, code string) ([]string, error) {
+	user, err := u.caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	factor, err := u.activeFactor(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.checkCode(ctx, user, factor, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := u.factors.ReplaceRecoveryCodes(ctx, user.ID, hashes); err != nil {
+		return nil, err
+	}
+
+	u.logger.Info("MFA recovery codes regenerated", map[string]interface{}{
+		"email": user.Email,
+	})
+	return codes, nil
+}
+
+// Requirement reports whether the user has MFA enabled, and whether MFA is mandatory for them
+func (u *mfaUsecase) Requirement(ctx context.Context, user *model.User) (bool, bool, error) {
+	required, err := u.required(ctx, user)
+	if err != nil {
+		return false, false, err
+	}
+
+	factor, err := u.factors.GetTOTP(ctx, user.ID)
+	if errors.Is(err, model.ErrNotFound) {
+		return false, required, nil
+	}
+	if err != nil {
+		return false, false, err
+	}
+	return factor.Active(), required, nil
+}
+
+// EnrollUser starts TOTP enrollment for the user
+func (u *mfaUsecase) EnrollUser(ctx context.Context, user *model.User) (*TOTPEnrollment, error) {
+	factor, err := u.factors.GetTOTP(ctx, user.ID)
+	if err == nil && factor.Active() {
+		return nil, model.ErrConflict
+	}
+	if err != nil && !errors.Is(err, model.ErrNotFound) {
+		return nil, err
+	}
+
+	secret, err := u.totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := u.factors.SaveTOTP(ctx, &model.TOTPFactor{UserID: user.ID, Secret: secret}); err != nil {
+		return nil, err
+	}
+
+	u.logger.Info("MFA enrollment started", map[string]interface{}{
+		"email": user.Email,
+	})
+	return &TOTPEnrollment{
+		Secret: secret,
+		URI:    u.totp.ProvisioningURI(user.Email, secret),
+	}, nil
+}
+
+// VerifyUser checks a code or recovery code of the user, activating a pending factor
+func (u *mfaUsecase) VerifyUser(ctx context.Context, user *model.User, code string) ([]string, error) {
+	factor, err := u.factors.GetTOTP(ctx, user.ID)
+	if errors.Is(err, model.ErrNotFound) {
+		return nil, model.ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if factor.Active() {
+		return nil, u.checkCode(ctx, user, factor, code)
+	}
+
+	// A pending factor is confirmed by its first code; recovery codes do not
+	// exist yet
+	step, ok := u.totp.Verify(factor.Secret, normalizeOTP(code), time.Now())
+	if !ok {
+		return nil, model.ErrInvalidToken
+	}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := u.factors.ConfirmTOTP(ctx, user.ID, step, hashes); err != nil {
+		if errors.Is(err, model.ErrConflict) {
+			return nil, model.ErrInvalidToken
+		}
+		return nil, err
+	}
+
+	u.logger.Warn("MFA enabled", map[string]interface{}{
+		"email": user.Email,
+	})
+	return codes, nil
+}
+
+// checkCode verifies a code from the active factor, or consumes a recovery code
+func (u *mfaUsecase) checkCode(ctx context.Context, user *model.User, factor *model.TOTPFactor, code string) error {
+	otp := normalizeOTP(code)
+	if step, ok := u.totp.Verify(factor.Secret, otp, time.Now()); ok {
+		fresh, err := u.factors.UseTOTPStep(ctx, user.ID, step)
+		if err != nil {
+			return err
+		}
+		if !fresh {
+			u.logger.Warn("Reused TOTP code rejected", map[string]interface{}{
+				"email": user.Email,
+			})
+			return model.ErrInvalidToken
+		}
+		return nil
+	}
+
+	err := u.factors.UseRecoveryCode(ctx, user.ID, hashSecret(normalizeRecoveryCode(code)))
+	if errors.Is(err, model.ErrNotFound) {
+		return model.ErrInvalidToken
+	}
+	if err != nil {
+		return err
+	}
+	u.logger.Warn("MFA recovery code used", map[string]interface{}{
+		"email": user.Email,
+	})
+	return nil
+}
+
+// activeFactor returns the active TOTP factor of the user, or
+// model.ErrNotFound if MFA is not enabled
+func (u *mfaUsecase) activeFactor(ctx context.Context, user *model.User) (*model.TOTPFactor, error) {
+	factor, err := u.factors.GetTOTP(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !factor.Active() {
+		return nil, model.ErrNotFound
+	}
+	return factor, nil
+}
+
+// required reports whether MFA is mandatory for the user
+func (u *mfaUsecase) required(ctx context.Context, user *model.User) (bool, error) {
+	mfa := &u.config.MFA
+	if mfa.RequiredForSuperAdmin && u.config.SuperAdminEmail != "" && user.Email == NormalizeEmail(u.config.SuperAdminEmail) {
+		return true, nil
+	}
+	if len(mfa.RequiredRoles) == 0 {
+		return false, nil
+	}
+
+	// Without RBAC the roles of the user cannot be known, so MFA is required
+	// rather than possibly skipped for a privileged user
+	if u.roles == nil {
+		return true, nil
+	}
+	roles, err := u.roles.ImplicitRoles(ctx, &auth.Principal{
+		ID:     user.Email,
+		Email:  user.Email,
+		Tenant: u.config.TenantOrDefault(user.Tenant),
+	})
+	if err != nil {
+		return false, err
+	}
+	for _, role := range roles {
+		if slices.Contains(mfa.RequiredRoles, role) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// caller returns the account of the user principal stored in the context
+func (u *mfaUsecase) caller(ctx context.Context) (*model.User, error) {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, model.ErrUnauthenticated
+	}
+	// Services and users of an external identity provider have no account
+	// here, and superadmins impersonating a user may not change its factors
+	if principal.Service != "" || principal.Impersonated() {
+		return nil, model.ErrForbidden
+	}
+
+	user, err := u.users.GetByEmail(ctx, NormalizeEmail(principal.Email))
+	if errors.Is(err, model.ErrNotFound) {
+		return nil, model.ErrForbidden
+	}
+	return user, err
+}
+
+// newRecoveryCodes returns a new set of recovery codes and their hashes
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		raw, err := randomToken(recoveryCodeBytes, recoveryCodeEncoding.EncodeToString)
+		if err != nil {
+			return nil, nil, err
+		}
+		codes[i] = raw[:8] + "-" + raw[8:]
+		hashes[i] = hashSecret(normalizeRecoveryCode(codes[i]))
+	}
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode ignores the case, spaces and dashes of a recovery
+// code, so it hashes the same however it was typed
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(code)))
+}
+
+// normalizeOTP strips the spaces authenticator apps show within codes
+func normalizeOTP(code string) string {
+	return strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+}
+
+// mfaChallengeTTL returns how long the second factor of a login may be entered
+func mfaChallengeTTL(cfg *config.AuthConfig) time.Duration {
+	if ttl := cfg.MFA.ChallengeTTL(); ttl > 0 {
+		return ttl
+	}
+	return defaultMFAChallengeTTL
+}
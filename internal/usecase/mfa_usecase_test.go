@@ -0,0 +1,390 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/config"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/auth"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/password"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/revocation"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/totp"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/usecase"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// MockMFARepository is a mock implementation of the MFARepository interface
+type MockMFARepository struct {
+	mock.Mock
+}
+
+func (m *MockMFARepository) GetTOTP(ctx context.Context, userID uint) (*model.TOTPFactor, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.TOTPFactor), args.Error(1)
+}
+
+func (m *MockMFARepository) SaveTOTP(ctx context.Context, factor *model.TOTPFactor) error {
+	args := m.Called(ctx, factor)
+	return args.Error(0)
+}
+
+func (m *MockMFARepository) ConfirmTOTP(ctx context.Context, userID uint, step int64, recoveryCodeHashes []string) error {
+	args := m.Called(ctx, userID, step, recoveryCodeHashes)
+	return args.Error(0)
+}
+
+func (m *MockMFARepository) UseTOTPStep(ctx context.Context, userID uint, step int64) (bool, error) {
+	args := m.Called(ctx, userID, step)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockMFARepository) DeleteTOTP(ctx context.Context, userID uint) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockMFARepository) ReplaceRecoveryCodes(ctx context.Context, userID uint, codeHashes []string) error {
+	args := m.Called(ctx, userID, codeHashes)
+	return args.Error(0)
+}
+
+func (m *MockMFARepository) UseRecoveryCode(ctx context.Context, userID uint, codeHash string) error {
+	args := m.Called(ctx, userID, codeHash)
+	return args.Error(0)
+}
+
+func (m *MockMFARepository) CountRecoveryCodes(ctx context.Context, userID uint) (int, error) {
+	args := m.Called(ctx, userID)
+	return args.Int(0), args.Error(1)
+}
+
+// MockRoleResolver is a mock implementation of the RoleResolver interface
+type MockRoleResolver struct {
+	mock.Mock
+}
+
+func (m *MockRoleResolver) ImplicitRoles(ctx context.Context, principal *auth.Principal) ([]string, error) {
+	args := m.Called(ctx, principal)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+// testTOTP generates and verifies the codes of test factors
+var testTOTP = totp.NewAuthenticator("Test")
+
+// testTOTPSecret is the secret of the active factor in tests
+const testTOTPSecret = "JBSWY3DPEHPK3PXP"
+
+// mfaFixture is an MFA usecase, an auth usecase using it, and the mocks behind them
+type mfaFixture struct {
+	users       *MockUserRepository
+	refresh     *MockRefreshTokenRepository
+	factors     *MockMFARepository
+	roles       *MockRoleResolver
+	mfa         usecase.MFAUsecase
+	authUsecase usecase.AuthUsecase
+}
+
+func newMFAFixture(mfaConfig config.MFAConfig) *mfaFixture {
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+	authConfig := *testAuthConfig
+	authConfig.MFA = mfaConfig
+
+	f := &mfaFixture{
+		users:   new(MockUserRepository),
+		refresh: new(MockRefreshTokenRepository),
+		factors: new(MockMFARepository),
+		roles:   new(MockRoleResolver),
+	}
+	f.mfa = usecase.NewMFAUsecase(f.users, f.factors, testTOTP, f.roles, &authConfig, log)
+	f.authUsecase = usecase.NewAuthUsecase(f.users, f.refresh, revocation.NewMemoryStore(), nil, nil, f.mfa, nil,
+		password.NewBcryptHasher(bcrypt.MinCost), testTokenService, &authConfig, log)
+	return f
+}
+
+// currentCode returns the current code of the test factor
+func currentCode(t *testing.T) string {
+	t.Helper()
+	code, err := testTOTP.Code(testTOTPSecret, time.Now())
+	require.NoError(t, err)
+	return code
+}
+
+func activeFactor(userID uint) *model.TOTPFactor {
+	now := time.Now()
+	return &model.TOTPFactor{UserID: userID, Secret: testTOTPSecret, ConfirmedAt: &now}
+}
+
+func TestMFAUsecase_Requirement(t *testing.T) {
+	ctx := context.Background()
+	user := &model.User{ID: 1, Email: "user@example.com", Tenant: "default"}
+	admin := &model.User{ID: 2, Email: "admin@example.com", Tenant: "default"}
+
+	t.Run("Mandatory for the superadmin", func(t *testing.T) {
+		f := newMFAFixture(config.MFAConfig{RequiredForSuperAdmin: true})
+		f.factors.On("GetTOTP", ctx, uint(2)).Return(nil, model.ErrNotFound).Once()
+
+		enabled, required, err := f.mfa.Requirement(ctx, admin)
+
+		require.NoError(t, err)
+		assert.False(t, enabled)
+		assert.True(t, required)
+	})
+
+	t.Run("Mandatory for a configured role", func(t *testing.T) {
+		f := newMFAFixture(config.MFAConfig{RequiredRoles: []string{"admin"}})
+		f.roles.On("ImplicitRoles", ctx, mock.MatchedBy(func(p *auth.Principal) bool { return p.Email == "user@example.com" })).
+			Return([]string{"user", "admin"}, nil).Once()
+		f.factors.On("GetTOTP", ctx, uint(1)).Return(activeFactor(1), nil).Once()
+
+		enabled, required, err := f.mfa.Requirement(ctx, user)
+
+		require.NoError(t, err)
+		assert.True(t, enabled)
+		assert.True(t, required)
+	})
+
+	t.Run("Optional for other roles", func(t *testing.T) {
+		f := newMFAFixture(config.MFAConfig{RequiredRoles: []string{"admin"}})
+		f.roles.On("ImplicitRoles", ctx, mock.Anything).Return([]string{"user"}, nil).Once()
+		f.factors.On("GetTOTP", ctx, uint(1)).Return(nil, model.ErrNotFound).Once()
+
+		enabled, required, err := f.mfa.Requirement(ctx, user)
+
+		require.NoError(t, err)
+		assert.False(t, enabled)
+		assert.False(t, required)
+	})
+
+	t.Run("Mandatory without a role resolver", func(t *testing.T) {
+		log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+		factors := new(MockMFARepository)
+		factors.On("GetTOTP", ctx, uint(1)).Return(nil, model.ErrNotFound).Once()
+		mfa := usecase.NewMFAUsecase(new(MockUserRepository), factors, testTOTP, nil,
+			&config.AuthConfig{MFA: config.MFAConfig{RequiredRoles: []string{"admin"}}}, log)
+
+		_, required, err := mfa.Requirement(ctx, user)
+
+		require.NoError(t, err)
+		assert.True(t, required)
+	})
+}
+
+func TestMFAUsecase_EnrollAndVerify(t *testing.T) {
+	ctx := context.Background()
+	user := &model.User{ID: 1, Email: "user@example.com"}
+
+	t.Run("Enrollment returns a provisioning URI", func(t *testing.T) {
+		f := newMFAFixture(config.MFAConfig{})
+		f.factors.On("GetTOTP", ctx, uint(1)).Return(nil, model.ErrNotFound).Once()
+		f.factors.On("SaveTOTP", ctx, mock.MatchedBy(func(factor *model.TOTPFactor) bool {
+			return factor.UserID == 1 && factor.Secret != "" && factor.ConfirmedAt == nil
+		})).Return(nil).Once()
+
+		enrollment, err := f.mfa.EnrollUser(ctx, user)
+
+		require.NoError(t, err)
+		assert.Contains(t, enrollment.URI, "otpauth://totp/")
+		assert.Contains(t, enrollment.URI, "secret="+enrollment.Secret)
+		f.factors.AssertExpectations(t)
+	})
+
+	t.Run("Enrollment when already enabled", func(t *testing.T) {
+		f := newMFAFixture(config.MFAConfig{})
+		f.factors.On("GetTOTP", ctx, uint(1)).Return(activeFactor(1), nil).Once()
+
+		_, err := f.mfa.EnrollUser(ctx, user)
+
+		assert.ErrorIs(t, err, model.ErrConflict)
+		f.factors.AssertNotCalled(t, "SaveTOTP", mock.Anything, mock.Anything)
+	})
+
+	t.Run("First code activates a pending factor", func(t *testing.T) {
+		f := newMFAFixture(config.MFAConfig{})
+		f.factors.On("GetTOTP", ctx, uint(1)).Return(&model.TOTPFactor{UserID: 1, Secret: testTOTPSecret}, nil).Once()
+		var hashes []string
+		f.factors.On("ConfirmTOTP", ctx, uint(1), mock.AnythingOfType("int64"), mock.AnythingOfType("[]string")).
+			Run(func(args mock.Arguments) { hashes = args.Get(3).([]string) }).
+			Return(nil).Once()
+
+		codes, err := f.mfa.VerifyUser(ctx, user, currentCode(t))
+
+		require.NoError(t, err)
+		assert.Len(t, codes, 10)
+		assert.Len(t, hashes, 10)
+		assert.Regexp(t, `^[a-z2-7]{8}-[a-z2-7]{8}$`, codes[0])
+		assert.NotContains(t, hashes, codes[0])
+	})
+
+	t.Run("Code of an active factor", func(t *testing.T) {
+		f := newMFAFixture(config.MFAConfig{})
+		f.factors.On("GetTOTP", ctx, uint(1)).Return(activeFactor(1), nil).Once()
+		f.factors.On("UseTOTPStep", ctx, uint(1), mock.AnythingOfType("int64")).Return(true, nil).Once()
+
+		codes, err := f.mfa.VerifyUser(ctx, user, currentCode(t))
+
+		require.NoError(t, err)
+		assert.Empty(t, codes)
+	})
+
+	t.Run("Replayed code", func(t *testing.T) {
+		f := newMFAFixture(config.MFAConfig{})
+		f.factors.On("GetTOTP", ctx, uint(1)).Return(activeFactor(1), nil).Once()
+		f.factors.On("UseTOTPStep", ctx, uint(1), mock.AnythingOfType("int64")).Return(false, nil).Once()
+
+		_, err := f.mfa.VerifyUser(ctx, user, currentCode(t))
+
+		assert.ErrorIs(t, err, model.ErrInvalidToken)
+	})
+
+	t.Run("Recovery code", func(t *testing.T) {
+		f := newMFAFixture(config.MFAConfig{})
+		f.factors.On("GetTOTP", ctx, uint(1)).Return(activeFactor(1), nil).Once()
+		f.factors.On("UseRecoveryCode", ctx, uint(1), sha256Hex("abcdefghijklmnop")).Return(nil).Once()
+
+		_, err := f.mfa.VerifyUser(ctx, user, "ABCDEFGH-ijklmnop")
+
+		assert.NoError(t, err)
+		f.factors.AssertExpectations(t)
+	})
+
+	t.Run("Wrong code", func(t *testing.T) {
+		f := newMFAFixture(config.MFAConfig{})
+		f.factors.On("GetTOTP", ctx, uint(1)).Return(activeFactor(1), nil).Once()
+		f.factors.On("UseRecoveryCode", ctx, uint(1), mock.Anything).Return(model.ErrNotFound).Once()
+
+		_, err := f.mfa.VerifyUser(ctx, user, "000000")
+
+		assert.ErrorIs(t, err, model.ErrInvalidToken)
+	})
+}
+
+func TestMFAUsecase_Disable(t *testing.T) {
+	user := &model.User{ID: 1, Email: "user@example.com"}
+
+	t.Run("Success", func(t *testing.T) {
+		f := newMFAFixture(config.MFAConfig{})
+		ctx := userContext()
+		f.users.On("GetByEmail", ctx, "user@example.com").Return(user, nil).Once()
+		f.factors.On("GetTOTP", ctx, uint(1)).Return(activeFactor(1), nil).Once()
+		f.factors.On("UseTOTPStep", ctx, uint(1), mock.AnythingOfType("int64")).Return(true, nil).Once()
+		f.factors.On("DeleteTOTP", ctx, uint(1)).Return(nil).Once()
+
+		err := f.mfa.Disable(ctx, currentCode(t))
+
+		assert.NoError(t, err)
+		f.factors.AssertExpectations(t)
+	})
+
+	t.Run("Mandatory MFA cannot be disabled", func(t *testing.T) {
+		f := newMFAFixture(config.MFAConfig{RequiredForSuperAdmin: true})
+		ctx := superAdminContext()
+		f.users.On("GetByEmail", ctx, "admin@example.com").Return(&model.User{ID: 2, Email: "admin@example.com"}, nil).Once()
+
+		err := f.mfa.Disable(ctx, currentCode(t))
+
+		assert.ErrorIs(t, err, model.ErrForbidden)
+		f.factors.AssertNotCalled(t, "DeleteTOTP", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Services have no MFA", func(t *testing.T) {
+		f := newMFAFixture(config.MFAConfig{})
+		ctx := auth.WithPrincipal(context.Background(), &auth.Principal{ID: "service:batch", Service: "batch"})
+
+		_, err := f.mfa.Status(ctx)
+
+		assert.ErrorIs(t, err, model.ErrForbidden)
+	})
+}
+
+func TestAuthUsecase_LoginWithMFA(t *testing.T) {
+	ctx := context.Background()
+	hash, _ := password.NewBcryptHasher(bcrypt.MinCost).Hash("correct-password")
+	user := &model.User{ID: 1, Email: "user@example.com", PasswordHash: hash}
+
+	t.Run("Password login asks for the second factor", func(t *testing.T) {
+		f := newMFAFixture(config.MFAConfig{})
+		f.users.On("GetByEmail", ctx, "user@example.com").Return(user, nil)
+		f.factors.On("GetTOTP", ctx, uint(1)).Return(activeFactor(1), nil)
+		f.factors.On("UseTOTPStep", ctx, uint(1), mock.AnythingOfType("int64")).Return(true, nil).Once()
+		var stored *model.RefreshToken
+		f.refresh.On("Create", ctx, mock.AnythingOfType("*model.RefreshToken")).
+			Run(func(args mock.Arguments) { stored = args.Get(1).(*model.RefreshToken) }).
+			Return(nil).Once()
+
+		_, err := f.authUsecase.Login(ctx, "user@example.com", "correct-password")
+
+		var mfaErr *usecase.MFARequiredError
+		require.ErrorAs(t, err, &mfaErr)
+		assert.False(t, mfaErr.EnrollmentRequired)
+		f.refresh.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+
+		// The MFA token is not an access token
+		_, err = testTokenService.ValidateToken(mfaErr.Token)
+		assert.Error(t, err)
+
+		tokens, err := f.authUsecase.VerifyMFA(ctx, mfaErr.Token, currentCode(t))
+
+		require.NoError(t, err)
+		claims, err := testTokenService.ValidateToken(tokens.AccessToken)
+		require.NoError(t, err)
+		assert.Equal(t, []string{auth.AMRPassword, auth.AMROTP, auth.AMRMFA}, claims.AMR)
+		assert.Equal(t, claims.AMR, stored.AMR)
+	})
+
+	t.Run("Mandatory MFA without a factor requires enrollment", func(t *testing.T) {
+		f := newMFAFixture(config.MFAConfig{RequiredForSuperAdmin: true})
+		admin := &model.User{ID: 2, Email: "admin@example.com", PasswordHash: hash}
+		f.users.On("GetByEmail", ctx, "admin@example.com").Return(admin, nil)
+		f.factors.On("GetTOTP", ctx, uint(2)).Return(nil, model.ErrNotFound).Once()
+
+		_, err := f.authUsecase.Login(ctx, "admin@example.com", "correct-password")
+
+		var mfaErr *usecase.MFARequiredError
+		require.ErrorAs(t, err, &mfaErr)
+		assert.True(t, mfaErr.EnrollmentRequired)
+
+		f.factors.On("GetTOTP", ctx, uint(2)).Return(nil, model.ErrNotFound).Once()
+		f.factors.On("SaveTOTP", ctx, mock.AnythingOfType("*model.TOTPFactor")).Return(nil).Once()
+
+		enrollment, err := f.authUsecase.EnrollMFA(ctx, mfaErr.Token)
+
+		require.NoError(t, err)
+		assert.NotEmpty(t, enrollment.Secret)
+	})
+
+	t.Run("Invalid MFA token", func(t *testing.T) {
+		f := newMFAFixture(config.MFAConfig{})
+		accessToken, _ := testTokenService.GenerateToken("user@example.com", "")
+
+		_, err := f.authUsecase.VerifyMFA(ctx, accessToken, "123456")
+
+		assert.ErrorIs(t, err, model.ErrInvalidToken)
+		f.factors.AssertNotCalled(t, "GetTOTP", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Without MFA the login completes", func(t *testing.T) {
+		f := newMFAFixture(config.MFAConfig{})
+		f.users.On("GetByEmail", ctx, "user@example.com").Return(user, nil).Once()
+		f.factors.On("GetTOTP", ctx, uint(1)).Return(nil, model.ErrNotFound).Once()
+		f.refresh.On("Create", ctx, mock.AnythingOfType("*model.RefreshToken")).Return(nil).Once()
+
+		tokens, err := f.authUsecase.Login(ctx, "user@example.com", "correct-password")
+
+		require.NoError(t, err)
+		claims, err := testTokenService.ValidateToken(tokens.AccessToken)
+		require.NoError(t, err)
+		assert.Equal(t, []string{auth.AMRPassword}, claims.AMR)
+	})
+}
@@ -0,0 +1,135 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/auth"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+)
+
+// maxRouteChecks bounds the number of routes checked in one call to Can
+const maxRouteChecks = 100
+
+// RouteAuthorizer decides whether a caller may call an API route
+type RouteAuthorizer interface {
+	// AuthorizeRoute reports whether the principal may call the route template
+	// with the HTTP method. Superadmins may call every route.
+	AuthorizeRoute(ctx context.Context, principal *auth.Principal, method, route string) (bool, error)
+
+	// ImplicitRoles returns the roles the principal holds, directly or through other roles
+	ImplicitRoles(ctx context.Context, principal *auth.Principal) ([]string, error)
+}
+
+// RouteLister lists the API routes that are subject to authorization
+type RouteLister interface {
+	// Routes returns the routes the API serves
+	Routes() []model.Route
+}
+
+// PermissionUsecase defines the interface for introspecting the permissions
+// of the principal stored in the context, so clients can tell which actions
+// will be allowed before attempting them
+type PermissionUsecase interface {
+	// Me describes the caller, its roles and the routes it may call
+	Me(ctx context.Context) (*model.CallerPermissions, error)
+
+	// Can checks whether the caller may call each of the routes, in order.
+	// Routes the API does not serve are never allowed.
+	Can(ctx context.Context, routes []model.Route) ([]model.RouteCheck, error)
+}
+
+// permissionUsecase implements the PermissionUsecase interface
+type permissionUsecase struct {
+	authorizer RouteAuthorizer
+	routes     RouteLister
+	logger     *logger.Logger
+}
+
+// NewPermissionUsecase creates a new permission usecase. Routes are listed
+// when needed, so routes registered after it is created are included.
+func NewPermissionUsecase(authorizer RouteAuthorizer, routes RouteLister, logger *logger.Logger) PermissionUsecase {
+	return &permissionUsecase{
+		authorizer: authorizer,
+		routes:     routes,
+		logger:     logger,
+	}
+}
+
+// Me describes the caller, its roles and the routes it may call
+func (u *permissionUsecase) Me(ctx context.Context) (*model.CallerPermissions, error) {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, model.ErrUnauthenticated
+	}
+
+	roles, err := u.authorizer.ImplicitRoles(ctx, principal)
+	if err != nil {
+		return nil, err
+	}
+
+	permissions := make([]model.Route, 0)
+	for _, route := range u.routes.Routes() {
+		allowed, err := u.authorizer.AuthorizeRoute(ctx, principal, route.Method, route.Route)
+		if err != nil {
+			return nil, err
+		}
+		if allowed {
+			permissions = append(permissions, route)
+		}
+	}
+
+	return &model.CallerPermissions{
+		Email:          principal.Email,
+		Tenant:         principal.Tenant,
+		IsSuperAdmin:   principal.IsSuperAdmin,
+		Roles:          roles,
+		Scopes:         principal.Scopes,
+		ImpersonatedBy: principal.Actor,
+		Permissions:    permissions,
+	}, nil
+}
+
+// Can checks whether the caller may call each of the routes
+func (u *permissionUsecase) Can(ctx context.Context, routes []model.Route) ([]model.RouteCheck, error) {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, model.ErrUnauthenticated
+	}
+
+	if len(routes) == 0 {
+		return nil, model.NewValidationError("checks", "must name at least one route")
+	}
+	if len(routes) > maxRouteChecks {
+		return nil, model.NewValidationError("checks", fmt.Sprintf("must name at most %d routes", maxRouteChecks))
+	}
+
+	served := make(map[model.Route]bool)
+	for _, route := range u.routes.Routes() {
+		served[route] = true
+	}
+
+	checks := make([]model.RouteCheck, 0, len(routes))
+	for _, route := range routes {
+		route.Method = strings.ToUpper(strings.TrimSpace(route.Method))
+		route.Route = strings.TrimSpace(route.Route)
+		if !policyActions[route.Method] {
+			return nil, model.NewValidationError("method", "must be an HTTP method")
+		}
+		if !strings.HasPrefix(route.Route, "/") {
+			return nil, model.NewValidationError("route", "must be a route template starting with /")
+		}
+
+		allowed := false
+		if served[route] {
+			var err error
+			if allowed, err = u.authorizer.AuthorizeRoute(ctx, principal, route.Method, route.Route); err != nil {
+				return nil, err
+			}
+		}
+		checks = append(checks, model.RouteCheck{Route: route, Allowed: allowed})
+	}
+	return checks, nil
+}
@@ -0,0 +1,154 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/auth"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/usecase"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockRouteAuthorizer is a mock implementation of the RouteAuthorizer interface
+type MockRouteAuthorizer struct {
+	mock.Mock
+}
+
+func (m *MockRouteAuthorizer) AuthorizeRoute(ctx context.Context, principal *auth.Principal, method, route string) (bool, error) {
+	args := m.Called(ctx, principal, method, route)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRouteAuthorizer) ImplicitRoles(ctx context.Context, principal *auth.Principal) ([]string, error) {
+	args := m.Called(ctx, principal)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+// staticRoutes is a RouteLister serving a fixed set of routes
+type staticRoutes []model.Route
+
+func (r staticRoutes) Routes() []model.Route {
+	return r
+}
+
+var (
+	listTodos  = model.Route{Method: "GET", Route: "/api/v1/todos"}
+	createTodo = model.Route{Method: "POST", Route: "/api/v1/todos"}
+	deleteTodo = model.Route{Method: "DELETE", Route: "/api/v1/todos/:id"}
+)
+
+func TestPermissionUsecase_Me(t *testing.T) {
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+	routes := staticRoutes{listTodos, createTodo, deleteTodo}
+
+	t.Run("Lists permitted routes", func(t *testing.T) {
+		authorizer := new(MockRouteAuthorizer)
+		permissionUsecase := usecase.NewPermissionUsecase(authorizer, routes, log)
+		ctx := userContext()
+
+		authorizer.On("ImplicitRoles", ctx, mock.Anything).Return([]string{"user"}, nil).Once()
+		authorizer.On("AuthorizeRoute", ctx, mock.Anything, "GET", "/api/v1/todos").Return(true, nil).Once()
+		authorizer.On("AuthorizeRoute", ctx, mock.Anything, "POST", "/api/v1/todos").Return(false, nil).Once()
+		authorizer.On("AuthorizeRoute", ctx, mock.Anything, "DELETE", "/api/v1/todos/:id").Return(true, nil).Once()
+
+		me, err := permissionUsecase.Me(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "user@example.com", me.Email)
+		assert.False(t, me.IsSuperAdmin)
+		assert.Equal(t, []string{"user"}, me.Roles)
+		assert.Equal(t, []model.Route{listTodos, deleteTodo}, me.Permissions)
+		authorizer.AssertExpectations(t)
+	})
+
+	t.Run("Superadmins may call every route", func(t *testing.T) {
+		authorizer := new(MockRouteAuthorizer)
+		permissionUsecase := usecase.NewPermissionUsecase(authorizer, routes, log)
+		ctx := superAdminContext()
+
+		authorizer.On("ImplicitRoles", ctx, mock.Anything).Return([]string{}, nil).Once()
+		authorizer.On("AuthorizeRoute", ctx, mock.MatchedBy(func(p *auth.Principal) bool { return p.IsSuperAdmin }), mock.Anything, mock.Anything).Return(true, nil).Times(len(routes))
+
+		me, err := permissionUsecase.Me(ctx)
+		require.NoError(t, err)
+		assert.True(t, me.IsSuperAdmin)
+		assert.Equal(t, []model.Route(routes), me.Permissions)
+		authorizer.AssertExpectations(t)
+	})
+
+	t.Run("Requires authentication", func(t *testing.T) {
+		permissionUsecase := usecase.NewPermissionUsecase(new(MockRouteAuthorizer), routes, log)
+
+		_, err := permissionUsecase.Me(context.Background())
+		assert.ErrorIs(t, err, model.ErrUnauthenticated)
+	})
+}
+
+func TestPermissionUsecase_Can(t *testing.T) {
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+	authorizer := new(MockRouteAuthorizer)
+	permissionUsecase := usecase.NewPermissionUsecase(authorizer, staticRoutes{listTodos, deleteTodo}, log)
+	ctx := userContext()
+
+	t.Run("Checks each route in order", func(t *testing.T) {
+		authorizer.On("AuthorizeRoute", ctx, mock.Anything, "DELETE", "/api/v1/todos/:id").Return(false, nil).Once()
+		authorizer.On("AuthorizeRoute", ctx, mock.Anything, "GET", "/api/v1/todos").Return(true, nil).Once()
+
+		checks, err := permissionUsecase.Can(ctx, []model.Route{
+			{Method: "delete", Route: " /api/v1/todos/:id"},
+			listTodos,
+			{Method: "GET", Route: "/api/v1/unknown"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []model.RouteCheck{
+			{Route: deleteTodo, Allowed: false},
+			{Route: listTodos, Allowed: true},
+			{Route: model.Route{Method: "GET", Route: "/api/v1/unknown"}, Allowed: false},
+		}, checks)
+		authorizer.AssertExpectations(t)
+	})
+
+	t.Run("Unserved routes are denied to superadmins", func(t *testing.T) {
+		ctx := superAdminContext()
+		authorizer.On("AuthorizeRoute", ctx, mock.Anything, "GET", "/api/v1/todos").Return(true, nil).Once()
+
+		checks, err := permissionUsecase.Can(ctx, []model.Route{listTodos, createTodo})
+		require.NoError(t, err)
+		assert.True(t, checks[0].Allowed)
+		assert.False(t, checks[1].Allowed)
+	})
+
+	t.Run("Invalid checks", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			routes []model.Route
+			field  string
+		}{
+			{name: "no routes", routes: nil, field: "checks"},
+			{name: "too many routes", routes: make([]model.Route, 101), field: "checks"},
+			{name: "unknown method", routes: []model.Route{{Method: "FETCH", Route: "/api/v1/todos"}}, field: "method"},
+			{name: "path without leading slash", routes: []model.Route{{Method: "GET", Route: "api/v1/todos"}}, field: "route"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := permissionUsecase.Can(ctx, tt.routes)
+
+				var validationErr *model.ValidationError
+				require.ErrorAs(t, err, &validationErr)
+				assert.Equal(t, tt.field, validationErr.Field)
+			})
+		}
+	})
+
+	t.Run("Requires authentication", func(t *testing.T) {
+		_, err := permissionUsecase.Can(context.Background(), []model.Route{listTodos})
+		assert.ErrorIs(t, err, model.ErrUnauthenticated)
+	})
+}
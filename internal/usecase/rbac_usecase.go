@@ -0,0 +1,365 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/auth"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/repository"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+)
+
+// policyActions are the actions a permission rule may grant; requests are authorized by HTTP method
+var policyActions = map[string]bool{
+	"GET":     true,
+	"HEAD":    true,
+	"POST":    true,
+	"PUT":     true,
+	"PATCH":   true,
+	"DELETE":  true,
+	"OPTIONS": true,
+}
+
+// maxPolicyCases bounds the number of cases evaluated in one dry run
+const maxPolicyCases = 1000
+
+// PolicyReloader reports on hot reloads of the RBAC model and policy
+type PolicyReloader interface {
+	// Status returns the reload count and the outcome of the last reload
+	Status() model.PolicyReloadStatus
+}
+
+// PolicyExplainer explains the decisions of the permission rules
+type PolicyExplainer interface {
+	// Explain evaluates the query with the active policy and explains the decision
+	Explain(ctx context.Context, query model.PolicyQuery) (*model.PolicyDecision, error)
+
+	// DryRun evaluates the cases with a proposed policy without changing the active policy
+	DryRun(ctx context.Context, policy string, cases []model.PolicyCase) (*model.PolicyDryRun, error)
+}
+
+// RBACUsecase defines the interface for administering RBAC rules.
+// Every method requires a superadmin caller.
+type RBACUsecase interface {
+	// ListPolicies returns every permission rule
+	ListPolicies(ctx context.Context) ([]model.Policy, error)
+
+	// AddPolicy adds a permission rule and returns it as stored
+	AddPolicy(ctx context.Context, policy model.Policy) (*model.Policy, error)
+
+	// RemovePolicy removes a permission rule
+	RemovePolicy(ctx context.Context, policy model.Policy) error
+
+	// ListRoleAssignments returns every role assignment
+	ListRoleAssignments(ctx context.Context) ([]model.RoleAssignment, error)
+
+	// AddRoleAssignment adds a role assignment and returns it as stored
+	AddRoleAssignment(ctx context.Context, assignment model.RoleAssignment) (*model.RoleAssignment, error)
+
+	// RemoveRoleAssignment removes a role assignment
+	RemoveRoleAssignment(ctx context.Context, assignment model.RoleAssignment) error
+
+	// ReloadStatus reports on hot reloads of the RBAC model and policy
+	ReloadStatus(ctx context.Context) (*model.PolicyReloadStatus, error)
+
+	// Explain evaluates a query with the permission rules and explains the decision
+	Explain(ctx context.Context, query model.PolicyQuery) (*model.PolicyDecision, error)
+
+	// DryRun evaluates cases with a proposed policy, in the format of
+	// policy.csv, without changing the active policy
+	DryRun(ctx context.Context, policy string, cases []model.PolicyCase) (*model.PolicyDryRun, error)
+}
+
+// rbacUsecase implements the RBACUsecase interface
+type rbacUsecase struct {
+	policies  repository.PolicyRepository
+	reloader  PolicyReloader
+	explainer PolicyExplainer
+	logger    *logger.Logger
+}
+
+// NewRBACUsecase creates a new RBAC usecase
+func NewRBACUsecase(policies repository.PolicyRepository, reloader PolicyReloader, explainer PolicyExplainer, logger *logger.Logger) RBACUsecase {
+	return &rbacUsecase{
+		policies:  policies,
+		reloader:  reloader,
+		explainer: explainer,
+		logger:    logger,
+	}
+}
+
+// ListPolicies returns every permission rule
+func (u *rbacUsecase) ListPolicies(ctx context.Context) ([]model.Policy, error) {
+	if _, err := requireSuperAdmin(ctx); err != nil {
+		return nil, err
+	}
+	return u.policies.ListPolicies(ctx)
+}
+
+// AddPolicy adds a permission rule
+func (u *rbacUsecase) AddPolicy(ctx context.Context, policy model.Policy) (*model.Policy, error) {
+	principal, err := requireSuperAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err = normalizePolicy(policy)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.policies.AddPolicy(ctx, policy); err != nil {
+		return nil, err
+	}
+
+	u.logger.Warn("Policy added", map[string]interface{}{
+		"subject":    policy.Subject,
+		"tenant":     policy.Tenant,
+		"object":     policy.Object,
+		"action":     policy.Action,
+		"changed_by": principal.Email,
+	})
+
+	return &policy, nil
+}
+
+// RemovePolicy removes a permission rule
+func (u *rbacUsecase) RemovePolicy(ctx context.Context, policy model.Policy) error {
+	principal, err := requireSuperAdmin(ctx)
+	if err != nil {
+		return err
+	}
+
+	policy, err = normalizePolicy(policy)
+	if err != nil {
+		return err
+	}
+	if err := u.policies.RemovePolicy(ctx, policy); err != nil {
+		return err
+	}
+
+	u.logger.Warn("Policy removed", map[string]interface{}{
+		"subject":    policy.Subject,
+		"tenant":     policy.Tenant,
+		"object":     policy.Object,
+		"action":     policy.Action,
+		"changed_by": principal.Email,
+	})
+
+	return nil
+}
+
+// ListRoleAssignments returns every role assignment
+func (u *rbacUsecase) ListRoleAssignments(ctx context.Context) ([]model.RoleAssignment, error) {
+	if _, err := requireSuperAdmin(ctx); err != nil {
+		return nil, err
+	}
+	return u.policies.ListRoleAssignments(ctx)
+}
+
+// AddRoleAssignment adds a role assignment
+func (u *rbacUsecase) AddRoleAssignment(ctx context.Context, assignment model.RoleAssignment) (*model.RoleAssignment, error) {
+	principal, err := requireSuperAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	assignment, err = normalizeRoleAssignment(assignment)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.policies.AddRoleAssignment(ctx, assignment); err != nil {
+		return nil, err
+	}
+
+	u.logger.Warn("Role assigned", map[string]interface{}{
+		"subject":    assignment.Subject,
+		"role":       assignment.Role,
+		"tenant":     assignment.Tenant,
+		"changed_by": principal.Email,
+	})
+
+	return &assignment, nil
+}
+
+// RemoveRoleAssignment removes a role assignment
+func (u *rbacUsecase) RemoveRoleAssignment(ctx context.Context, assignment model.RoleAssignment) error {
+	principal, err := requireSuperAdmin(ctx)
+	if err != nil {
+		return err
+	}
+
+	assignment, err = normalizeRoleAssignment(assignment)
+	if err != nil {
+		return err
+	}
+	if err := u.policies.RemoveRoleAssignment(ctx, assignment); err != nil {
+		return err
+	}
+
+	u.logger.Warn("Role unassigned", map[string]interface{}{
+		"subject":    assignment.Subject,
+		"role":       assignment.Role,
+		"tenant":     assignment.Tenant,
+		"changed_by": principal.Email,
+	})
+
+	return nil
+}
+
+// ReloadStatus reports on hot reloads of the RBAC model and policy
+func (u *rbacUsecase) ReloadStatus(ctx context.Context) (*model.PolicyReloadStatus, error) {
+	if _, err := requireSuperAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	status := u.reloader.Status()
+	return &status, nil
+}
+
+// Explain evaluates a query with the permission rules and explains the decision
+func (u *rbacUsecase) Explain(ctx context.Context, query model.PolicyQuery) (*model.PolicyDecision, error) {
+	if _, err := requireSuperAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	query, err := normalizePolicyQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	return u.explainer.Explain(ctx, query)
+}
+
+// DryRun evaluates cases with a proposed policy
+func (u *rbacUsecase) DryRun(ctx context.Context, policy string, cases []model.PolicyCase) (*model.PolicyDryRun, error) {
+	if _, err := requireSuperAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(policy) == "" {
+		return nil, model.NewValidationError("policy", "is required")
+	}
+	if len(cases) == 0 {
+		return nil, model.NewValidationError("cases", "must contain at least one case")
+	}
+	if len(cases) > maxPolicyCases {
+		return nil, model.NewValidationError("cases", fmt.Sprintf("must contain at most %d cases", maxPolicyCases))
+	}
+
+	normalized := make([]model.PolicyCase, len(cases))
+	for i, c := range cases {
+		query, err := normalizePolicyQuery(c.PolicyQuery)
+		if err != nil {
+			var validationErr *model.ValidationError
+			if errors.As(err, &validationErr) {
+				return nil, model.NewValidationError(fmt.Sprintf("cases[%d].%s", i, validationErr.Field), validationErr.Message)
+			}
+			return nil, err
+		}
+		normalized[i] = model.PolicyCase{PolicyQuery: query, Allow: c.Allow}
+	}
+	return u.explainer.DryRun(ctx, policy, normalized)
+}
+
+// requireSuperAdmin returns the caller if it is a superadmin
+func requireSuperAdmin(ctx context.Context) (*auth.Principal, error) {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, model.ErrUnauthenticated
+	}
+	if !principal.IsSuperAdmin {
+		return nil, model.ErrForbidden
+	}
+	return principal, nil
+}
+
+// normalizePolicy trims the fields of a permission rule, upper-cases its
+// action and checks that it can match requests. Rules naming no tenant
+// apply in every tenant.
+func normalizePolicy(policy model.Policy) (model.Policy, error) {
+	policy.Subject = normalizeSubject(policy.Subject)
+	policy.Tenant = strings.TrimSpace(policy.Tenant)
+	if policy.Tenant == "" {
+		policy.Tenant = model.AllTenants
+	}
+	policy.Object = strings.TrimSpace(policy.Object)
+	policy.Action = strings.ToUpper(strings.TrimSpace(policy.Action))
+
+	if policy.Subject == "" {
+		return policy, model.NewValidationError("subject", "is required")
+	}
+	if !isRouteObject(policy.Object) {
+		return policy, model.NewValidationError("object", "must be a route template starting with /, optionally prefixed with a resource name and :")
+	}
+	if !policyActions[policy.Action] {
+		return policy, model.NewValidationError("action", "must be an HTTP method")
+	}
+	return policy, nil
+}
+
+// isRouteObject reports whether a policy object is a route template, such as
+// /api/v1/todos/:id, or a route template prefixed with a resource name, such
+// as todos:/api/v1/todos/:id
+func isRouteObject(object string) bool {
+	if strings.HasPrefix(object, "/") {
+		return true
+	}
+	resource, route, ok := strings.Cut(object, ":")
+	return ok && resource != "" && !strings.Contains(resource, "/") && strings.HasPrefix(route, "/")
+}
+
+// normalizePolicyQuery trims the fields of a policy query, upper-cases its
+// action and checks them like the fields of a permission rule. Queries are
+// evaluated in a single tenant.
+func normalizePolicyQuery(query model.PolicyQuery) (model.PolicyQuery, error) {
+	query.Subject = normalizeSubject(query.Subject)
+	query.Tenant = strings.TrimSpace(query.Tenant)
+	query.Object = strings.TrimSpace(query.Object)
+	query.Action = strings.ToUpper(strings.TrimSpace(query.Action))
+
+	if query.Subject == "" {
+		return query, model.NewValidationError("subject", "is required")
+	}
+	if query.Tenant == "" || query.Tenant == model.AllTenants {
+		return query, model.NewValidationError("tenant", "must name a single tenant")
+	}
+	if !isRouteObject(query.Object) {
+		return query, model.NewValidationError("object", "must be a route template starting with /, optionally prefixed with a resource name and :")
+	}
+	if !policyActions[query.Action] {
+		return query, model.NewValidationError("action", "must be an HTTP method")
+	}
+	return query, nil
+}
+
+// normalizeRoleAssignment trims the fields of a role assignment and checks them
+func normalizeRoleAssignment(assignment model.RoleAssignment) (model.RoleAssignment, error) {
+	assignment.Subject = normalizeSubject(assignment.Subject)
+	assignment.Role = strings.TrimSpace(assignment.Role)
+	assignment.Tenant = strings.TrimSpace(assignment.Tenant)
+
+	if assignment.Subject == "" {
+		return assignment, model.NewValidationError("subject", "is required")
+	}
+	if assignment.Role == "" {
+		return assignment, model.NewValidationError("role", "is required")
+	}
+	if assignment.Subject == assignment.Role {
+		return assignment, model.NewValidationError("role", "must differ from the subject")
+	}
+	if assignment.Tenant == "" || assignment.Tenant == model.AllTenants {
+		return assignment, model.NewValidationError("tenant", "must name a single tenant")
+	}
+	return assignment, nil
+}
+
+// normalizeSubject trims a rule subject. Emails are lowercased to match the
+// subjects the auth middleware enforces with; role names are kept as given.
+func normalizeSubject(subject string) string {
+	if strings.Contains(subject, "@") {
+		return NormalizeEmail(subject)
+	}
+	return strings.TrimSpace(subject)
+}
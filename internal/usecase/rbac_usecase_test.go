@@ -0,0 +1,304 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/usecase"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockPolicyRepository is a mock implementation of the PolicyRepository interface
+type MockPolicyRepository struct {
+	mock.Mock
+}
+
+func (m *MockPolicyRepository) ListPolicies(ctx context.Context) ([]model.Policy, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.Policy), args.Error(1)
+}
+
+func (m *MockPolicyRepository) AddPolicy(ctx context.Context, policy model.Policy) error {
+	args := m.Called(ctx, policy)
+	return args.Error(0)
+}
+
+func (m *MockPolicyRepository) RemovePolicy(ctx context.Context, policy model.Policy) error {
+	args := m.Called(ctx, policy)
+	return args.Error(0)
+}
+
+func (m *MockPolicyRepository) ListRoleAssignments(ctx context.Context) ([]model.RoleAssignment, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.RoleAssignment), args.Error(1)
+}
+
+func (m *MockPolicyRepository) AddRoleAssignment(ctx context.Context, assignment model.RoleAssignment) error {
+	args := m.Called(ctx, assignment)
+	return args.Error(0)
+}
+
+func (m *MockPolicyRepository) RemoveRoleAssignment(ctx context.Context, assignment model.RoleAssignment) error {
+	args := m.Called(ctx, assignment)
+	return args.Error(0)
+}
+
+// MockPolicyReloader is a mock implementation of the PolicyReloader interface
+type MockPolicyReloader struct {
+	mock.Mock
+}
+
+func (m *MockPolicyReloader) Status() model.PolicyReloadStatus {
+	args := m.Called()
+	return args.Get(0).(model.PolicyReloadStatus)
+}
+
+// MockPolicyExplainer is a mock implementation of the PolicyExplainer interface
+type MockPolicyExplainer struct {
+	mock.Mock
+}
+
+func (m *MockPolicyExplainer) Explain(ctx context.Context, query model.PolicyQuery) (*model.PolicyDecision, error) {
+	args := m.Called(ctx, query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.PolicyDecision), args.Error(1)
+}
+
+func (m *MockPolicyExplainer) DryRun(ctx context.Context, policy string, cases []model.PolicyCase) (*model.PolicyDryRun, error) {
+	args := m.Called(ctx, policy, cases)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.PolicyDryRun), args.Error(1)
+}
+
+func TestRBACUsecase_RequiresSuperAdmin(t *testing.T) {
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+	mockRepo := new(MockPolicyRepository)
+	rbacUsecase := usecase.NewRBACUsecase(mockRepo, new(MockPolicyReloader), new(MockPolicyExplainer), log)
+
+	_, err := rbacUsecase.ListPolicies(context.Background())
+	assert.ErrorIs(t, err, model.ErrUnauthenticated)
+
+	_, err = rbacUsecase.ListPolicies(userContext())
+	assert.ErrorIs(t, err, model.ErrForbidden)
+
+	_, err = rbacUsecase.AddPolicy(userContext(), model.Policy{Subject: "user", Object: "/api/v1/todos", Action: "POST"})
+	assert.ErrorIs(t, err, model.ErrForbidden)
+
+	err = rbacUsecase.RemoveRoleAssignment(userContext(), model.RoleAssignment{Subject: "user@example.com", Role: "admin"})
+	assert.ErrorIs(t, err, model.ErrForbidden)
+
+	mockRepo.AssertNotCalled(t, "ListPolicies", mock.Anything)
+	mockRepo.AssertNotCalled(t, "AddPolicy", mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "RemoveRoleAssignment", mock.Anything, mock.Anything)
+}
+
+func TestRBACUsecase_AddPolicy(t *testing.T) {
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+
+	t.Run("Normalizes and stores the rule", func(t *testing.T) {
+		mockRepo := new(MockPolicyRepository)
+		rbacUsecase := usecase.NewRBACUsecase(mockRepo, new(MockPolicyReloader), new(MockPolicyExplainer), log)
+
+		expected := model.Policy{Subject: "manager", Tenant: model.AllTenants, Object: "/api/v1/todos/:id", Action: "DELETE"}
+		mockRepo.On("AddPolicy", mock.Anything, expected).Return(nil)
+
+		policy, err := rbacUsecase.AddPolicy(superAdminContext(), model.Policy{Subject: " manager ", Object: "/api/v1/todos/:id", Action: "delete"})
+		assert.NoError(t, err)
+		assert.Equal(t, &expected, policy)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Accepts resource-prefixed objects", func(t *testing.T) {
+		mockRepo := new(MockPolicyRepository)
+		rbacUsecase := usecase.NewRBACUsecase(mockRepo, new(MockPolicyReloader), new(MockPolicyExplainer), log)
+
+		expected := model.Policy{Subject: "manager", Tenant: "acme", Object: "todos:/api/v1/todos/:id", Action: "GET"}
+		mockRepo.On("AddPolicy", mock.Anything, expected).Return(nil)
+
+		_, err := rbacUsecase.AddPolicy(superAdminContext(), expected)
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Rejects invalid rules", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			policy model.Policy
+			field  string
+		}{
+			{name: "missing subject", policy: model.Policy{Subject: " ", Object: "/api/v1/todos", Action: "GET"}, field: "subject"},
+			{name: "relative object", policy: model.Policy{Subject: "user", Object: "api/v1/todos", Action: "GET"}, field: "object"},
+			{name: "resource without route", policy: model.Policy{Subject: "user", Object: "todos:", Action: "GET"}, field: "object"},
+			{name: "empty resource", policy: model.Policy{Subject: "user", Object: ":/api/v1/todos", Action: "GET"}, field: "object"},
+			{name: "unknown action", policy: model.Policy{Subject: "user", Object: "/api/v1/todos", Action: "READ"}, field: "action"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				mockRepo := new(MockPolicyRepository)
+				rbacUsecase := usecase.NewRBACUsecase(mockRepo, new(MockPolicyReloader), new(MockPolicyExplainer), log)
+
+				_, err := rbacUsecase.AddPolicy(superAdminContext(), tt.policy)
+				var validationErr *model.ValidationError
+				assert.True(t, errors.As(err, &validationErr))
+				assert.Equal(t, tt.field, validationErr.Field)
+				mockRepo.AssertNotCalled(t, "AddPolicy", mock.Anything, mock.Anything)
+			})
+		}
+	})
+
+	t.Run("Passes repository errors through", func(t *testing.T) {
+		mockRepo := new(MockPolicyRepository)
+		rbacUsecase := usecase.NewRBACUsecase(mockRepo, new(MockPolicyReloader), new(MockPolicyExplainer), log)
+		mockRepo.On("AddPolicy", mock.Anything, mock.Anything).Return(model.ErrConflict)
+
+		_, err := rbacUsecase.AddPolicy(superAdminContext(), model.Policy{Subject: "user", Object: "/api/v1/todos", Action: "GET"})
+		assert.ErrorIs(t, err, model.ErrConflict)
+	})
+}
+
+func TestRBACUsecase_AddRoleAssignment(t *testing.T) {
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+
+	t.Run("Lowercases email subjects", func(t *testing.T) {
+		mockRepo := new(MockPolicyRepository)
+		rbacUsecase := usecase.NewRBACUsecase(mockRepo, new(MockPolicyReloader), new(MockPolicyExplainer), log)
+
+		expected := model.RoleAssignment{Subject: "carol@example.com", Role: "Manager", Tenant: "acme"}
+		mockRepo.On("AddRoleAssignment", mock.Anything, expected).Return(nil)
+
+		assignment, err := rbacUsecase.AddRoleAssignment(superAdminContext(), model.RoleAssignment{Subject: "Carol@Example.com", Role: "Manager", Tenant: " acme "})
+		assert.NoError(t, err)
+		assert.Equal(t, &expected, assignment)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Rejects self assignment", func(t *testing.T) {
+		mockRepo := new(MockPolicyRepository)
+		rbacUsecase := usecase.NewRBACUsecase(mockRepo, new(MockPolicyReloader), new(MockPolicyExplainer), log)
+
+		_, err := rbacUsecase.AddRoleAssignment(superAdminContext(), model.RoleAssignment{Subject: "admin", Role: "admin", Tenant: "acme"})
+		var validationErr *model.ValidationError
+		assert.True(t, errors.As(err, &validationErr))
+		mockRepo.AssertNotCalled(t, "AddRoleAssignment", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Requires a single tenant", func(t *testing.T) {
+		mockRepo := new(MockPolicyRepository)
+		rbacUsecase := usecase.NewRBACUsecase(mockRepo, new(MockPolicyReloader), new(MockPolicyExplainer), log)
+
+		for _, tenant := range []string{"", " ", model.AllTenants} {
+			_, err := rbacUsecase.AddRoleAssignment(superAdminContext(), model.RoleAssignment{Subject: "carol@example.com", Role: "admin", Tenant: tenant})
+			var validationErr *model.ValidationError
+			assert.True(t, errors.As(err, &validationErr))
+			assert.Equal(t, "tenant", validationErr.Field)
+		}
+		mockRepo.AssertNotCalled(t, "AddRoleAssignment", mock.Anything, mock.Anything)
+	})
+}
+
+func TestRBACUsecase_ReloadStatus(t *testing.T) {
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+	mockReloader := new(MockPolicyReloader)
+	rbacUsecase := usecase.NewRBACUsecase(new(MockPolicyRepository), mockReloader, new(MockPolicyExplainer), log)
+
+	expected := model.PolicyReloadStatus{Watching: true, ReloadCount: 2, LastStatus: model.PolicyReloadOK}
+	mockReloader.On("Status").Return(expected)
+
+	status, err := rbacUsecase.ReloadStatus(superAdminContext())
+	assert.NoError(t, err)
+	assert.Equal(t, &expected, status)
+
+	_, err = rbacUsecase.ReloadStatus(userContext())
+	assert.ErrorIs(t, err, model.ErrForbidden)
+}
+
+func TestRBACUsecase_Explain(t *testing.T) {
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+	mockExplainer := new(MockPolicyExplainer)
+	rbacUsecase := usecase.NewRBACUsecase(new(MockPolicyRepository), new(MockPolicyReloader), mockExplainer, log)
+
+	t.Run("Normalizes the query", func(t *testing.T) {
+		query := model.PolicyQuery{Subject: "bob@example.com", Tenant: "default", Object: "/api/v1/todos", Action: "GET"}
+		decision := &model.PolicyDecision{PolicyQuery: query, Allowed: true}
+		mockExplainer.On("Explain", mock.Anything, query).Return(decision, nil).Once()
+
+		result, err := rbacUsecase.Explain(superAdminContext(), model.PolicyQuery{Subject: " Bob@Example.com ", Tenant: "default", Object: "/api/v1/todos", Action: "get"})
+		assert.NoError(t, err)
+		assert.Equal(t, decision, result)
+		mockExplainer.AssertExpectations(t)
+	})
+
+	t.Run("Requires a single tenant", func(t *testing.T) {
+		_, err := rbacUsecase.Explain(superAdminContext(), model.PolicyQuery{Subject: "user", Tenant: "*", Object: "/api/v1/todos", Action: "GET"})
+
+		var validationErr *model.ValidationError
+		assert.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, "tenant", validationErr.Field)
+	})
+
+	t.Run("Requires superadmin", func(t *testing.T) {
+		_, err := rbacUsecase.Explain(userContext(), model.PolicyQuery{Subject: "user", Tenant: "default", Object: "/api/v1/todos", Action: "GET"})
+		assert.ErrorIs(t, err, model.ErrForbidden)
+	})
+}
+
+func TestRBACUsecase_DryRun(t *testing.T) {
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+	mockExplainer := new(MockPolicyExplainer)
+	rbacUsecase := usecase.NewRBACUsecase(new(MockPolicyRepository), new(MockPolicyReloader), mockExplainer, log)
+	policy := "p, user, *, /api/v1/todos, GET"
+
+	t.Run("Success", func(t *testing.T) {
+		cases := []model.PolicyCase{{PolicyQuery: model.PolicyQuery{Subject: "user", Tenant: "default", Object: "/api/v1/todos", Action: "GET"}, Allow: true}}
+		run := &model.PolicyDryRun{Passed: true, Results: []model.PolicyCaseResult{}}
+		mockExplainer.On("DryRun", mock.Anything, policy, cases).Return(run, nil).Once()
+
+		result, err := rbacUsecase.DryRun(superAdminContext(), policy, cases)
+		assert.NoError(t, err)
+		assert.Equal(t, run, result)
+		mockExplainer.AssertExpectations(t)
+	})
+
+	t.Run("Invalid case", func(t *testing.T) {
+		cases := []model.PolicyCase{
+			{PolicyQuery: model.PolicyQuery{Subject: "user", Tenant: "default", Object: "/api/v1/todos", Action: "GET"}},
+			{PolicyQuery: model.PolicyQuery{Subject: "user", Tenant: "default", Object: "/api/v1/todos", Action: "FETCH"}},
+		}
+		_, err := rbacUsecase.DryRun(superAdminContext(), policy, cases)
+
+		var validationErr *model.ValidationError
+		assert.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, "cases[1].action", validationErr.Field)
+	})
+
+	t.Run("Requires a policy and cases", func(t *testing.T) {
+		var validationErr *model.ValidationError
+		_, err := rbacUsecase.DryRun(superAdminContext(), " ", []model.PolicyCase{{}})
+		assert.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, "policy", validationErr.Field)
+
+		_, err = rbacUsecase.DryRun(superAdminContext(), policy, nil)
+		assert.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, "cases", validationErr.Field)
+	})
+
+	t.Run("Requires superadmin", func(t *testing.T) {
+		_, err := rbacUsecase.DryRun(userContext(), policy, []model.PolicyCase{{}})
+		assert.ErrorIs(t, err, model.ErrForbidden)
+	})
+}
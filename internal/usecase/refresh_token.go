@@ -0,0 +1,125 @@
+package usecase
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/auth"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+)
+
+// refreshTokenBytes is the amount of randomness in an opaque refresh token
+const refreshTokenBytes = 32
+
+// Refresh exchanges a refresh token for a new token pair
+func (u *authUsecase) Refresh(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	current, err := u.refreshTokens.GetByHash(ctx, hashSecret(refreshToken))
+	if errors.Is(err, model.ErrNotFound) {
+		return nil, model.ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if current.RevokedAt != nil {
+		return nil, model.ErrInvalidToken
+	}
+	if current.RotatedAt != nil {
+		return nil, u.revokeReusedFamily(ctx, current)
+	}
+	if !time.Now().Before(current.ExpiresAt) {
+		return nil, model.ErrInvalidToken
+	}
+
+	user, err := u.users.GetByID(ctx, current.UserID)
+	if errors.Is(err, model.ErrNotFound) {
+		return nil, model.ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	raw, next, err := u.newRefreshToken(user.ID, current.FamilyID, current.AMR, current.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.refreshTokens.Rotate(ctx, current, next); err != nil {
+		if errors.Is(err, model.ErrConflict) {
+			// Another request rotated the token first
+			return nil, u.revokeReusedFamily(ctx, current)
+		}
+		return nil, err
+	}
+
+	return u.tokenPair(user, raw, current.AMR, current.Scopes)
+}
+
+// issueTokens issues an access token and a refresh token starting a new token
+// family for a login with the given authentication methods, restricted to
+// the given scopes if any
+func (u *authUsecase) issueTokens(ctx context.Context, user *model.User, amr, scopes []string) (*TokenPair, error) {
+	familyID, err := randomToken(16, hex.EncodeToString)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, refreshToken, err := u.newRefreshToken(user.ID, familyID, amr, scopes)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.refreshTokens.Create(ctx, refreshToken); err != nil {
+		return nil, err
+	}
+
+	return u.tokenPair(user, raw, amr, scopes)
+}
+
+// tokenPair signs an access token for the user and pairs it with the refresh token
+func (u *authUsecase) tokenPair(user *model.User, refreshToken string, amr, scopes []string) (*TokenPair, error) {
+	accessToken, err := u.tokens.IssueToken(auth.TokenGrant{Email: user.Email, Tenant: user.Tenant, AMR: amr, Scopes: scopes})
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		ExpiresIn:    u.config.AccessTokenTTL(),
+		RefreshToken: refreshToken,
+		Scopes:       scopes,
+	}, nil
+}
+
+// newRefreshToken generates an opaque refresh token in the family and returns
+// it along with the record to persist
+func (u *authUsecase) newRefreshToken(userID uint, familyID string, amr, scopes []string) (string, *model.RefreshToken, error) {
+	raw, err := randomToken(refreshTokenBytes, base64.RawURLEncoding.EncodeToString)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return raw, &model.RefreshToken{
+		UserID:    userID,
+		FamilyID:  familyID,
+		AMR:       amr,
+		Scopes:    scopes,
+		TokenHash: hashSecret(raw),
+		ExpiresAt: time.Now().Add(u.config.RefreshTokenTTL()),
+	}, nil
+}
+
+// revokeReusedFamily revokes the family of a refresh token that was presented
+// after being rotated, since either the client or an attacker holds a stale copy
+func (u *authUsecase) revokeReusedFamily(ctx context.Context, token *model.RefreshToken) error {
+	u.logger.Warn("Refresh token reuse detected, revoking token family", map[string]interface{}{
+		"user_id":   token.UserID,
+		"family_id": token.FamilyID,
+	})
+
+	if err := u.refreshTokens.RevokeFamily(ctx, token.FamilyID); err != nil {
+		return err
+	}
+	return model.ErrInvalidToken
+}
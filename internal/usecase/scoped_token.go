@@ -0,0 +1,50 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/auth"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+)
+
+// IssueScopedTokens issues the caller a token pair restricted to the named roles
+func (u *authUsecase) IssueScopedTokens(ctx context.Context, scopes []string) (*TokenPair, error) {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, model.ErrUnauthenticated
+	}
+	// Services keep their scopes on their API keys, and impersonation must
+	// not outlive its own short-lived token
+	if principal.Service != "" || principal.Impersonated() {
+		return nil, model.ErrForbidden
+	}
+
+	scopes, err := normalizeScopes(scopes)
+	if err != nil {
+		return nil, err
+	}
+	if len(scopes) == 0 {
+		return nil, model.NewValidationError("scopes", "at least one role is required")
+	}
+	if !principal.HasScopes(scopes...) {
+		return nil, model.NewValidationError("scopes", "cannot widen the scopes of the current token")
+	}
+
+	// Users of an external identity provider have no account to refresh
+	user, err := u.users.GetByEmail(ctx, NormalizeEmail(principal.Email))
+	if errors.Is(err, model.ErrNotFound) {
+		return nil, model.ErrForbidden
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	u.logger.Info("Scoped tokens issued", map[string]interface{}{
+		"email":  user.Email,
+		"scopes": scopes,
+	})
+
+	// The new tokens keep the methods the caller authenticated with
+	return u.issueTokens(ctx, user, principal.AMR, scopes)
+}
@@ -0,0 +1,101 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/auth"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/revocation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthUsecase_IssueScopedTokens(t *testing.T) {
+	user := &model.User{ID: 1, Email: "user@example.com", Tenant: "default"}
+
+	t.Run("Scopes are carried by the access token and the refresh token family", func(t *testing.T) {
+		ctx := auth.WithPrincipal(context.Background(), &auth.Principal{
+			Email: "user@example.com",
+			AMR:   []string{auth.AMRPassword},
+		})
+		users := new(MockUserRepository)
+		refreshRepo := new(MockRefreshTokenRepository)
+		users.On("GetByEmail", ctx, "user@example.com").Return(user, nil).Once()
+		var created *model.RefreshToken
+		refreshRepo.On("Create", ctx, mock.AnythingOfType("*model.RefreshToken")).
+			Run(func(args mock.Arguments) { created = args.Get(1).(*model.RefreshToken) }).
+			Return(nil).Once()
+
+		tokens, err := newAuthUsecase(users, refreshRepo, revocation.NewMemoryStore()).IssueScopedTokens(ctx, []string{"viewer", "auditor", "viewer"})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"auditor", "viewer"}, tokens.Scopes)
+		assert.Equal(t, []string{"auditor", "viewer"}, created.Scopes)
+		assert.Equal(t, []string{auth.AMRPassword}, created.AMR)
+
+		claims, err := testTokenService.ValidateToken(tokens.AccessToken)
+		require.NoError(t, err)
+		assert.Equal(t, "auditor viewer", claims.Scope)
+		assert.Equal(t, []string{"auditor", "viewer"}, claims.Identity().Scopes)
+
+		// Refreshing keeps the restriction
+		ctx = context.Background()
+		created.ExpiresAt = time.Now().Add(time.Hour)
+		refreshRepo.On("GetByHash", ctx, sha256Hex(tokens.RefreshToken)).Return(created, nil).Once()
+		users.On("GetByID", ctx, uint(1)).Return(user, nil).Once()
+		refreshRepo.On("Rotate", ctx, created, mock.AnythingOfType("*model.RefreshToken")).Return(nil).Once()
+
+		refreshed, err := newAuthUsecase(users, refreshRepo, revocation.NewMemoryStore()).Refresh(ctx, tokens.RefreshToken)
+
+		require.NoError(t, err)
+		claims, err = testTokenService.ValidateToken(refreshed.AccessToken)
+		require.NoError(t, err)
+		assert.Equal(t, "auditor viewer", claims.Scope)
+		users.AssertExpectations(t)
+		refreshRepo.AssertExpectations(t)
+	})
+
+	t.Run("Scoped callers may only narrow their scopes", func(t *testing.T) {
+		ctx := auth.WithPrincipal(context.Background(), &auth.Principal{
+			Email:  "user@example.com",
+			Scopes: []string{"viewer"},
+		})
+
+		_, err := newAuthUsecase(new(MockUserRepository), new(MockRefreshTokenRepository), revocation.NewMemoryStore()).IssueScopedTokens(ctx, []string{"viewer", "editor"})
+
+		var validationErr *model.ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, "scopes", validationErr.Field)
+	})
+
+	t.Run("At least one scope is required", func(t *testing.T) {
+		_, err := newAuthUsecase(new(MockUserRepository), new(MockRefreshTokenRepository), revocation.NewMemoryStore()).IssueScopedTokens(userContext(), nil)
+
+		var validationErr *model.ValidationError
+		assert.ErrorAs(t, err, &validationErr)
+	})
+
+	t.Run("Services and impersonations are refused", func(t *testing.T) {
+		for _, principal := range []*auth.Principal{
+			{Email: "service:batch", Service: "batch"},
+			{Email: "user@example.com", Actor: "admin@example.com"},
+		} {
+			ctx := auth.WithPrincipal(context.Background(), principal)
+			_, err := newAuthUsecase(new(MockUserRepository), new(MockRefreshTokenRepository), revocation.NewMemoryStore()).IssueScopedTokens(ctx, []string{"viewer"})
+			assert.ErrorIs(t, err, model.ErrForbidden)
+		}
+	})
+
+	t.Run("Users without an account are refused", func(t *testing.T) {
+		ctx := userContext()
+		users := new(MockUserRepository)
+		users.On("GetByEmail", ctx, "user@example.com").Return(nil, model.ErrNotFound).Once()
+
+		_, err := newAuthUsecase(users, new(MockRefreshTokenRepository), revocation.NewMemoryStore()).IssueScopedTokens(ctx, []string{"viewer"})
+
+		assert.ErrorIs(t, err, model.ErrForbidden)
+	})
+}
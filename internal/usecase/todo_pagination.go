@@ -0,0 +1,199 @@
+package usecase
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/repository"
+)
+
+const (
+	// DefaultTodoPageSize is the page size used when no limit is requested
+	DefaultTodoPageSize = 20
+
+	// MaxTodoPageSize is the largest page size a caller may request
+	MaxTodoPageSize = 100
+)
+
+// TodoListParams describes a todo listing request
+type TodoListParams struct {
+	// Owner selects whose todos to list; empty means the caller
+	Owner string
+
+	Completed     *bool
+	Title         string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	UpdatedAfter  *time.Time
+	UpdatedBefore *time.Time
+
+	// Sort is a field name optionally prefixed with "-" for descending order
+	Sort string
+
+	// Limit is the page size; zero selects DefaultTodoPageSize
+	Limit int
+	// Offset skips the given number of todos; it cannot be combined with Cursor
+	Offset int
+	// Cursor is an opaque position returned as TodoPage.NextCursor
+	Cursor string
+}
+
+// TodoPage is a single page of a todo listing
+type TodoPage struct {
+	Items []model.Todo
+	// Total is the number of todos matching the filters across all pages
+	Total  int64
+	Limit  int
+	Offset int
+	// NextCursor resumes the listing after this page; empty on the last page
+	NextCursor string
+}
+
+// todoCursor is the decoded form of an opaque pagination cursor
+type todoCursor struct {
+	Sort  string          `json:"s"`
+	Value json.RawMessage `json:"v"`
+	ID    uint            `json:"id"`
+}
+
+// listOptions validates the params and converts them to repository options
+func (p TodoListParams) listOptions(ownerID string) (repository.TodoListOptions, error) {
+	opts := repository.TodoListOptions{
+		OwnerID:       ownerID,
+		Completed:     p.Completed,
+		Title:         strings.TrimSpace(p.Title),
+		CreatedAfter:  p.CreatedAfter,
+		CreatedBefore: p.CreatedBefore,
+		UpdatedAfter:  p.UpdatedAfter,
+		UpdatedBefore: p.UpdatedBefore,
+		Limit:         p.Limit,
+		Offset:        p.Offset,
+	}
+
+	switch {
+	case p.Limit == 0:
+		opts.Limit = DefaultTodoPageSize
+	case p.Limit < 0 || p.Limit > MaxTodoPageSize:
+		return opts, model.NewValidationError("limit", "must be between 1 and 100")
+	}
+	if p.Offset < 0 {
+		return opts, model.NewValidationError("offset", "must not be negative")
+	}
+
+	sort, err := parseTodoSort(p.Sort)
+	if err != nil {
+		return opts, err
+	}
+	opts.Sort = sort
+
+	if p.Cursor != "" {
+		if p.Offset > 0 {
+			return opts, model.NewValidationError("cursor", "cannot be combined with offset")
+		}
+		after, err := decodeTodoCursor(p.Cursor, sort)
+		if err != nil {
+			return opts, err
+		}
+		opts.After = after
+	}
+
+	return opts, nil
+}
+
+// parseTodoSort parses a sort expression such as "title" or "-created_at"
+func parseTodoSort(expr string) (repository.TodoSort, error) {
+	sort := repository.TodoSort{Field: repository.TodoSortID}
+	if expr == "" {
+		return sort, nil
+	}
+
+	if strings.HasPrefix(expr, "-") {
+		sort.Desc = true
+		expr = expr[1:]
+	}
+
+	for _, field := range repository.TodoSortFields {
+		if expr == field {
+			sort.Field = field
+			return sort, nil
+		}
+	}
+
+	return sort, model.NewValidationError("sort", "must be one of "+strings.Join(repository.TodoSortFields, ", ")+", optionally prefixed with -")
+}
+
+// sortExpression formats a sort back into its query form
+func sortExpression(sort repository.TodoSort) string {
+	if sort.Desc {
+		return "-" + sort.Field
+	}
+	return sort.Field
+}
+
+// encodeTodoCursor builds the opaque cursor pointing just after the todo
+func encodeTodoCursor(sort repository.TodoSort, todo model.Todo) string {
+	var value interface{}
+	switch sort.Field {
+	case repository.TodoSortTitle:
+		value = todo.Title
+	case repository.TodoSortCompleted:
+		value = todo.Completed
+	case repository.TodoSortCreatedAt:
+		value = todo.CreatedAt
+	case repository.TodoSortUpdatedAt:
+		value = todo.UpdatedAt
+	default:
+		value = todo.ID
+	}
+
+	raw, _ := json.Marshal(value)
+	data, _ := json.Marshal(todoCursor{
+		Sort:  sortExpression(sort),
+		Value: raw,
+		ID:    todo.ID,
+	})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeTodoCursor parses an opaque cursor produced for the given sort
+func decodeTodoCursor(encoded string, sort repository.TodoSort) (*repository.TodoCursor, error) {
+	invalid := model.NewValidationError("cursor", "is invalid or does not match the requested sort")
+
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, invalid
+	}
+
+	var cursor todoCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.Sort != sortExpression(sort) {
+		return nil, invalid
+	}
+
+	var value interface{}
+	switch sort.Field {
+	case repository.TodoSortTitle:
+		var title string
+		err = json.Unmarshal(cursor.Value, &title)
+		value = title
+	case repository.TodoSortCompleted:
+		var completed bool
+		err = json.Unmarshal(cursor.Value, &completed)
+		value = completed
+	case repository.TodoSortCreatedAt, repository.TodoSortUpdatedAt:
+		var at time.Time
+		err = json.Unmarshal(cursor.Value, &at)
+		value = at
+	default:
+		var id uint
+		err = json.Unmarshal(cursor.Value, &id)
+		value = id
+	}
+	if err != nil {
+		return nil, invalid
+	}
+
+	return &repository.TodoCursor{Value: value, ID: cursor.ID}, nil
+}
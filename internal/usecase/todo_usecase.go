@@ -2,39 +2,123 @@ package usecase
 
 import (
 	"context"
+	"strconv"
+	"strings"
+	"unicode/utf8"
 
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/auth"
 	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
 	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/repository"
 	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
 )
 
-// TodoUsecase defines the interface for todo business logic
+// maxTitleLength mirrors the size of the todos.title column
+const maxTitleLength = 255
+
+// ResourceAuthorizer decides whether a caller may act on a concrete resource
+// by comparing the resource's attributes with the caller
+type ResourceAuthorizer interface {
+	// AuthorizeResource reports whether the principal may perform the action on the resource
+	AuthorizeResource(ctx context.Context, principal *auth.Principal, action string, resource auth.Resource) (bool, error)
+}
+
+// TodoUsecase defines the interface for todo business logic.
+// Todos are owned by the principal stored in the context; callers see their
+// own todos, and modify the todos the resource authorizer allows.
 type TodoUsecase interface {
-	// List returns all todos
-	List(ctx context.Context) ([]model.Todo, error)
+	// List returns a page of the todos of params.Owner, or of the caller when
+	// it is empty. Only superadmins may list todos of other owners.
+	List(ctx context.Context, params TodoListParams) (*TodoPage, error)
+
+	// Get returns the todo with the given ID from the owner, or from the caller
+	// when owner is empty. Only superadmins may read todos of other owners.
+	Get(ctx context.Context, id uint, owner string) (*model.Todo, error)
 
 	// Create creates a new todo with the given title
 	Create(ctx context.Context, title string) (*model.Todo, error)
+
+	// Update replaces the title and completion state of the todo with the given ID.
+	// It returns model.ErrNotFound for todos of other owners the caller may not update.
+	Update(ctx context.Context, id uint, title string, completed bool) (*model.Todo, error)
+
+	// Patch applies the non-nil fields of the patch to the todo with the given ID
+	Patch(ctx context.Context, id uint, patch TodoPatch) (*model.Todo, error)
+
+	// Delete removes the todo with the given ID.
+	// It returns model.ErrNotFound for todos of other owners the caller may not delete.
+	Delete(ctx context.Context, id uint) error
+}
+
+// TodoPatch represents a partial update of a todo
+type TodoPatch struct {
+	Title     *string
+	Completed *bool
 }
 
 // todoUsecase implements the TodoUsecase interface
 type todoUsecase struct {
-	repo   repository.TodoRepository
-	logger *logger.Logger
+	repo       repository.TodoRepository
+	authorizer ResourceAuthorizer
+	logger     *logger.Logger
 }
 
-// NewTodoUsecase creates a new todo usecase
-func NewTodoUsecase(repo repository.TodoRepository, logger *logger.Logger) TodoUsecase {
+// NewTodoUsecase creates a new todo usecase. Updates and deletes are checked
+// with the authorizer; when it is nil only owners and superadmins may modify
+// todos.
+func NewTodoUsecase(repo repository.TodoRepository, authorizer ResourceAuthorizer, logger *logger.Logger) TodoUsecase {
 	return &todoUsecase{
-		repo:   repo,
-		logger: logger,
+		repo:       repo,
+		authorizer: authorizer,
+		logger:     logger,
 	}
 }
 
-// List returns all todos
-func (u *todoUsecase) List(ctx context.Context) ([]model.Todo, error) {
-	u.logger.Info("Listing all todos", map[string]interface{}{})
-	return u.repo.GetAll(ctx)
+// List returns a page of the todos of params.Owner, or of the caller when it is empty
+func (u *todoUsecase) List(ctx context.Context, params TodoListParams) (*TodoPage, error) {
+	ownerID, err := u.readableOwner(ctx, params.Owner)
+	if err != nil {
+		return nil, err
+	}
+
+	opts, err := params.listOptions(ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	u.logger.Info("Listing all todos", map[string]interface{}{
+		"owner": ownerID,
+	})
+
+	// Fetch one extra todo to learn whether another page follows
+	limit := opts.Limit
+	opts.Limit++
+	todos, total, err := u.repo.List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &TodoPage{
+		Items:  todos,
+		Total:  total,
+		Limit:  limit,
+		Offset: opts.Offset,
+	}
+	if len(todos) > limit {
+		page.Items = todos[:limit]
+		page.NextCursor = encodeTodoCursor(opts.Sort, page.Items[limit-1])
+	}
+
+	return page, nil
+}
+
+// Get returns the todo with the given ID from the owner, or from the caller when owner is empty
+func (u *todoUsecase) Get(ctx context.Context, id uint, owner string) (*model.Todo, error) {
+	ownerID, err := u.readableOwner(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.repo.GetByID(ctx, ownerID, id)
 }
 
 // Create creates a new todo with the given title
@@ -42,8 +126,19 @@ func (u *todoUsecase) Create(ctx context.Context, title string) (*model.Todo, er
 	u.logger.Info("Creating new todo", map[string]interface{}{
 		"title": title,
 	})
-	
+
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, model.ErrUnauthenticated
+	}
+
+	title, err := validateTitle(title)
+	if err != nil {
+		return nil, err
+	}
+
 	todo := &model.Todo{
+		OwnerID:   principal.ID,
 		Title:     title,
 		Completed: false,
 	}
@@ -57,3 +152,134 @@ func (u *todoUsecase) Create(ctx context.Context, title string) (*model.Todo, er
 
 	return todo, nil
 }
+
+// Update replaces the title and completion state of the todo with the given ID
+func (u *todoUsecase) Update(ctx context.Context, id uint, title string, completed bool) (*model.Todo, error) {
+	return u.Patch(ctx, id, TodoPatch{
+		Title:     &title,
+		Completed: &completed,
+	})
+}
+
+// Patch applies the non-nil fields of the patch to the todo with the given ID
+func (u *todoUsecase) Patch(ctx context.Context, id uint, patch TodoPatch) (*model.Todo, error) {
+	u.logger.Info("Updating todo", map[string]interface{}{
+		"id": id,
+	})
+
+	todo, err := u.authorizedTodo(ctx, id, auth.ActionUpdate)
+	if err != nil {
+		return nil, err
+	}
+
+	if patch.Title != nil {
+		title, err := validateTitle(*patch.Title)
+		if err != nil {
+			return nil, err
+		}
+		todo.Title = title
+	}
+	if patch.Completed != nil {
+		todo.Completed = *patch.Completed
+	}
+
+	if err := u.repo.Update(ctx, todo); err != nil {
+		u.logger.Error("Failed to update todo", map[string]interface{}{
+			"error": err.Error(),
+			"id":    id,
+		})
+		return nil, err
+	}
+
+	return todo, nil
+}
+
+// Delete removes the todo with the given ID
+func (u *todoUsecase) Delete(ctx context.Context, id uint) error {
+	todo, err := u.authorizedTodo(ctx, id, auth.ActionDelete)
+	if err != nil {
+		return err
+	}
+
+	u.logger.Info("Deleting todo", map[string]interface{}{
+		"id":    id,
+		"owner": todo.OwnerID,
+	})
+	return u.repo.Delete(ctx, todo.OwnerID, id)
+}
+
+// authorizedTodo returns the todo with the given ID if the caller may perform
+// the action on it. Todos of other owners the caller may not act on are
+// reported as not found, so their existence is not revealed.
+func (u *todoUsecase) authorizedTodo(ctx context.Context, id uint, action string) (*model.Todo, error) {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, model.ErrUnauthenticated
+	}
+
+	todo, err := u.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Superadmins may act on any todo, as they may call any route
+	owned := todo.OwnerID == principal.ID
+	allowed := owned || principal.IsSuperAdmin
+	if u.authorizer != nil && !principal.IsSuperAdmin {
+		resource := auth.Resource{
+			Type:   auth.ResourceTodo,
+			ID:     strconv.FormatUint(uint64(todo.ID), 10),
+			Owner:  todo.OwnerID,
+			Tenant: todo.Tenant,
+		}
+		allowed, err = u.authorizer.AuthorizeResource(ctx, principal, action, resource)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !allowed {
+		u.logger.Warn("Todo access denied", map[string]interface{}{
+			"id":     id,
+			"action": action,
+			"email":  principal.Email,
+		})
+		if !owned {
+			return nil, model.ErrNotFound
+		}
+		return nil, model.ErrForbidden
+	}
+	return todo, nil
+}
+
+// readableOwner resolves which owner's todos the caller is reading.
+// An empty owner means the caller's own todos; any other owner requires
+// superadmin privileges.
+func (u *todoUsecase) readableOwner(ctx context.Context, owner string) (string, error) {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return "", model.ErrUnauthenticated
+	}
+
+	if owner == "" || owner == principal.ID {
+		return principal.ID, nil
+	}
+
+	if !principal.IsSuperAdmin {
+		return "", model.ErrForbidden
+	}
+
+	return owner, nil
+}
+
+// validateTitle trims the title and checks it fits the todos.title column
+func validateTitle(title string) (string, error) {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return "", model.NewValidationError("title", "must not be empty")
+	}
+	if utf8.RuneCountInString(title) > maxTitleLength {
+		return "", model.NewValidationError("title", "must be at most 255 characters")
+	}
+	return title, nil
+}
@@ -4,8 +4,11 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/auth"
 	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/repository"
 	"github.com/bgaurav7/gin-microservice-boilerplate/internal/infrastructure/logger"
 	"github.com/bgaurav7/gin-microservice-boilerplate/internal/usecase"
 	"github.com/stretchr/testify/assert"
@@ -17,12 +20,12 @@ type MockTodoRepository struct {
 	mock.Mock
 }
 
-func (m *MockTodoRepository) GetAll(ctx context.Context) ([]model.Todo, error) {
-	args := m.Called(ctx)
+func (m *MockTodoRepository) List(ctx context.Context, opts repository.TodoListOptions) ([]model.Todo, int64, error) {
+	args := m.Called(ctx, opts)
 	if args.Get(0) == nil {
-		return nil, args.Error(1)
+		return nil, 0, args.Error(2)
 	}
-	return args.Get(0).([]model.Todo), args.Error(1)
+	return args.Get(0).([]model.Todo), args.Get(1).(int64), args.Error(2)
 }
 
 func (m *MockTodoRepository) Create(ctx context.Context, todo *model.Todo) error {
@@ -30,11 +33,71 @@ func (m *MockTodoRepository) Create(ctx context.Context, todo *model.Todo) error
 	return args.Error(0)
 }
 
+func (m *MockTodoRepository) GetByID(ctx context.Context, ownerID string, id uint) (*model.Todo, error) {
+	args := m.Called(ctx, ownerID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Todo), args.Error(1)
+}
+
+func (m *MockTodoRepository) FindByID(ctx context.Context, id uint) (*model.Todo, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Todo), args.Error(1)
+}
+
+func (m *MockTodoRepository) Update(ctx context.Context, todo *model.Todo) error {
+	args := m.Called(ctx, todo)
+	return args.Error(0)
+}
+
+func (m *MockTodoRepository) Delete(ctx context.Context, ownerID string, id uint) error {
+	args := m.Called(ctx, ownerID, id)
+	return args.Error(0)
+}
+
+// MockResourceAuthorizer is a mock implementation of the ResourceAuthorizer interface
+type MockResourceAuthorizer struct {
+	mock.Mock
+}
+
+func (m *MockResourceAuthorizer) AuthorizeResource(ctx context.Context, principal *auth.Principal, action string, resource auth.Resource) (bool, error) {
+	args := m.Called(ctx, principal, action, resource)
+	return args.Bool(0), args.Error(1)
+}
+
+// userContext returns a context carrying a regular user principal
+func userContext() context.Context {
+	return auth.WithPrincipal(context.Background(), &auth.Principal{
+		ID:    "user@example.com",
+		Email: "user@example.com",
+	})
+}
+
+// superAdminContext returns a context carrying a superadmin principal
+func superAdminContext() context.Context {
+	return auth.WithPrincipal(context.Background(), &auth.Principal{
+		ID:           "admin@example.com",
+		Email:        "admin@example.com",
+		IsSuperAdmin: true,
+	})
+}
+
+// ownedBy matches list options scoped to the given owner
+func ownedBy(ownerID string) interface{} {
+	return mock.MatchedBy(func(opts repository.TodoListOptions) bool {
+		return opts.OwnerID == ownerID
+	})
+}
+
 func TestTodoUsecase_List(t *testing.T) {
 	mockRepo := new(MockTodoRepository)
 	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
-	todoUsecase := usecase.NewTodoUsecase(mockRepo, log)
-	ctx := context.Background()
+	todoUsecase := usecase.NewTodoUsecase(mockRepo, nil, log)
+	ctx := userContext()
 
 	t.Run("Success", func(t *testing.T) {
 		expectedTodos := []model.Todo{
@@ -42,38 +105,130 @@ func TestTodoUsecase_List(t *testing.T) {
 			{ID: 2, Title: "Test Todo 2", Completed: true},
 		}
 
-		mockRepo.On("GetAll", ctx).Return(expectedTodos, nil).Once()
+		mockRepo.On("List", ctx, repository.TodoListOptions{
+			OwnerID: "user@example.com",
+			Sort:    repository.TodoSort{Field: repository.TodoSortID},
+			Limit:   usecase.DefaultTodoPageSize + 1,
+		}).Return(expectedTodos, int64(2), nil).Once()
 
-		todos, err := todoUsecase.List(ctx)
+		page, err := todoUsecase.List(ctx, usecase.TodoListParams{})
 
 		assert.NoError(t, err)
-		assert.Equal(t, expectedTodos, todos)
+		assert.Equal(t, expectedTodos, page.Items)
+		assert.Equal(t, int64(2), page.Total)
+		assert.Equal(t, usecase.DefaultTodoPageSize, page.Limit)
+		assert.Empty(t, page.NextCursor)
 		mockRepo.AssertExpectations(t)
 	})
 
 	t.Run("Error", func(t *testing.T) {
 		expectedError := errors.New("database error")
-		mockRepo.On("GetAll", ctx).Return(nil, expectedError).Once()
+		mockRepo.On("List", ctx, ownedBy("user@example.com")).Return(nil, int64(0), expectedError).Once()
 
-		todos, err := todoUsecase.List(ctx)
+		page, err := todoUsecase.List(ctx, usecase.TodoListParams{})
 
 		assert.Error(t, err)
 		assert.Equal(t, expectedError, err)
-		assert.Nil(t, todos)
+		assert.Nil(t, page)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Cursor Pagination", func(t *testing.T) {
+		created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+		todos := []model.Todo{
+			{ID: 7, Title: "a", CreatedAt: created.Add(2 * time.Hour)},
+			{ID: 5, Title: "b", CreatedAt: created.Add(time.Hour)},
+			{ID: 4, Title: "c", CreatedAt: created},
+		}
+		mockRepo.On("List", ctx, mock.MatchedBy(func(opts repository.TodoListOptions) bool {
+			return opts.After == nil && opts.Limit == 3 && opts.Sort.Field == repository.TodoSortCreatedAt && opts.Sort.Desc
+		})).Return(todos, int64(10), nil).Once()
+
+		page, err := todoUsecase.List(ctx, usecase.TodoListParams{Sort: "-created_at", Limit: 2})
+
+		assert.NoError(t, err)
+		assert.Len(t, page.Items, 2)
+		assert.NotEmpty(t, page.NextCursor)
+
+		// The cursor resumes strictly after the last todo of the page
+		mockRepo.On("List", ctx, mock.MatchedBy(func(opts repository.TodoListOptions) bool {
+			return opts.After != nil && opts.After.ID == 5 &&
+				opts.After.Value.(time.Time).Equal(created.Add(time.Hour))
+		})).Return(todos[2:], int64(10), nil).Once()
+
+		next, err := todoUsecase.List(ctx, usecase.TodoListParams{Sort: "-created_at", Limit: 2, Cursor: page.NextCursor})
+
+		assert.NoError(t, err)
+		assert.Len(t, next.Items, 1)
+		assert.Empty(t, next.NextCursor)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Invalid Params", func(t *testing.T) {
+		for name, params := range map[string]usecase.TodoListParams{
+			"limit":  {Limit: usecase.MaxTodoPageSize + 1},
+			"offset": {Offset: -1},
+			"sort":   {Sort: "owner_id"},
+			"cursor": {Cursor: "not-a-cursor"},
+		} {
+			page, err := todoUsecase.List(ctx, params)
+
+			var validationErr *model.ValidationError
+			assert.ErrorAs(t, err, &validationErr, name)
+			assert.Equal(t, name, validationErr.Field)
+			assert.Nil(t, page)
+		}
+	})
+
+	t.Run("Cursor From Other Sort", func(t *testing.T) {
+		mockRepo.On("List", ctx, ownedBy("user@example.com")).Return([]model.Todo{{ID: 1}, {ID: 2}}, int64(2), nil).Once()
+
+		page, err := todoUsecase.List(ctx, usecase.TodoListParams{Limit: 1})
+		assert.NoError(t, err)
+
+		_, err = todoUsecase.List(ctx, usecase.TodoListParams{Limit: 1, Sort: "title", Cursor: page.NextCursor})
+
+		var validationErr *model.ValidationError
+		assert.ErrorAs(t, err, &validationErr)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Other Owner Forbidden", func(t *testing.T) {
+		page, err := todoUsecase.List(ctx, usecase.TodoListParams{Owner: "bob@example.com"})
+
+		assert.ErrorIs(t, err, model.ErrForbidden)
+		assert.Nil(t, page)
+	})
+
+	t.Run("Superadmin Reads Other Owner", func(t *testing.T) {
+		adminCtx := superAdminContext()
+		mockRepo.On("List", adminCtx, ownedBy("bob@example.com")).Return([]model.Todo{}, int64(0), nil).Once()
+
+		page, err := todoUsecase.List(adminCtx, usecase.TodoListParams{Owner: "bob@example.com"})
+
+		assert.NoError(t, err)
+		assert.Empty(t, page.Items)
 		mockRepo.AssertExpectations(t)
 	})
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		page, err := todoUsecase.List(context.Background(), usecase.TodoListParams{})
+
+		assert.ErrorIs(t, err, model.ErrUnauthenticated)
+		assert.Nil(t, page)
+	})
 }
 
 func TestTodoUsecase_Create(t *testing.T) {
 	mockRepo := new(MockTodoRepository)
 	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
-	todoUsecase := usecase.NewTodoUsecase(mockRepo, log)
-	ctx := context.Background()
+	todoUsecase := usecase.NewTodoUsecase(mockRepo, nil, log)
+	ctx := userContext()
 
 	t.Run("Success", func(t *testing.T) {
 		title := "Test Todo"
 		mockRepo.On("Create", ctx, mock.MatchedBy(func(todo *model.Todo) bool {
-			return todo.Title == title && !todo.Completed
+			return todo.Title == title && !todo.Completed && todo.OwnerID == "user@example.com"
 		})).Return(nil).Once()
 
 		todo, err := todoUsecase.Create(ctx, title)
@@ -100,3 +255,195 @@ func TestTodoUsecase_Create(t *testing.T) {
 		mockRepo.AssertExpectations(t)
 	})
 }
+
+func TestTodoUsecase_CreateValidation(t *testing.T) {
+	mockRepo := new(MockTodoRepository)
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+	todoUsecase := usecase.NewTodoUsecase(mockRepo, nil, log)
+	ctx := userContext()
+
+	todo, err := todoUsecase.Create(ctx, "   ")
+
+	var validationErr *model.ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "title", validationErr.Field)
+	assert.Nil(t, todo)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestTodoUsecase_Get(t *testing.T) {
+	mockRepo := new(MockTodoRepository)
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+	todoUsecase := usecase.NewTodoUsecase(mockRepo, nil, log)
+	ctx := userContext()
+
+	t.Run("Success", func(t *testing.T) {
+		expectedTodo := &model.Todo{ID: 1, Title: "Test Todo"}
+		mockRepo.On("GetByID", ctx, "user@example.com", uint(1)).Return(expectedTodo, nil).Once()
+
+		todo, err := todoUsecase.Get(ctx, 1, "")
+
+		assert.NoError(t, err)
+		assert.Equal(t, expectedTodo, todo)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Not Found", func(t *testing.T) {
+		mockRepo.On("GetByID", ctx, "user@example.com", uint(2)).Return(nil, model.ErrNotFound).Once()
+
+		todo, err := todoUsecase.Get(ctx, 2, "")
+
+		assert.ErrorIs(t, err, model.ErrNotFound)
+		assert.Nil(t, todo)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestTodoUsecase_Patch(t *testing.T) {
+	mockRepo := new(MockTodoRepository)
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+	todoUsecase := usecase.NewTodoUsecase(mockRepo, nil, log)
+	ctx := userContext()
+
+	t.Run("Completes Todo", func(t *testing.T) {
+		completed := true
+		mockRepo.On("FindByID", ctx, uint(1)).Return(&model.Todo{ID: 1, OwnerID: "user@example.com", Title: "Test Todo"}, nil).Once()
+		mockRepo.On("Update", ctx, mock.MatchedBy(func(todo *model.Todo) bool {
+			return todo.ID == 1 && todo.Title == "Test Todo" && todo.Completed
+		})).Return(nil).Once()
+
+		todo, err := todoUsecase.Patch(ctx, 1, usecase.TodoPatch{Completed: &completed})
+
+		assert.NoError(t, err)
+		assert.True(t, todo.Completed)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Rejects Empty Title", func(t *testing.T) {
+		title := ""
+		mockRepo.On("FindByID", ctx, uint(1)).Return(&model.Todo{ID: 1, OwnerID: "user@example.com", Title: "Test Todo"}, nil).Once()
+
+		todo, err := todoUsecase.Patch(ctx, 1, usecase.TodoPatch{Title: &title})
+
+		var validationErr *model.ValidationError
+		assert.ErrorAs(t, err, &validationErr)
+		assert.Nil(t, todo)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Not Found", func(t *testing.T) {
+		title := "Renamed"
+		mockRepo.On("FindByID", ctx, uint(2)).Return(nil, model.ErrNotFound).Once()
+
+		todo, err := todoUsecase.Update(ctx, 2, title, false)
+
+		assert.ErrorIs(t, err, model.ErrNotFound)
+		assert.Nil(t, todo)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestTodoUsecase_Delete(t *testing.T) {
+	mockRepo := new(MockTodoRepository)
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+	todoUsecase := usecase.NewTodoUsecase(mockRepo, nil, log)
+	ctx := userContext()
+
+	mockRepo.On("FindByID", ctx, uint(1)).Return(&model.Todo{ID: 1, OwnerID: "user@example.com"}, nil).Once()
+	mockRepo.On("Delete", ctx, "user@example.com", uint(1)).Return(nil).Once()
+	mockRepo.On("FindByID", ctx, uint(2)).Return(nil, model.ErrNotFound).Once()
+	mockRepo.On("FindByID", ctx, uint(3)).Return(&model.Todo{ID: 3, OwnerID: "other@example.com"}, nil).Once()
+
+	assert.NoError(t, todoUsecase.Delete(ctx, 1))
+	assert.ErrorIs(t, todoUsecase.Delete(ctx, 2), model.ErrNotFound)
+	assert.ErrorIs(t, todoUsecase.Delete(ctx, 3), model.ErrNotFound)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestTodoUsecase_ResourceAuthorization(t *testing.T) {
+	log, _ := logger.NewLogger(&logger.Config{Level: "info"})
+	ctx := userContext()
+	principal, _ := auth.PrincipalFromContext(ctx)
+	othersTodo := auth.Resource{Type: auth.ResourceTodo, ID: "3", Owner: "other@example.com"}
+
+	t.Run("Allowed caller updates another owner's todo", func(t *testing.T) {
+		mockRepo := new(MockTodoRepository)
+		mockAuthorizer := new(MockResourceAuthorizer)
+		todoUsecase := usecase.NewTodoUsecase(mockRepo, mockAuthorizer, log)
+
+		mockRepo.On("FindByID", ctx, uint(3)).Return(&model.Todo{ID: 3, OwnerID: "other@example.com", Title: "Theirs"}, nil).Once()
+		mockAuthorizer.On("AuthorizeResource", ctx, principal, auth.ActionUpdate, othersTodo).Return(true, nil).Once()
+		mockRepo.On("Update", ctx, mock.MatchedBy(func(todo *model.Todo) bool {
+			return todo.ID == 3 && todo.OwnerID == "other@example.com" && todo.Title == "Renamed"
+		})).Return(nil).Once()
+
+		todo, err := todoUsecase.Update(ctx, 3, "Renamed", false)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "other@example.com", todo.OwnerID)
+		mockRepo.AssertExpectations(t)
+		mockAuthorizer.AssertExpectations(t)
+	})
+
+	t.Run("Allowed caller deletes another owner's todo", func(t *testing.T) {
+		mockRepo := new(MockTodoRepository)
+		mockAuthorizer := new(MockResourceAuthorizer)
+		todoUsecase := usecase.NewTodoUsecase(mockRepo, mockAuthorizer, log)
+
+		mockRepo.On("FindByID", ctx, uint(3)).Return(&model.Todo{ID: 3, OwnerID: "other@example.com"}, nil).Once()
+		mockAuthorizer.On("AuthorizeResource", ctx, principal, auth.ActionDelete, othersTodo).Return(true, nil).Once()
+		mockRepo.On("Delete", ctx, "other@example.com", uint(3)).Return(nil).Once()
+
+		assert.NoError(t, todoUsecase.Delete(ctx, 3))
+		mockRepo.AssertExpectations(t)
+		mockAuthorizer.AssertExpectations(t)
+	})
+
+	t.Run("Denied access to another owner's todo is reported as not found", func(t *testing.T) {
+		mockRepo := new(MockTodoRepository)
+		mockAuthorizer := new(MockResourceAuthorizer)
+		todoUsecase := usecase.NewTodoUsecase(mockRepo, mockAuthorizer, log)
+
+		mockRepo.On("FindByID", ctx, uint(3)).Return(&model.Todo{ID: 3, OwnerID: "other@example.com"}, nil).Once()
+		mockAuthorizer.On("AuthorizeResource", ctx, principal, auth.ActionDelete, othersTodo).Return(false, nil).Once()
+
+		assert.ErrorIs(t, todoUsecase.Delete(ctx, 3), model.ErrNotFound)
+		mockRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Denied access to an own todo is forbidden", func(t *testing.T) {
+		mockRepo := new(MockTodoRepository)
+		mockAuthorizer := new(MockResourceAuthorizer)
+		todoUsecase := usecase.NewTodoUsecase(mockRepo, mockAuthorizer, log)
+
+		mockRepo.On("FindByID", ctx, uint(1)).Return(&model.Todo{ID: 1, OwnerID: "user@example.com"}, nil).Once()
+		mockAuthorizer.On("AuthorizeResource", ctx, principal, auth.ActionUpdate, mock.Anything).Return(false, nil).Once()
+
+		_, err := todoUsecase.Update(ctx, 1, "Renamed", false)
+		assert.ErrorIs(t, err, model.ErrForbidden)
+	})
+
+	t.Run("Authorizer errors are returned", func(t *testing.T) {
+		mockRepo := new(MockTodoRepository)
+		mockAuthorizer := new(MockResourceAuthorizer)
+		todoUsecase := usecase.NewTodoUsecase(mockRepo, mockAuthorizer, log)
+
+		mockRepo.On("FindByID", ctx, uint(1)).Return(&model.Todo{ID: 1, OwnerID: "user@example.com"}, nil).Once()
+		mockAuthorizer.On("AuthorizeResource", ctx, principal, auth.ActionDelete, mock.Anything).Return(false, errors.New("matcher failed")).Once()
+
+		assert.EqualError(t, todoUsecase.Delete(ctx, 1), "matcher failed")
+	})
+
+	t.Run("Superadmins are not checked", func(t *testing.T) {
+		mockRepo := new(MockTodoRepository)
+		mockAuthorizer := new(MockResourceAuthorizer)
+		todoUsecase := usecase.NewTodoUsecase(mockRepo, mockAuthorizer, log)
+		adminCtx := superAdminContext()
+
+		mockRepo.On("FindByID", adminCtx, uint(3)).Return(&model.Todo{ID: 3, OwnerID: "other@example.com"}, nil).Once()
+		mockRepo.On("Delete", adminCtx, "other@example.com", uint(3)).Return(nil).Once()
+
+		assert.NoError(t, todoUsecase.Delete(adminCtx, 3))
+		mockAuthorizer.AssertNotCalled(t, "AuthorizeResource", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,101 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/auth"
+	"github.com/bgaurav7/gin-microservice-boilerplate/internal/domain/model"
+)
+
+// Logout revokes the caller's access token and optionally its refresh token family
+func (u *authUsecase) Logout(ctx context.Context, refreshToken string) error {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return model.ErrUnauthenticated
+	}
+
+	if principal.TokenID != "" {
+		if err := u.revocations.RevokeToken(ctx, principal.TokenID, principal.TokenExpiresAt); err != nil {
+			return err
+		}
+	}
+
+	if refreshToken != "" {
+		if err := u.revokeOwnFamily(ctx, principal, refreshToken); err != nil {
+			return err
+		}
+	}
+
+	u.logger.Info("User logged out", map[string]interface{}{
+		"email": principal.Email,
+	})
+
+	return nil
+}
+
+// RevokeUserTokens revokes every access and refresh token issued to the account so far
+func (u *authUsecase) RevokeUserTokens(ctx context.Context, email string) error {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return model.ErrUnauthenticated
+	}
+	if !principal.IsSuperAdmin {
+		return model.ErrForbidden
+	}
+
+	email = NormalizeEmail(email)
+	if err := ValidateEmail(email); err != nil {
+		return err
+	}
+
+	// Access tokens carry the email as their subject
+	if err := u.revocations.RevokeSubject(ctx, email, time.Now()); err != nil {
+		return err
+	}
+
+	// Accounts that only exist with an external identity provider have no refresh tokens
+	user, err := u.users.GetByEmail(ctx, email)
+	if err != nil && !errors.Is(err, model.ErrNotFound) {
+		return err
+	}
+	if user != nil {
+		if err := u.refreshTokens.RevokeUser(ctx, user.ID); err != nil {
+			return err
+		}
+	}
+
+	u.logger.Warn("Revoked all tokens of user", map[string]interface{}{
+		"email":      email,
+		"revoked_by": principal.Email,
+	})
+
+	return nil
+}
+
+// revokeOwnFamily revokes the family of the refresh token if it belongs to the
+// caller. Unknown tokens and tokens of other users are ignored so logout does
+// not reveal whether a token exists.
+func (u *authUsecase) revokeOwnFamily(ctx context.Context, principal *auth.Principal, refreshToken string) error {
+	token, err := u.refreshTokens.GetByHash(ctx, hashSecret(refreshToken))
+	if errors.Is(err, model.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	user, err := u.users.GetByID(ctx, token.UserID)
+	if errors.Is(err, model.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if user.Email != principal.Email {
+		return nil
+	}
+
+	return u.refreshTokens.RevokeFamily(ctx, token.FamilyID)
+}
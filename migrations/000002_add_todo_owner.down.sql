@@ -0,0 +1,4 @@
+-- Drop todo ownership
+DROP INDEX IF EXISTS idx_todos_owner_id;
+
+ALTER TABLE todos DROP COLUMN IF EXISTS owner_id;
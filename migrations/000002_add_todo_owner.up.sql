@@ -0,0 +1,12 @@
+-- Add todo ownership
+-- Every todo belongs to the user that created it. Rows created before
+-- ownership existed are assigned to the 'system' owner; superadmins can
+-- still read them with GET /api/v1/todos?owner=system.
+
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS owner_id VARCHAR(255);
+
+UPDATE todos SET owner_id = 'system' WHERE owner_id IS NULL;
+
+ALTER TABLE todos ALTER COLUMN owner_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_todos_owner_id ON todos (owner_id);
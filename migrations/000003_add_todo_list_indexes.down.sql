@@ -0,0 +1,4 @@
+-- Drop todo listing indexes
+DROP INDEX IF EXISTS idx_todos_owner_updated_at;
+
+DROP INDEX IF EXISTS idx_todos_owner_created_at;
@@ -0,0 +1,6 @@
+-- Add indexes backing todo listing
+-- Listings are always scoped to an owner and paginated by (sort column, id).
+
+CREATE INDEX IF NOT EXISTS idx_todos_owner_created_at ON todos (owner_id, created_at, id);
+
+CREATE INDEX IF NOT EXISTS idx_todos_owner_updated_at ON todos (owner_id, updated_at, id);
@@ -0,0 +1,3 @@
+-- Drop token revocation tables
+DROP TABLE IF EXISTS subject_revocations;
+DROP TABLE IF EXISTS revoked_tokens;
@@ -0,0 +1,16 @@
+-- Create token revocation tables
+-- revoked_tokens holds individually revoked access tokens by jti until they expire.
+-- subject_revocations holds a per-subject cutoff: tokens issued at or before it are rejected.
+
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    token_id VARCHAR(64) PRIMARY KEY,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    revoked_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_expires_at ON revoked_tokens (expires_at);
+
+CREATE TABLE IF NOT EXISTS subject_revocations (
+    subject VARCHAR(255) PRIMARY KEY,
+    revoked_before TIMESTAMP WITH TIME ZONE NOT NULL
+);
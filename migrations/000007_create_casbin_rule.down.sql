@@ -0,0 +1,2 @@
+-- Drop casbin_rule table
+DROP TABLE IF EXISTS casbin_rule;
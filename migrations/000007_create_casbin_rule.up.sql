@@ -0,0 +1,15 @@
+-- Create casbin_rule table
+-- Holds Casbin policy (p) and role (g) rules; unused value columns are empty strings.
+
+CREATE TABLE IF NOT EXISTS casbin_rule (
+    id BIGSERIAL PRIMARY KEY,
+    ptype VARCHAR(100) NOT NULL,
+    v0 VARCHAR(100) NOT NULL DEFAULT '',
+    v1 VARCHAR(100) NOT NULL DEFAULT '',
+    v2 VARCHAR(100) NOT NULL DEFAULT '',
+    v3 VARCHAR(100) NOT NULL DEFAULT '',
+    v4 VARCHAR(100) NOT NULL DEFAULT '',
+    v5 VARCHAR(100) NOT NULL DEFAULT ''
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_casbin_rule ON casbin_rule (ptype, v0, v1, v2, v3, v4, v5);
@@ -0,0 +1,12 @@
+-- Drop tenants
+UPDATE casbin_rule SET v1 = v2, v2 = v3, v3 = v4, v4 = '' WHERE ptype = 'p2' AND v4 <> '';
+
+UPDATE casbin_rule SET v1 = v2, v2 = v3, v3 = '' WHERE ptype = 'p' AND v3 <> '';
+
+UPDATE casbin_rule SET v2 = '' WHERE ptype = 'g';
+
+DROP INDEX IF EXISTS idx_todos_tenant_owner_id;
+
+ALTER TABLE todos DROP COLUMN IF EXISTS tenant;
+
+ALTER TABLE users DROP COLUMN IF EXISTS tenant;
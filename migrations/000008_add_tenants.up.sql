@@ -0,0 +1,17 @@
+-- Add tenants
+-- Users and todos belong to a tenant. Rows created before tenants existed are
+-- assigned to the 'default' tenant, matching auth.default_tenant. Casbin rules
+-- gain a domain: role assignments apply in 'default' and permission rules in
+-- every tenant ('*'), so existing rules keep granting what they did.
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS tenant VARCHAR(255) NOT NULL DEFAULT 'default';
+
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS tenant VARCHAR(255) NOT NULL DEFAULT 'default';
+
+CREATE INDEX IF NOT EXISTS idx_todos_tenant_owner_id ON todos (tenant, owner_id);
+
+UPDATE casbin_rule SET v2 = 'default' WHERE ptype = 'g' AND v2 = '';
+
+UPDATE casbin_rule SET v3 = v2, v2 = v1, v1 = '*' WHERE ptype = 'p' AND v3 = '';
+
+UPDATE casbin_rule SET v4 = v3, v3 = v2, v2 = v1, v1 = '*' WHERE ptype = 'p2' AND v4 = '';
@@ -0,0 +1,22 @@
+-- Create api_keys table
+-- API keys authenticate services rather than users. Only a SHA-256 hash of
+-- each key is stored; its public prefix identifies the key in listings and
+-- logs. Scopes are stored as a JSON array of the roles the key may act with.
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    service VARCHAR(64) NOT NULL,
+    tenant VARCHAR(255) NOT NULL DEFAULT 'default',
+    prefix VARCHAR(32) NOT NULL,
+    key_hash VARCHAR(64) NOT NULL,
+    scopes TEXT NOT NULL DEFAULT '[]',
+    expires_at TIMESTAMP WITH TIME ZONE,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    created_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_keys_prefix ON api_keys (prefix);
+CREATE INDEX IF NOT EXISTS idx_api_keys_service ON api_keys (service);
@@ -0,0 +1,2 @@
+-- Drop login_challenges table
+DROP TABLE IF EXISTS login_challenges;
@@ -0,0 +1,16 @@
+-- Create login_challenges table
+-- A login challenge backs a passwordless login email. The link in the email
+-- names the challenge; the 6-digit code is only stored as a SHA-256 hash.
+-- Using either sets used_at, so each challenge logs in at most once.
+
+CREATE TABLE IF NOT EXISTS login_challenges (
+    id SERIAL PRIMARY KEY,
+    email VARCHAR(255) NOT NULL,
+    code_hash VARCHAR(64) NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_challenges_email ON login_challenges (email);
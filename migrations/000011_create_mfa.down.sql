@@ -0,0 +1,4 @@
+-- Drop multi-factor authentication tables
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS amr;
+DROP TABLE IF EXISTS recovery_codes;
+DROP TABLE IF EXISTS totp_factors;
@@ -0,0 +1,12 @@
+-- Create login_attempts table
+-- Counts recent failed logins per key, "account:<email>" or "ip:<address>",
+-- to slow down and lock out password guessing. A row whose last failure is
+-- older than the lockout window no longer counts and is pruned.
+
+CREATE TABLE IF NOT EXISTS login_attempts (
+    key VARCHAR(320) PRIMARY KEY,
+    failures INTEGER NOT NULL,
+    last_failure_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_attempts_last_failure_at ON login_attempts (last_failure_at);
@@ -0,0 +1,2 @@
+-- Remove scopes from refresh tokens
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS scopes;
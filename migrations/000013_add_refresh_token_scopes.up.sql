@@ -0,0 +1,5 @@
+-- Add scopes to refresh tokens
+-- refresh_tokens.scopes carries the roles a scoped login is restricted to, as
+-- a JSON array, into refreshed access tokens. Existing tokens are unscoped.
+
+ALTER TABLE refresh_tokens ADD COLUMN IF NOT EXISTS scopes TEXT NOT NULL DEFAULT '[]';
@@ -0,0 +1,11 @@
+-- Restore wildcard todo paths
+
+DELETE FROM casbin_rule r
+WHERE r.ptype = 'p' AND r.v2 = '/api/v1/todos/:id'
+  AND EXISTS (
+    SELECT 1 FROM casbin_rule t
+    WHERE t.ptype = 'p' AND t.v0 = r.v0 AND t.v1 = r.v1 AND t.v2 = '/api/v1/todos/*'
+      AND t.v3 = r.v3 AND t.v4 = r.v4 AND t.v5 = r.v5
+  );
+
+UPDATE casbin_rule SET v2 = '/api/v1/todos/*' WHERE ptype = 'p' AND v2 = '/api/v1/todos/:id';
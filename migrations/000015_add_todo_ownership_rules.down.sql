@@ -0,0 +1,8 @@
+-- Drop todo ownership rules
+-- Only the rules the up migration added are removed; rules seeded from
+-- policy.csv or created through the API are kept.
+
+DELETE FROM casbin_rule
+WHERE id IN (SELECT id FROM casbin_rule_000015_added);
+
+DROP TABLE IF EXISTS casbin_rule_000015_added;
@@ -0,0 +1,29 @@
+-- Add todo ownership rules
+-- Users may modify the todos they own and admins any todo, as decided by the
+-- p2 rules policy.csv seeds. Stores seeded before those rules existed gain
+-- them here, with the route rules that let users reach the usecase check.
+-- Empty stores are left to be seeded from policy.csv. The rules added are
+-- recorded so the down migration removes only those.
+
+CREATE TABLE IF NOT EXISTS casbin_rule_000015_added (
+    id BIGINT PRIMARY KEY
+);
+
+WITH added AS (
+    INSERT INTO casbin_rule (ptype, v0, v1, v2, v3, v4)
+    SELECT rule.ptype, rule.v0, rule.v1, rule.v2, rule.v3, rule.v4
+    FROM (VALUES
+        ('p', 'user', '*', '/api/v1/todos/:id', 'PUT', ''),
+        ('p', 'user', '*', '/api/v1/todos/:id', 'PATCH', ''),
+        ('p', 'user', '*', '/api/v1/todos/:id', 'DELETE', ''),
+        ('p2', 'admin', '*', 'todo', 'update', 'any'),
+        ('p2', 'admin', '*', 'todo', 'delete', 'any'),
+        ('p2', 'user', '*', 'todo', 'update', 'own'),
+        ('p2', 'user', '*', 'todo', 'delete', 'own')
+    ) AS rule (ptype, v0, v1, v2, v3, v4)
+    WHERE EXISTS (SELECT 1 FROM casbin_rule)
+    ON CONFLICT DO NOTHING
+    RETURNING id
+)
+INSERT INTO casbin_rule_000015_added (id)
+SELECT id FROM added;
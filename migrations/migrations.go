@@ -0,0 +1,10 @@
+// Package migrations embeds the versioned SQL schema migrations so the
+// server binary can apply them without access to the source tree.
+package migrations
+
+import "embed"
+
+// FS holds the NNNNNN_name.up.sql and NNNNNN_name.down.sql migration files
+//
+//go:embed *.sql
+var FS embed.FS